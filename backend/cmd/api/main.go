@@ -1,60 +1,184 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/lifecycle"
+	"github.com/Noblefel/ManorTalk/backend/internal/migrate"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
 	"github.com/Noblefel/ManorTalk/backend/internal/router"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/admin"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/auth"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/media"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/moderation"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/post"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/user"
-	"github.com/joho/godotenv"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/migrations"
 )
 
 func main() {
-	// If not in production, the application loads the local .env file.
 	prod := flag.Bool("production", true, "Run in production mode")
+	configFile := flag.String("config", "", "Path to a yaml config file, the environment overrides it")
+	runMigrations := flag.Bool("migrate", false, "Apply the pending migrations before starting")
 	flag.Parse()
 
-	if !*prod {
-		if err := godotenv.Load(); err != nil {
-			log.Fatal(err)
-		}
+	c, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	c := config.Default().WithProductionMode(*prod)
+	c.WithProductionMode(*prod)
 
 	db, err := database.Connect(c)
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer db.Sql.Close()
-	defer db.Redis.Close()
+
+	// Stopped from last to first, so the connections close after everything
+	// that may still use them
+	lc := lifecycle.New()
+	lc.OnStop("postgres", func(context.Context) error { return db.Sql.Close() })
+	lc.OnStop("redis", func(context.Context) error { return db.Redis.Close() })
+
+	if *runMigrations {
+		if err := migrateUp(db); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	userRepo := postgres.NewUserRepo(db)
 	postRepo := postgres.NewPostRepo(db)
+	mediaRepo := postgres.NewMediaRepo(db)
+	reportRepo := postgres.NewReportRepo(db)
+	auditRepo := postgres.NewAuditRepo(db)
 	cacheRepo := redis.NewRepo(db)
+	txManager := postgres.NewTxManager(db)
+
+	auditLogger := audit.New(auditRepo)
 
-	authService := auth.NewAuthService(c, cacheRepo, userRepo)
-	userService := user.NewUserService(c, cacheRepo, userRepo)
-	postService := post.NewPostService(c, cacheRepo, postRepo)
+	store, err := storage.New(c.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	router := router.NewRouter(c, authService, userService, postService)
+	authService := auth.NewAuthService(c, cacheRepo, userRepo, auditLogger)
+	userService := user.NewUserService(c, cacheRepo, userRepo, txManager, store, auditLogger)
+	postService := post.NewPostService(c, cacheRepo, postRepo, txManager, store, auditLogger)
+	mediaService := media.NewMediaService(c, mediaRepo, store)
+	moderationService := moderation.NewModerationService(c, cacheRepo, reportRepo, postRepo, userRepo, txManager, auditLogger)
+	adminService := admin.NewAdminService(c, auditRepo, userRepo)
+
+	lc.Go("purge trash", func(ctx context.Context) { purgeTrash(ctx, postService) })
+	lc.Go("collect media", func(ctx context.Context) { collectMedia(ctx, mediaService) })
+
+	router := router.NewRouter(c, cacheRepo, userRepo, db.Checks(), authService, userService, postService, mediaService, moderationService, adminService)
 
 	server := &http.Server{
-		Addr:    fmt.Sprint("localhost:", c.Port),
-		Handler: router.Routes(),
+		Addr:              net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Handler:           router.Routes(),
+		ReadHeaderTimeout: c.Server.ReadHeaderTimeout,
+		ReadTimeout:       c.Server.ReadTimeout,
+		WriteTimeout:      c.Server.WriteTimeout,
+		IdleTimeout:       c.Server.IdleTimeout,
 	}
+	lc.OnStop("http server", server.Shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	log.Println("Starting server at port:", c.Port)
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Println("Starting server at:", server.Addr)
+		serveErr <- server.ListenAndServe()
+	}()
 
-	if err = server.ListenAndServe(); err != nil {
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		log.Println("Shutting down, send the signal again to force")
+	}
+
+	// Restores the default handling so a second signal kills the process
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.Server.ShutdownTimeout)
+	err = errors.Join(err, lc.Shutdown(shutdownCtx))
+	cancel()
+
+	if err != nil {
 		log.Fatal(err)
 	}
 }
+
+// migrateUp applies the pending migrations. Instances starting together wait
+// on each other through the migrator's lock.
+func migrateUp(db *database.DB) error {
+	m, err := migrate.New(db.Sql, migrations.FS, migrate.MigrationsTable)
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.Up(context.Background())
+	for _, mig := range applied {
+		log.Printf("Applied migration %04d_%s", mig.Version, mig.Name)
+	}
+
+	return err
+}
+
+// purgeTrash periodically removes posts that have stayed in the trash past
+// the retention period, until the context is canceled.
+func purgeTrash(ctx context.Context, ps post.PostService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		n, err := ps.PurgeTrash(ctx)
+		if err != nil {
+			log.Println(err)
+		} else if n > 0 {
+			log.Println("Purged trashed posts:", n)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// collectMedia periodically removes uploaded media that no post uses anymore
+func collectMedia(ctx context.Context, ms media.MediaService) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		n, err := ms.CollectGarbage(ctx)
+		if err != nil {
+			log.Println(err)
+		} else if n > 0 {
+			log.Println("Removed unused media:", n)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
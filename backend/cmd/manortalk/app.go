@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/auth"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/media"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/post"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+)
+
+// cliClient is recorded in the audit log for the changes made from here
+var cliClient = audit.Client{UserAgent: "manortalk cli"}
+
+// app holds the same repositories and services the api is built from
+type app struct {
+	c         *config.AppConfig
+	db        *database.DB
+	userRepo  repository.UserRepo
+	postRepo  repository.PostRepo
+	mediaRepo repository.MediaRepo
+	cacheRepo repository.CacheRepo
+	store     storage.Store
+	audit     audit.Logger
+
+	authService  auth.AuthService
+	postService  post.PostService
+	mediaService media.MediaService
+}
+
+func newApp(c *config.AppConfig) (*app, error) {
+	db, err := database.Connect(c)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := storage.New(c.Storage)
+	if err != nil {
+		db.Sql.Close()
+		db.Redis.Close()
+		return nil, err
+	}
+
+	a := &app{
+		c:         c,
+		db:        db,
+		userRepo:  postgres.NewUserRepo(db),
+		postRepo:  postgres.NewPostRepo(db),
+		mediaRepo: postgres.NewMediaRepo(db),
+		cacheRepo: redis.NewRepo(db),
+		store:     store,
+		audit:     audit.New(postgres.NewAuditRepo(db)),
+	}
+
+	a.authService = auth.NewAuthService(c, a.cacheRepo, a.userRepo, a.audit)
+	a.postService = post.NewPostService(c, a.cacheRepo, a.postRepo, postgres.NewTxManager(db), store, a.audit)
+	a.mediaService = media.NewMediaService(c, a.mediaRepo, store)
+
+	return a, nil
+}
+
+func (a *app) close() {
+	a.db.Sql.Close()
+	a.db.Redis.Close()
+}
+
+// newFlagSet is for the flags of a subcommand, which come after its name
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseArgs parses the flags and checks the number of positional arguments
+func parseArgs(fs *flag.FlagSet, args []string, names ...string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%s: %w", fs.Name(), err)
+	}
+
+	if fs.NArg() != len(names) {
+		return nil, fmt.Errorf("%s needs %d arguments: %v", fs.Name(), len(names), names)
+	}
+
+	return fs.Args(), nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+)
+
+// runConfig prints the settings after the file and environment are applied,
+// which is the quickest way to see what a deploy actually runs with
+func runConfig(c *config.AppConfig, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("unknown or missing subcommand, see -help")
+	}
+
+	fs := newFlagSet("config print")
+	redact := fs.Bool("redacted", false, "Mask the keys and passwords")
+
+	if _, err := parseArgs(fs, args[1:]); err != nil {
+		return err
+	}
+
+	return c.Write(os.Stdout, *redact)
+}
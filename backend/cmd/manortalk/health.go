@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/migrate"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/migrations"
+)
+
+// runHealth checks every dependency on its own, so one being down doesn't
+// hide the state of the others
+func runHealth(ctx context.Context, c *config.AppConfig) error {
+	type check struct {
+		name, detail string
+		err          error
+	}
+
+	var checks []check
+
+	db, err := database.ConnectSQL(c)
+	if err == nil {
+		defer db.Close()
+	}
+	checks = append(checks, check{"postgres", fmt.Sprintf("%s:%d/%s", c.DB.Host, c.DB.Port, c.DB.Name), err})
+
+	if db != nil {
+		detail, err := pendingMigrations(ctx, db)
+		checks = append(checks, check{"migrations", detail, err})
+	}
+
+	rdb, err := database.ConnectRedis(c)
+	if err == nil {
+		defer rdb.Close()
+	}
+	checks = append(checks, check{"redis", fmt.Sprintf("%s:%d", c.DB.RedisHost, c.DB.RedisPort), err})
+
+	store, err := storage.New(c.Storage)
+	if err == nil {
+		_, err = store.List(ctx, "health/")
+	}
+	driver := c.Storage.Driver
+	if driver == "" {
+		driver = "local"
+	}
+	checks = append(checks, check{"storage", driver, err})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+
+	failed := 0
+	for _, ch := range checks {
+		status, detail := "ok", ch.detail
+		if ch.err != nil {
+			status, detail = "failed", ch.err.Error()
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", ch.name, status, detail)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+
+	return nil
+}
+
+func pendingMigrations(ctx context.Context, db *sql.DB) (string, error) {
+	m, err := migrate.New(db, migrations.FS, migrate.MigrationsTable)
+	if err != nil {
+		return "", err
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	pending := 0
+	for _, s := range statuses {
+		if s.AppliedAt == nil {
+			pending++
+		}
+	}
+
+	if pending > 0 {
+		return "", fmt.Errorf("%d pending, run migrate up", pending)
+	}
+
+	return fmt.Sprint("at version ", m.Latest()), nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+)
+
+const usage = `Usage: manortalk [-production=false] [-config FILE] <command> [flags] [arguments]
+
+Database:
+  migrate up                     apply every pending migration
+  migrate down                   revert the latest applied migration
+  migrate to N                   migrate up or down to version N, 0 reverts them all
+  migrate status                 list the migrations and when they were applied
+  migrate force N                record up to version N as applied without running anything
+  seed [up|down|...]             the same as migrate but for the seeders, up by default
+
+Users:
+  user create [-role R] NAME EMAIL
+                                 register a user, the password is read from
+                                 MANORTALK_PASSWORD or generated
+  user role NAME ROLE            set the role to user, moderator or admin
+  user reset-password NAME       set a generated password and log the user out
+  user sessions                  list the users with a refresh token
+  user logout NAME               revoke the refresh token of the user
+
+Maintenance:
+  post purge-trash               remove the posts past the trash retention
+  post backfill                  store the rendered content, excerpt, word
+                                 count and reading time of the posts written
+                                 before they were stored
+  media gc                       remove the uploaded media no post uses
+  images reconcile [-delete] [-min-age D]
+                                 report, or delete, orphaned and missing images
+  images backfill                record the variants stored for the images
+                                 uploaded before variants were recorded
+  cache flush                    drop the cached posts, profiles and categories
+  health                         check postgres, migrations, redis and storage
+
+Config:
+  config print [--redacted]      print the settings in use, as a config file
+`
+
+// manortalk runs the maintenance commands of the backend
+func main() {
+	prod := flag.Bool("production", true, "Run in production mode")
+	configFile := flag.String("config", "", "Path to a yaml config file, the environment overrides it")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	c, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c.WithProductionMode(*prod)
+
+	// Canceling rolls back the migration in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch args[0] {
+	case "migrate":
+		err = runMigrate(ctx, c, args[1:])
+	case "seed":
+		if len(args) == 1 {
+			args = append(args, "up")
+		}
+
+		err = runSeed(ctx, c, args[1:])
+	case "config":
+		err = runConfig(c, args[1:])
+	case "health":
+		err = runHealth(ctx, c)
+	case "user", "post", "media", "images", "cache":
+		err = runWithApp(ctx, c, args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		stop()
+		log.Fatal(err)
+	}
+}
+
+// runWithApp runs the commands that need the repositories and services
+func runWithApp(ctx context.Context, c *config.AppConfig, args []string) error {
+	a, err := newApp(c)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	switch args[0] {
+	case "user":
+		return runUser(ctx, a, args[1:])
+	case "post":
+		return runPost(ctx, a, args[1:])
+	case "media":
+		return runMedia(ctx, a, args[1:])
+	case "images":
+		return runImages(ctx, a, args[1:])
+	default:
+		return runCache(ctx, a, args[1:])
+	}
+}
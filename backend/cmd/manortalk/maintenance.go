@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/reconcile"
+)
+
+func runPost(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("unknown or missing subcommand, see -help")
+	}
+
+	switch args[0] {
+	case "purge-trash":
+		n, err := a.postService.PurgeTrash(ctx)
+		if err != nil {
+			return err
+		}
+
+		log.Println("Purged trashed posts:", n)
+		return nil
+	case "backfill":
+		n, err := a.postService.Backfill(ctx)
+		log.Println("Rendered posts:", n)
+		return err
+	default:
+		return errors.New("unknown or missing subcommand, see -help")
+	}
+}
+
+func runMedia(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || args[0] != "gc" {
+		return errors.New("unknown or missing subcommand, see -help")
+	}
+
+	n, err := a.mediaService.CollectGarbage(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Removed unused media:", n)
+	return nil
+}
+
+func runImages(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("unknown or missing subcommand, see -help")
+	}
+
+	switch args[0] {
+	case "reconcile":
+		return runReconcile(ctx, a, args[1:])
+	case "backfill":
+		n, err := reconcile.New(a.c, a.postRepo, a.userRepo, a.mediaRepo, a.store).Backfill(ctx)
+		log.Println("Recorded image variants:", n)
+		return err
+	default:
+		return errors.New("unknown or missing subcommand, see -help")
+	}
+}
+
+// runReconcile compares the stored images against the posts.image,
+// users.avatar and media.name columns. It only reports unless -delete is
+// given.
+func runReconcile(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("images reconcile")
+	remove := fs.Bool("delete", false, "Delete the orphaned files")
+	minAge := fs.Duration("min-age", time.Hour, "Skip files written more recently than this")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	r := reconcile.New(a.c, a.postRepo, a.userRepo, a.mediaRepo, a.store)
+
+	report, err := r.Run(ctx, *minAge, *remove)
+
+	for _, obj := range report.Orphans {
+		log.Printf("Orphan: %s (%d bytes, %s)", obj.Key, obj.Size, obj.ModTime.Format(time.RFC3339))
+	}
+
+	for _, key := range report.Missing {
+		log.Println("Missing:", key)
+	}
+
+	log.Printf("Orphans: %d, missing: %d, removed: %d", len(report.Orphans), len(report.Missing), report.Removed)
+
+	return err
+}
+
+func runCache(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 || args[0] != "flush" {
+		return errors.New("unknown or missing subcommand, see -help")
+	}
+
+	n, err := a.cacheRepo.FlushCache(ctx)
+	if err != nil {
+		return fmt.Errorf("flushing cache: %w", err)
+	}
+
+	log.Println("Removed cached keys:", n)
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/migrate"
+	"github.com/Noblefel/ManorTalk/backend/migrations"
+)
+
+func runMigrate(ctx context.Context, c *config.AppConfig, args []string) error {
+	return run(ctx, c, migrations.FS, migrate.MigrationsTable, args)
+}
+
+func runSeed(ctx context.Context, c *config.AppConfig, args []string) error {
+	return run(ctx, c, migrations.Seeders, migrate.SeedersTable, args)
+}
+
+func run(ctx context.Context, c *config.AppConfig, fsys fs.FS, table string, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing subcommand, see -help")
+	}
+
+	db, err := database.ConnectSQL(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := migrate.New(db, fsys, table)
+	if err != nil {
+		return err
+	}
+
+	var done []migrate.Migration
+
+	switch args[0] {
+	case "up":
+		done, err = m.Up(ctx)
+	case "down":
+		done, err = m.Down(ctx)
+	case "to", "force":
+		if len(args) < 2 {
+			return fmt.Errorf("%s needs a version", args[0])
+		}
+
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+
+		if args[0] == "force" {
+			return m.Force(ctx, version)
+		}
+
+		done, err = m.To(ctx, version)
+	case "status":
+		return printStatus(ctx, m)
+	default:
+		return fmt.Errorf("unknown subcommand %q, see -help", args[0])
+	}
+
+	for _, mig := range done {
+		log.Printf("Done: %04d_%s", mig.Version, mig.Name)
+	}
+
+	if errors.Is(err, migrate.ErrNoChange) || (err == nil && len(done) == 0) {
+		log.Println("Nothing to do")
+		return nil
+	}
+
+	return err
+}
+
+func printStatus(ctx context.Context, m *migrate.Migrator) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+
+	return w.Flush()
+}
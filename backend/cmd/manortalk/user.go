@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/validate"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func runUser(ctx context.Context, a *app, args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing subcommand, see -help")
+	}
+
+	switch args[0] {
+	case "create":
+		return createUser(ctx, a, args[1:])
+	case "role":
+		args, err := parseArgs(newFlagSet("user role"), args[1:], "username", "role")
+		if err != nil {
+			return err
+		}
+
+		return setRole(ctx, a, args[0], args[1])
+	case "reset-password":
+		args, err := parseArgs(newFlagSet("user reset-password"), args[1:], "username")
+		if err != nil {
+			return err
+		}
+
+		return resetPassword(ctx, a, args[0])
+	case "sessions":
+		return listSessions(ctx, a)
+	case "logout":
+		args, err := parseArgs(newFlagSet("user logout"), args[1:], "username")
+		if err != nil {
+			return err
+		}
+
+		return logout(ctx, a, args[0])
+	default:
+		return fmt.Errorf("unknown subcommand %q, see -help", args[0])
+	}
+}
+
+// createUser registers the user the same way the api does. The password is
+// taken from MANORTALK_PASSWORD, or generated and printed when it's unset.
+func createUser(ctx context.Context, a *app, args []string) error {
+	fs := newFlagSet("user create")
+	role := fs.String("role", models.RoleUser, "user, moderator or admin")
+
+	args, err := parseArgs(fs, args, "username", "email")
+	if err != nil {
+		return err
+	}
+
+	payload := models.UserRegisterInput{
+		Username: args[0],
+		Email:    args[1],
+		Password: os.Getenv("MANORTALK_PASSWORD"),
+	}
+
+	generated := payload.Password == ""
+	if generated {
+		if payload.Password, err = generatePassword(); err != nil {
+			return err
+		}
+	}
+
+	if inputErr := validate.Struct(payload); inputErr != nil {
+		return fmt.Errorf("invalid user: %v", *inputErr)
+	}
+
+	if err := a.authService.Register(ctx, payload, cliClient); err != nil {
+		return err
+	}
+
+	log.Printf("Created user %s", payload.Username)
+
+	if generated {
+		log.Println("Password:", payload.Password)
+	}
+
+	if *role == models.RoleUser {
+		return nil
+	}
+
+	return setRole(ctx, a, payload.Username, *role)
+}
+
+func setRole(ctx context.Context, a *app, username, role string) error {
+	switch role {
+	case models.RoleUser, models.RoleModerator, models.RoleAdmin:
+	default:
+		return fmt.Errorf("role should be %s/%s/%s", models.RoleUser, models.RoleModerator, models.RoleAdmin)
+	}
+
+	user, err := getUser(ctx, a, username)
+	if err != nil {
+		return err
+	}
+
+	if err := a.userRepo.SetRole(ctx, user.Id, role); err != nil {
+		return fmt.Errorf("setting role: %w", err)
+	}
+
+	if err := a.cacheRepo.DelProfile(ctx, user.Username); err != nil {
+		log.Println("unable to uncache profile: ", err)
+	}
+
+	a.audit.Log(ctx, models.AuditEvent{
+		Action:     audit.ActionSetRole,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+		Metadata:   map[string]interface{}{"from": user.Role, "to": role},
+	}, cliClient)
+
+	log.Printf("Changed the role of %s from %s to %s", user.Username, user.Role, role)
+	return nil
+}
+
+// resetPassword sets a generated password and ends the user's session
+func resetPassword(ctx context.Context, a *app, username string) error {
+	user, err := getUser(ctx, a, username)
+	if err != nil {
+		return err
+	}
+
+	password, err := generatePassword()
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	if err := a.userRepo.SetPassword(ctx, user.Id, string(hash)); err != nil {
+		return fmt.Errorf("setting password: %w", err)
+	}
+
+	if err := a.cacheRepo.DelRefreshToken(ctx, token.Details{UserId: user.Id}); err != nil {
+		return fmt.Errorf("deleting refresh token: %w", err)
+	}
+
+	a.audit.Log(ctx, models.AuditEvent{
+		Action:     audit.ActionResetPassword,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+	}, cliClient)
+
+	log.Printf("Reset the password of %s: %s", user.Username, password)
+	return nil
+}
+
+func listSessions(ctx context.Context, a *app) error {
+	sessions, err := a.cacheRepo.GetSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("getting sessions: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "USER ID\tUSERNAME\tEXPIRES")
+
+	for _, s := range sessions {
+		// The token may outlive a deleted user
+		user, err := a.userRepo.GetUser(ctx, models.UserFilters{Id: s.UserId})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("getting user by id: %w", err)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.UserId, user.Username, s.ExpiresAt.Format(time.RFC3339))
+	}
+
+	return w.Flush()
+}
+
+// logout revokes the refresh token, the access token still lasts until it
+// expires
+func logout(ctx context.Context, a *app, username string) error {
+	user, err := getUser(ctx, a, username)
+	if err != nil {
+		return err
+	}
+
+	if err := a.cacheRepo.DelRefreshToken(ctx, token.Details{UserId: user.Id}); err != nil {
+		return fmt.Errorf("deleting refresh token: %w", err)
+	}
+
+	a.audit.Log(ctx, models.AuditEvent{
+		Action:     audit.ActionLogout,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+	}, cliClient)
+
+	log.Printf("Logged out %s", user.Username)
+	return nil
+}
+
+func getUser(ctx context.Context, a *app, username string) (models.User, error) {
+	user, err := a.userRepo.GetUser(ctx, models.UserFilters{Username: username})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, fmt.Errorf("no user named %q", username)
+		}
+
+		return user, fmt.Errorf("getting user by username: %w", err)
+	}
+
+	return user, nil
+}
+
+func generatePassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating password: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,104 @@
+// Package audit records security and moderation events in the append-only
+// audit_events table.
+package audit
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+)
+
+const (
+	ActionRegister      = "auth.register"
+	ActionLogin         = "auth.login"
+	ActionLoginFailed   = "auth.login_failed"
+	ActionLogout        = "auth.logout"
+	ActionUpdateProfile = "user.update_profile"
+	ActionSuspend       = "user.suspend"
+	ActionUnsuspend     = "user.unsuspend"
+	ActionSetRole       = "user.set_role"
+	ActionResetPassword = "user.reset_password"
+	ActionDeletePost    = "post.delete"
+	ActionRestorePost   = "post.restore"
+	ActionResolveReport = "report.resolve"
+)
+
+const (
+	TargetUser   = "user"
+	TargetPost   = "post"
+	TargetReport = "report"
+)
+
+// Client describes where a request came from
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// FromRequest reads the client details off the request. It relies on the
+// RealIP middleware for requests coming through a proxy.
+func FromRequest(r *http.Request) Client {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return Client{IP: ip, UserAgent: truncate(r.UserAgent(), 255)}
+}
+
+// truncate cuts s to at most n bytes without splitting a character, which
+// postgres would refuse to store. Invalid sequences are dropped as well.
+func truncate(s string, n int) string {
+	s = strings.ToValidUTF8(s, "")
+	if len(s) <= n {
+		return s
+	}
+
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+
+	return s[:n]
+}
+
+type Logger interface {
+	Log(ctx context.Context, e models.AuditEvent, c Client)
+}
+
+type logger struct {
+	auditRepo repository.AuditRepo
+}
+
+func New(ar repository.AuditRepo) Logger {
+	return &logger{
+		auditRepo: ar,
+	}
+}
+
+// Log stores the event. A failure is only logged, since losing an audit
+// entry should not undo what the user has already done.
+func (l *logger) Log(ctx context.Context, e models.AuditEvent, c Client) {
+	e.IP = c.IP
+	e.UserAgent = c.UserAgent
+
+	// The event is kept even if the request is canceled right after
+	ctx = context.WithoutCancel(ctx)
+
+	if err := l.auditRepo.CreateEvent(ctx, e); err != nil {
+		log.Println("creating audit event: ", err)
+	}
+}
+
+type mockLogger struct{}
+
+func NewMockLogger() Logger {
+	return &mockLogger{}
+}
+
+func (l *mockLogger) Log(ctx context.Context, e models.AuditEvent, c Client) {}
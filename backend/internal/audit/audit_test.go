@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+)
+
+func TestNew(t *testing.T) {
+	var db *database.DB
+	l := New(postgres.NewAuditRepo(db))
+
+	typeString := reflect.TypeOf(l).String()
+
+	if typeString != "*audit.logger" {
+		t.Error("New() get incorrect type, wanted *audit.logger")
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	var tests = []struct {
+		name       string
+		remoteAddr string
+		userAgent  string
+		wantIP     string
+		wantUA     string
+	}{
+		{"with port", "10.0.0.1:5432", "firefox", "10.0.0.1", "firefox"},
+		{"without port", "10.0.0.1", "firefox", "10.0.0.1", "firefox"},
+		{"long user agent", "10.0.0.1", strings.Repeat("x", 300), "10.0.0.1", strings.Repeat("x", 255)},
+		// The 255th byte falls in the middle of the 3 bytes of "€" following 254 x
+		{"long multi-byte user agent", "10.0.0.1", strings.Repeat("x", 254) + "€" + "x", "10.0.0.1", strings.Repeat("x", 254)},
+		{"invalid utf-8", "10.0.0.1", "fire\xfffox", "10.0.0.1", "firefox"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.Header.Set("User-Agent", tt.userAgent)
+
+			c := FromRequest(r)
+
+			if c.IP != tt.wantIP {
+				t.Errorf("want ip %q, got %q", tt.wantIP, c.IP)
+			}
+
+			if c.UserAgent != tt.wantUA {
+				t.Errorf("want user agent %q, got %q", tt.wantUA, c.UserAgent)
+			}
+		})
+	}
+}
+
+func TestLogger_Log(t *testing.T) {
+	l := New(postgres.NewMockAuditRepo())
+
+	// Neither call should panic, errors are only logged
+	l.Log(context.Background(), models.AuditEvent{ActorId: 1, Action: ActionLogin}, Client{})
+	l.Log(context.Background(), models.AuditEvent{ActorId: repository.UnexpectedKeyInt, Action: ActionLogin}, Client{})
+}
+
+// ctxAuditRepo keeps the context error seen when the event is created
+type ctxAuditRepo struct {
+	repository.AuditRepo
+	err error
+}
+
+func (r *ctxAuditRepo) CreateEvent(ctx context.Context, e models.AuditEvent) error {
+	r.err = ctx.Err()
+	return nil
+}
+
+func TestLogger_LogCanceled(t *testing.T) {
+	ar := &ctxAuditRepo{AuditRepo: postgres.NewMockAuditRepo()}
+	l := New(ar)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	l.Log(ctx, models.AuditEvent{ActorId: 1, Action: ActionLogout}, Client{})
+
+	if ar.err != nil {
+		t.Errorf("expecting the event to outlive the request, got %v", ar.err)
+	}
+}
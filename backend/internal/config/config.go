@@ -1,54 +1,170 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
-	"strconv"
 	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
+	"github.com/joho/godotenv"
 )
 
+// AppConfig is built from the defaults below, then the config file, then the
+// environment. The yaml tag is the key in the file and the env tag the
+// variable that overrides it.
 type AppConfig struct {
-	InProduction    bool
-	Port            int
-	AccessTokenKey  string
-	AccessTokenExp  time.Duration
-	RefreshTokenKey string
-	RefreshTokenExp time.Duration
-	DB              dbConfig
+	InProduction     bool           `yaml:"-"`
+	Host             string         `yaml:"host" env:"API_HOST" required:"true"`
+	Port             int            `yaml:"port" env:"API_PORT"`
+	AccessTokenKey   string         `yaml:"access_token_key" env:"ACCESS_TOKEN_KEY" required:"true" secret:"true"`
+	AccessTokenExp   time.Duration  `yaml:"access_token_exp" env:"ACCESS_TOKEN_EXP"`
+	RefreshTokenKey  string         `yaml:"refresh_token_key" env:"REFRESH_TOKEN_KEY" required:"true" secret:"true"`
+	RefreshTokenExp  time.Duration  `yaml:"refresh_token_exp" env:"REFRESH_TOKEN_EXP"`
+	TrashRetention   time.Duration  `yaml:"trash_retention" env:"TRASH_RETENTION"`
+	MediaGracePeriod time.Duration  `yaml:"media_grace_period" env:"MEDIA_GRACE_PERIOD"`
+	Images           imagesConfig   `yaml:"images"`
+	Uploads          uploadsConfig  `yaml:"uploads"`
+	Cache            cacheConfig    `yaml:"cache"`
+	Server           serverConfig   `yaml:"server"`
+	Storage          storage.Config `yaml:"storage"`
+	DB               dbConfig       `yaml:"db"`
 }
 
-type dbConfig struct {
-	Host, Name, User, Password, RedisHost       string
-	Port, RedisPort, MaxOpenConns, MaxIdleConns int
-	MaxLifetime                                 time.Duration
+// imagesConfig lists the resized variants generated for each kind of upload,
+// written as widths with an optional height to crop to, e.g. "64x64,256x256"
+type imagesConfig struct {
+	Avatar img.Variants `yaml:"avatar" env:"IMAGES_AVATAR_VARIANTS"`
+	Post   img.Variants `yaml:"post" env:"IMAGES_POST_VARIANTS"`
 }
 
-func Default() *AppConfig {
-	port, _ := strconv.Atoi(os.Getenv("API_PORT"))
-	dbPort, _ := strconv.Atoi(os.Getenv("DB_PORT"))
-	redisPort, _ := strconv.Atoi(os.Getenv("REDIS_PORT"))
+// uploadsConfig caps the size in bytes of each kind of uploaded file, and of
+// the other form fields sent along with it
+type uploadsConfig struct {
+	Avatar int64 `yaml:"avatar" env:"UPLOAD_MAX_AVATAR"`
+	Post   int64 `yaml:"post" env:"UPLOAD_MAX_POST"`
+	Media  int64 `yaml:"media" env:"UPLOAD_MAX_MEDIA"`
+	Fields int64 `yaml:"fields" env:"UPLOAD_MAX_FIELDS"`
+}
+
+// cacheConfig is how long each kind of read stays cached in redis, 0 turns
+// the caching off
+type cacheConfig struct {
+	Post       time.Duration `yaml:"post" env:"CACHE_POST_TTL"`
+	Profile    time.Duration `yaml:"profile" env:"CACHE_PROFILE_TTL"`
+	Categories time.Duration `yaml:"categories" env:"CACHE_CATEGORIES_TTL"`
+}
+
+// serverConfig bounds how long the http server waits on a client, and how
+// long in-flight requests get to finish once it's asked to shut down
+type serverConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"API_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"API_READ_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"API_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"API_IDLE_TIMEOUT"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"API_SHUTDOWN_TIMEOUT"`
+}
 
+type dbConfig struct {
+	Host         string        `yaml:"host" env:"DB_HOST" required:"true"`
+	Port         int           `yaml:"port" env:"DB_PORT"`
+	Name         string        `yaml:"name" env:"DB_NAME" required:"true"`
+	User         string        `yaml:"user" env:"DB_USER" required:"true"`
+	Password     string        `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	RedisHost    string        `yaml:"redis_host" env:"REDIS_HOST" required:"true"`
+	RedisPort    int           `yaml:"redis_port" env:"REDIS_PORT"`
+	MaxOpenConns int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	MaxLifetime  time.Duration `yaml:"max_lifetime" env:"DB_MAX_LIFETIME"`
+	PingTimeout  time.Duration `yaml:"ping_timeout" env:"DB_PING_TIMEOUT"`
+}
+
+// defaults are the settings used when neither the file nor the environment
+// has them
+func defaults() *AppConfig {
 	return &AppConfig{
-		InProduction:    false,
-		Port:            port,
-		AccessTokenKey:  os.Getenv("ACCESS_TOKEN_KEY"),
-		AccessTokenExp:  time.Duration(15 * time.Minute),
-		RefreshTokenKey: os.Getenv("REFRESH_TOKEN_KEY"),
-		RefreshTokenExp: time.Duration(240 * time.Hour),
+		Host:             "localhost",
+		Port:             8080,
+		AccessTokenExp:   15 * time.Minute,
+		RefreshTokenExp:  240 * time.Hour,
+		TrashRetention:   30 * 24 * time.Hour,
+		MediaGracePeriod: 24 * time.Hour,
+		Images: imagesConfig{
+			Avatar: img.Variants{{Width: 64, Height: 64}, {Width: 256, Height: 256}},
+			Post:   img.Variants{{Width: 400}, {Width: 1200}},
+		},
+		Uploads: uploadsConfig{
+			Avatar: 2 << 20,
+			Post:   2 << 20,
+			Media:  2 << 20,
+			Fields: 1 << 20,
+		},
+		Cache: cacheConfig{
+			Post:       5 * time.Minute,
+			Profile:    5 * time.Minute,
+			Categories: time.Hour,
+		},
+		Server: serverConfig{
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   15 * time.Second,
+		},
+		Storage: storage.Config{
+			Driver:  "local",
+			Dir:     "images",
+			BaseURL: "/images",
+		},
 		DB: dbConfig{
-			Host:         os.Getenv("DB_HOST"),
-			Port:         dbPort,
-			Name:         os.Getenv("DB_NAME"),
-			User:         os.Getenv("DB_USER"),
-			Password:     os.Getenv("DB_PASSWORD"),
-			RedisHost:    os.Getenv("REDIS_HOST"),
-			RedisPort:    redisPort,
+			Port:         5432,
+			RedisPort:    6379,
 			MaxOpenConns: 10,
 			MaxIdleConns: 5,
 			MaxLifetime:  5 * time.Minute,
+			PingTimeout:  2 * time.Second,
 		},
 	}
 }
 
+// Default is the defaults with the environment on top. Malformed variables
+// are skipped and nothing is validated, the commands should use Load.
+func Default() *AppConfig {
+	c := defaults()
+	c.loadEnv(os.LookupEnv)
+	return c
+}
+
+// Load reads the .env file when there is one, then the config file at path
+// if it isn't empty, then the environment. Every malformed or missing setting
+// is reported in the error.
+func Load(path string) (*AppConfig, error) {
+	// Variables that are already set win over the .env file
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("loading .env: %w", err)
+	}
+
+	c := defaults()
+
+	if path != "" {
+		if err := c.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.loadEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
 func (c *AppConfig) WithProductionMode(b bool) *AppConfig {
 	c.InProduction = b
 	return c
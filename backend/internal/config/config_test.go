@@ -1,10 +1,15 @@
 package config
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
 )
 
 func TestDefault(t *testing.T) {
@@ -25,6 +30,28 @@ func TestDefault(t *testing.T) {
 	}
 }
 
+func TestDefault_Server(t *testing.T) {
+	os.Setenv("API_HOST", "")
+	os.Setenv("API_WRITE_TIMEOUT", "1m")
+	os.Setenv("API_READ_TIMEOUT", "soon")
+	defer os.Unsetenv("API_WRITE_TIMEOUT")
+	defer os.Unsetenv("API_READ_TIMEOUT")
+
+	config := Default()
+
+	if config.Host != "localhost" {
+		t.Error("Default().Host expecting localhost, but got", config.Host)
+	}
+
+	if config.Server.WriteTimeout != time.Minute {
+		t.Error("Default().Server.WriteTimeout expecting 1 minute, but got", config.Server.WriteTimeout.String())
+	}
+
+	if config.Server.ReadTimeout != 30*time.Second {
+		t.Error("Default().Server.ReadTimeout expecting the default 30 seconds, but got", config.Server.ReadTimeout.String())
+	}
+}
+
 func TestAppConfig_WithProductionMode(t *testing.T) {
 	config := Default().WithProductionMode(true)
 
@@ -36,3 +63,235 @@ func TestAppConfig_WithProductionMode(t *testing.T) {
 		t.Error("WithProductionMode(true) expecting InProduction to be true", config.InProduction)
 	}
 }
+
+// setRequired sets the variables Validate needs
+func setRequired(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_KEY", "access")
+	t.Setenv("REFRESH_TOKEN_KEY", "refresh")
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_NAME", "manortalk")
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("REDIS_HOST", "localhost")
+}
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	setRequired(t)
+	t.Setenv("API_PORT", "")
+	t.Setenv("DB_PORT", "6543")
+	t.Setenv("IMAGES_POST_VARIANTS", "320, 960")
+
+	path := writeFile(t, `
+port: 9000
+access_token_exp: 5m
+db:
+  port: 5433
+  max_open_conns: 20
+images:
+  avatar: 96x96
+storage:
+  s3:
+    use_ssl: true
+`)
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() expected no error but got %v", err)
+	}
+
+	if c.Port != 9000 {
+		t.Error("Load().Port expecting 9000 from the file, but got", c.Port)
+	}
+
+	if c.AccessTokenExp != 5*time.Minute {
+		t.Error("Load().AccessTokenExp expecting 5 minutes from the file, but got", c.AccessTokenExp.String())
+	}
+
+	if c.DB.Port != 6543 {
+		t.Error("Load().DB.Port expecting 6543 from the environment, but got", c.DB.Port)
+	}
+
+	if c.DB.MaxOpenConns != 20 {
+		t.Error("Load().DB.MaxOpenConns expecting 20, but got", c.DB.MaxOpenConns)
+	}
+
+	if !c.Storage.S3.UseSSL {
+		t.Error("Load().Storage.S3.UseSSL expecting true")
+	}
+
+	if c.RefreshTokenExp != 240*time.Hour {
+		t.Error("Load().RefreshTokenExp expecting the default 240 hours, but got", c.RefreshTokenExp.String())
+	}
+
+	if !reflect.DeepEqual(c.Images.Avatar, img.Variants{{Width: 96, Height: 96}}) {
+		t.Error("Load().Images.Avatar expecting 96x96 from the file, but got", c.Images.Avatar)
+	}
+
+	if !reflect.DeepEqual(c.Images.Post, img.Variants{{Width: 320}, {Width: 960}}) {
+		t.Error("Load().Images.Post expecting 320 and 960 from the environment, but got", c.Images.Post)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		file    string
+		missing bool
+		want    []string
+	}{
+		{
+			name: "malformed env",
+			env:  map[string]string{"DB_PORT": "x", "API_WRITE_TIMEOUT": "soon"},
+			want: []string{`DB_PORT: "x" is not a whole number`, `API_WRITE_TIMEOUT: "soon" is not a duration`},
+		},
+		{
+			name: "missing key",
+			env:  map[string]string{"ACCESS_TOKEN_KEY": ""},
+			want: []string{"ACCESS_TOKEN_KEY (access_token_key) is required"},
+		},
+		{
+			name: "unknown key",
+			file: "db:\n  prot: 5432\n",
+			want: []string{`unknown setting "db.prot"`},
+		},
+		{
+			name: "duration without unit",
+			file: "cache:\n  post: 300\n",
+			want: []string{`cache.post: "300" is not a duration`},
+		},
+		{
+			name: "malformed variants",
+			env:  map[string]string{"IMAGES_POST_VARIANTS": "400,wide"},
+			want: []string{`IMAGES_POST_VARIANTS: "wide" is not a width`},
+		},
+		{
+			name: "list",
+			file: "host: [a, b]\n",
+			want: []string{"host: expected a single value"},
+		},
+		{
+			name:    "missing file",
+			missing: true,
+			want:    []string{"reading config file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequired(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			path := ""
+			if tt.file != "" {
+				path = writeFile(t, tt.file)
+			}
+			if tt.missing {
+				path = filepath.Join(t.TempDir(), "nope.yaml")
+			}
+
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("Load() expected error but got none")
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Load() error should contain %q, got %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAppConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *AppConfig)
+		want   string
+	}{
+		{"valid", func(c *AppConfig) {}, ""},
+		{"no refresh key", func(c *AppConfig) { c.RefreshTokenKey = "" }, "REFRESH_TOKEN_KEY (refresh_token_key) is required"},
+		{"same keys", func(c *AppConfig) { c.RefreshTokenKey = c.AccessTokenKey }, "should differ"},
+		{"port", func(c *AppConfig) { c.Port = 70000 }, "API_PORT (port) should be between 1 and 65535"},
+		{"negative", func(c *AppConfig) { c.Cache.Post = -time.Second }, "CACHE_POST_TTL (cache.post) can't be negative"},
+		{"zero expiry", func(c *AppConfig) { c.AccessTokenExp = 0 }, "ACCESS_TOKEN_EXP (access_token_exp) should be longer than 0"},
+		{"upload size", func(c *AppConfig) { c.Uploads.Media = 0 }, "UPLOAD_MAX_MEDIA (uploads.media)"},
+		{"pool", func(c *AppConfig) { c.DB.MaxOpenConns = 0 }, "DB_MAX_OPEN_CONNS (db.max_open_conns)"},
+		{"driver", func(c *AppConfig) { c.Storage.Driver = "ftp" }, `should be local or s3, got "ftp"`},
+		{"s3 bucket", func(c *AppConfig) { c.Storage.Driver = "s3"; c.Storage.S3.Endpoint = "x" }, "S3_BUCKET (storage.s3.bucket)"},
+		{"variant width", func(c *AppConfig) { c.Images.Post = img.Variants{{Width: 0}} }, "IMAGES_POST_VARIANTS (images.post): 0 should be between 1 and 4096"},
+		{"variant too wide", func(c *AppConfig) { c.Images.Avatar = img.Variants{{Width: 64, Height: 9000}} }, "IMAGES_AVATAR_VARIANTS (images.avatar)"},
+		{"variant twice", func(c *AppConfig) { c.Images.Post = img.Variants{{Width: 400}, {Width: 400}} }, "listed twice"},
+		{"no variants", func(c *AppConfig) { c.Images.Post = nil }, ""},
+		{"empty base url", func(c *AppConfig) { c.Storage.BaseURL = "" }, "STORAGE_BASE_URL (storage.base_url)"},
+		{"base url without path", func(c *AppConfig) { c.Storage.BaseURL = "https://cdn.example.com" }, "STORAGE_BASE_URL (storage.base_url)"},
+		{"absolute base url", func(c *AppConfig) { c.Storage.BaseURL = "https://cdn.example.com/images" }, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaults()
+			c.AccessTokenKey, c.RefreshTokenKey = "access", "refresh"
+			c.DB.Host, c.DB.Name, c.DB.User, c.DB.RedisHost = "localhost", "manortalk", "postgres", "localhost"
+			tt.modify(c)
+
+			err := c.Validate()
+
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() expected no error but got %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error should contain %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestAppConfig_Write(t *testing.T) {
+	c := defaults()
+	c.AccessTokenKey = "very-secret"
+	c.DB.Host = "db"
+
+	var buf bytes.Buffer
+	if err := c.Write(&buf, true); err != nil {
+		t.Fatalf("Write() expected no error but got %v", err)
+	}
+
+	out := buf.String()
+
+	if strings.Contains(out, "very-secret") {
+		t.Error("Write() with redact should mask the access token key")
+	}
+
+	for _, want := range []string{"access_token_key: '[redacted]' # ACCESS_TOKEN_KEY", "refresh_token_key: \"\"", "host: db # DB_HOST", "shutdown_timeout: 15s", "avatar: 64x64,256x256 # IMAGES_AVATAR_VARIANTS"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Write() output should contain %q, got:\n%s", want, out)
+		}
+	}
+
+	// The output can be read back as a config file
+	path := writeFile(t, out)
+	loaded := defaults()
+	if err := loaded.loadFile(path); err != nil {
+		t.Fatalf("loadFile() expected no error reading the printed config but got %v", err)
+	}
+
+	if loaded.DB.Host != "db" || loaded.Server.ShutdownTimeout != 15*time.Second || !reflect.DeepEqual(loaded.Images.Post, c.Images.Post) {
+		t.Error("loadFile() did not read back the printed config")
+	}
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var textType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setting is a field the file and the environment can change
+type setting struct {
+	key      string // dotted path in the config file, e.g. db.port
+	env      string
+	required bool
+	secret   bool
+	v        reflect.Value
+}
+
+// name is how errors refer to the setting
+func (s setting) name() string {
+	return fmt.Sprintf("%s (%s)", s.env, s.key)
+}
+
+// settings walks the tagged fields in the order they are declared
+func (c *AppConfig) settings() []setting {
+	var all []setting
+
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+
+			key := f.Tag.Get("yaml")
+			if key == "" || key == "-" {
+				continue
+			}
+
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+
+			if f.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key)
+				continue
+			}
+
+			all = append(all, setting{
+				key:      key,
+				env:      f.Tag.Get("env"),
+				required: f.Tag.Get("required") == "true",
+				secret:   f.Tag.Get("secret") == "true",
+				v:        v.Field(i),
+			})
+		}
+	}
+
+	walk(reflect.ValueOf(c).Elem(), "")
+	return all
+}
+
+// loadEnv overrides the settings whose variable is set and not empty. The
+// field keeps its value when the variable is malformed.
+func (c *AppConfig) loadEnv(lookup func(string) (string, bool)) error {
+	var errs []error
+
+	for _, s := range c.settings() {
+		raw, ok := lookup(s.env)
+		if !ok || raw == "" {
+			continue
+		}
+
+		if err := set(s.v, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadFile overrides the settings found in the yaml file. Unknown keys are
+// errors so a typo doesn't go unnoticed.
+func (c *AppConfig) loadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(doc, "", values); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var errs []error
+
+	for _, s := range c.settings() {
+		raw, ok := values[s.key]
+		if !ok {
+			continue
+		}
+
+		delete(values, s.key)
+
+		if err := set(s.v, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s: %w", path, s.key, err))
+		}
+	}
+
+	unknown := make([]string, 0, len(values))
+	for key := range values {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("%s: unknown setting %q", path, key))
+	}
+
+	return errors.Join(errs...)
+}
+
+// flatten turns the nested mappings into dotted keys
+func flatten(m map[string]interface{}, prefix string, out map[string]string) error {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if err := flatten(v, key, out); err != nil {
+				return err
+			}
+		case []interface{}:
+			return fmt.Errorf("%s: expected a single value, not a list", key)
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+
+	return nil
+}
+
+func set(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 2m", raw)
+		}
+
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	// Lists such as the image variants parse themselves
+	if v.Addr().Type().Implements(textType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", raw)
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", raw)
+		}
+
+		v.SetInt(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
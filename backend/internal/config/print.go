@@ -0,0 +1,80 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[redacted]"
+
+// Write prints the settings in the config file format, each commented with
+// the variable that overrides it. With redact the secrets that are set are
+// masked.
+func (c *AppConfig) Write(w io.Writer, redact bool) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, s := range c.settings() {
+		parent := root
+		path := strings.Split(s.key, ".")
+
+		for _, key := range path[:len(path)-1] {
+			parent = child(parent, key)
+		}
+
+		value := &yaml.Node{Kind: yaml.ScalarNode, LineComment: s.env}
+
+		switch {
+		case s.secret && redact && !s.v.IsZero():
+			value.Tag, value.Value = "!!str", redacted
+		case s.v.Type() == durationType:
+			value.Tag, value.Value = "!!str", time.Duration(s.v.Int()).String()
+		case s.v.Addr().Type().Implements(textType):
+			text, err := s.v.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return fmt.Errorf("%s: %w", s.key, err)
+			}
+
+			value.Tag, value.Value = "!!str", string(text)
+		default:
+			value.Value = fmt.Sprint(s.v.Interface())
+
+			switch s.v.Interface().(type) {
+			case string:
+				value.Tag = "!!str"
+			case bool:
+				value.Tag = "!!bool"
+			default:
+				value.Tag = "!!int"
+			}
+		}
+
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: path[len(path)-1]}, value)
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// child returns the mapping under key, adding it when it's not there yet
+func child(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, node)
+	return node
+}
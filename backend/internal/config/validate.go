@@ -0,0 +1,144 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
+)
+
+// maxVariantSide is far above any width a page shows, a bigger variant is
+// more likely a typo than a choice
+const maxVariantSide = 4096
+
+// Validate reports every problem at once, so a deploy doesn't have to be
+// retried once per missing variable
+func (c *AppConfig) Validate() error {
+	var errs []error
+
+	for _, s := range c.settings() {
+		if s.required && s.v.IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required", s.name()))
+			continue
+		}
+
+		if s.v.Type() == durationType && s.v.Int() < 0 {
+			errs = append(errs, fmt.Errorf("%s can't be negative", s.name()))
+		}
+	}
+
+	ports := []struct {
+		name string
+		port int
+	}{
+		{"API_PORT (port)", c.Port},
+		{"DB_PORT (db.port)", c.DB.Port},
+		{"REDIS_PORT (db.redis_port)", c.DB.RedisPort},
+	}
+
+	for _, p := range ports {
+		if p.port < 1 || p.port > 65535 {
+			errs = append(errs, fmt.Errorf("%s should be between 1 and 65535, got %d", p.name, p.port))
+		}
+	}
+
+	positive := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"ACCESS_TOKEN_EXP (access_token_exp)", c.AccessTokenExp},
+		{"REFRESH_TOKEN_EXP (refresh_token_exp)", c.RefreshTokenExp},
+		{"API_SHUTDOWN_TIMEOUT (server.shutdown_timeout)", c.Server.ShutdownTimeout},
+	}
+
+	for _, p := range positive {
+		if p.d == 0 {
+			errs = append(errs, fmt.Errorf("%s should be longer than 0", p.name))
+		}
+	}
+
+	if c.AccessTokenKey != "" && c.AccessTokenKey == c.RefreshTokenKey {
+		errs = append(errs, errors.New("ACCESS_TOKEN_KEY and REFRESH_TOKEN_KEY should differ"))
+	}
+
+	sizes := []struct {
+		name string
+		n    int64
+	}{
+		{"UPLOAD_MAX_AVATAR (uploads.avatar)", c.Uploads.Avatar},
+		{"UPLOAD_MAX_POST (uploads.post)", c.Uploads.Post},
+		{"UPLOAD_MAX_MEDIA (uploads.media)", c.Uploads.Media},
+		{"UPLOAD_MAX_FIELDS (uploads.fields)", c.Uploads.Fields},
+	}
+
+	for _, s := range sizes {
+		if s.n <= 0 {
+			errs = append(errs, fmt.Errorf("%s should be more than 0 bytes", s.name))
+		}
+	}
+
+	if c.DB.MaxOpenConns < 1 {
+		errs = append(errs, fmt.Errorf("DB_MAX_OPEN_CONNS (db.max_open_conns) should be at least 1, got %d", c.DB.MaxOpenConns))
+	}
+
+	if c.DB.MaxIdleConns < 0 {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS (db.max_idle_conns) can't be negative, got %d", c.DB.MaxIdleConns))
+	}
+
+	variants := []struct {
+		name string
+		vs   img.Variants
+	}{
+		{"IMAGES_AVATAR_VARIANTS (images.avatar)", c.Images.Avatar},
+		{"IMAGES_POST_VARIANTS (images.post)", c.Images.Post},
+	}
+
+	for _, v := range variants {
+		if err := checkVariants(v.vs); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", v.name, err))
+		}
+	}
+
+	switch c.Storage.Driver {
+	case "", "local":
+		if _, err := c.Storage.ServePath(); err != nil {
+			errs = append(errs, fmt.Errorf("STORAGE_BASE_URL (storage.base_url): %w", err))
+		}
+	case "s3":
+		if c.Storage.S3.Endpoint == "" {
+			errs = append(errs, errors.New("S3_ENDPOINT (storage.s3.endpoint) is required by the s3 driver"))
+		}
+
+		if c.Storage.S3.Bucket == "" {
+			errs = append(errs, errors.New("S3_BUCKET (storage.s3.bucket) is required by the s3 driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("STORAGE_DRIVER (storage.driver) should be local or s3, got %q", c.Storage.Driver))
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+}
+
+// checkVariants wants each width once, as the variants are named after it
+func checkVariants(vs img.Variants) error {
+	seen := make(map[int]bool, len(vs))
+
+	for _, v := range vs {
+		if v.Width < 1 || v.Width > maxVariantSide || v.Height < 0 || v.Height > maxVariantSide {
+			return fmt.Errorf("%s should be between 1 and %d pixels", v, maxVariantSide)
+		}
+
+		if seen[v.Width] {
+			return fmt.Errorf("the width %d is listed twice", v.Width)
+		}
+
+		seen[v.Width] = true
+	}
+
+	return nil
+}
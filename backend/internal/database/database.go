@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -14,28 +15,64 @@ import (
 type DB struct {
 	Sql   *sql.DB
 	Redis *redis.Client
+	// PingTimeout bounds the pings of the readiness checks, 0 leaves them to
+	// the caller's context
+	PingTimeout time.Duration
 }
 
 func Connect(c *config.AppConfig) (*DB, error) {
-	sql, err := connectSQL(c)
+	sql, err := ConnectSQL(c)
 	if err != nil {
 		log.Println("ERROR connecting to SQL")
 		return nil, err
 	}
 
-	redis, err := connectRedis(c)
+	redis, err := ConnectRedis(c)
 	if err != nil {
 		log.Println("ERROR connecting to Redis")
 		return nil, err
 	}
 
 	return &DB{
-		Sql:   sql,
-		Redis: redis,
+		Sql:         sql,
+		Redis:       redis,
+		PingTimeout: c.DB.PingTimeout,
 	}, nil
 }
 
-func connectSQL(c *config.AppConfig) (*sql.DB, error) {
+// Checks are the pings the api is ready to serve by, keyed by dependency
+func (db *DB) Checks() map[string]func(context.Context) error {
+	return map[string]func(context.Context) error{
+		"postgres": db.PingSQL,
+		"redis":    db.PingRedis,
+	}
+}
+
+func (db *DB) PingSQL(ctx context.Context) error {
+	ctx, cancel := db.withPingTimeout(ctx)
+	defer cancel()
+
+	return db.Sql.PingContext(ctx)
+}
+
+func (db *DB) PingRedis(ctx context.Context) error {
+	ctx, cancel := db.withPingTimeout(ctx)
+	defer cancel()
+
+	return db.Redis.Ping(ctx).Err()
+}
+
+func (db *DB) withPingTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if db.PingTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, db.PingTimeout)
+}
+
+// ConnectSQL opens the postgres pool alone, for the commands that have no
+// use for redis
+func ConnectSQL(c *config.AppConfig) (*sql.DB, error) {
 	dsn := fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s",
 		c.DB.Host,
 		c.DB.Port,
@@ -60,7 +97,7 @@ func connectSQL(c *config.AppConfig) (*sql.DB, error) {
 	return conn, nil
 }
 
-func connectRedis(c *config.AppConfig) (*redis.Client, error) {
+func ConnectRedis(c *config.AppConfig) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr: fmt.Sprint(c.DB.RedisHost, ":", c.DB.RedisPort),
 	})
@@ -75,9 +75,9 @@ func TestConnectSQL(t *testing.T) {
 
 		config := config.Default()
 
-		_, err := connectSQL(config)
+		_, err := ConnectSQL(config)
 		if err != nil {
-			t.Errorf("connectSQL() expected no error but got %v", err)
+			t.Errorf("ConnectSQL() expected no error but got %v", err)
 		}
 	})
 
@@ -90,9 +90,9 @@ func TestConnectSQL(t *testing.T) {
 
 		config := config.Default()
 
-		_, err := connectSQL(config)
+		_, err := ConnectSQL(config)
 		if err == nil {
-			t.Errorf("connectSQL() expected error but got none")
+			t.Errorf("ConnectSQL() expected error but got none")
 		}
 	})
 }
@@ -104,7 +104,7 @@ func TestConnectRedis(t *testing.T) {
 
 		config := config.Default()
 
-		_, err := connectRedis(config)
+		_, err := ConnectRedis(config)
 		if err != nil {
 			t.Errorf("ConnectRedis() expected no error but got %v", err)
 		}
@@ -116,9 +116,9 @@ func TestConnectRedis(t *testing.T) {
 
 		config := config.Default()
 
-		_, err := connectRedis(config)
+		_, err := ConnectRedis(config)
 		if err == nil {
-			t.Errorf("connectRedis() expected error but got none")
+			t.Errorf("ConnectRedis() expected error but got none")
 		}
 	})
 }
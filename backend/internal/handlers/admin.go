@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	service "github.com/Noblefel/ManorTalk/backend/internal/service/admin"
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
+)
+
+type AdminHandlers struct {
+	service service.AdminService
+}
+
+func NewAdminHandlers(s service.AdminService) *AdminHandlers {
+	return &AdminHandlers{
+		service: s,
+	}
+}
+
+// GetAuditEvents lists the audit log, newest first. It's filtered by the
+// actor, action, from and to query parameters. The dates are either plain
+// dates or RFC3339 timestamps, from is inclusive and to is exclusive except
+// for a plain date, which includes that whole day.
+func (h *AdminHandlers) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	authId := r.Context().Value("user_id").(int)
+
+	events, pgMeta, err := h.service.GetAuditEvents(r.Context(), r.URL.Query(), authId)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnauthorized):
+			res.Message(w, http.StatusUnauthorized, err.Error())
+			return
+		case errors.Is(err, service.ErrInvalidFilter):
+			res.Message(w, http.StatusBadRequest, err.Error())
+			return
+		default:
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems retrieving the audit events")
+			return
+		}
+	}
+
+	res.JSON(w, http.StatusOK, res.Response{
+		Data: map[string]interface{}{
+			"pagination_meta": pgMeta,
+			"events":          events,
+		},
+	})
+}
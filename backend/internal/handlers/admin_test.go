@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	service "github.com/Noblefel/ManorTalk/backend/internal/service/admin"
+	"github.com/gosimple/slug"
+)
+
+func TestNewAdminHandlers(t *testing.T) {
+	var db *database.DB
+	var c *config.AppConfig
+	ar := postgres.NewAuditRepo(db)
+	ur := postgres.NewUserRepo(db)
+	s := service.NewAdminService(c, ar, ur)
+	admin := NewAdminHandlers(s)
+
+	typeString := reflect.TypeOf(admin).String()
+
+	if typeString != "*handlers.AdminHandlers" {
+		t.Error("NewAdminHandlers() did not get the correct type, wanted *handlers.AdminHandlers")
+	}
+}
+
+func TestAdmin_GetAuditEvents(t *testing.T) {
+	var tests = []struct {
+		name       string
+		query      string
+		statusCode int
+	}{
+		{"success", "action=auth.login", http.StatusOK},
+		{"unauthorized", slug.Make(service.ErrUnauthorized.Error()) + "=1", http.StatusUnauthorized},
+		{"invalid filter", slug.Make(service.ErrInvalidFilter.Error()) + "=1", http.StatusBadRequest},
+		{"unexpected error", slug.Make("unexpected error") + "=1", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/admin/audit-events?"+tt.query, nil)
+			ctx := context.WithValue(r.Context(), "user_id", 1)
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.admin.GetAuditEvents)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
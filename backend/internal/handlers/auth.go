@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	service "github.com/Noblefel/ManorTalk/backend/internal/service/auth"
 	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
@@ -34,7 +35,7 @@ func (h *AuthHandlers) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.Register(payload)
+	err := h.service.Register(r.Context(), payload, audit.FromRequest(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrDuplicateEmail):
@@ -66,12 +67,15 @@ func (h *AuthHandlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, accessToken, refreshToken, err := h.service.Login(payload)
+	user, accessToken, refreshToken, err := h.service.Login(r.Context(), payload, audit.FromRequest(r))
 	if err != nil {
 		switch {
 		case errors.Is(service.ErrInvalidCredentials, err), errors.Is(service.ErrNoUser, err):
 			res.Message(w, http.StatusUnauthorized, service.ErrInvalidCredentials.Error())
 			return
+		case errors.Is(err, service.ErrSuspended):
+			res.Message(w, http.StatusForbidden, err.Error())
+			return
 		default:
 			log.Println(err)
 			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems when authenticating")
@@ -100,12 +104,15 @@ func (h *AuthHandlers) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, accessToken, err := h.service.Refresh(refreshToken.Value)
+	user, accessToken, err := h.service.Refresh(r.Context(), refreshToken.Value)
 	if err != nil {
 		switch {
 		case errors.Is(service.ErrUnauthorized, err), errors.Is(service.ErrNoUser, err):
 			res.Message(w, http.StatusUnauthorized, service.ErrUnauthorized.Error())
 			return
+		case errors.Is(err, service.ErrSuspended):
+			res.Message(w, http.StatusForbidden, err.Error())
+			return
 		default:
 			log.Println(err)
 			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems verifying your request")
@@ -129,7 +136,7 @@ func (h *AuthHandlers) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Logout(refreshToken.Value)
+	err = h.service.Logout(r.Context(), refreshToken.Value, audit.FromRequest(r))
 	if err != nil {
 		log.Println(err)
 		res.Message(w, http.StatusUnauthorized, service.ErrUnauthorized.Error())
@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
@@ -22,7 +23,7 @@ func TestNewAuthHandlers(t *testing.T) {
 	var c *config.AppConfig
 	cr := redis.NewRepo(db)
 	ur := postgres.NewUserRepo(db)
-	s := service.NewAuthService(c, cr, ur)
+	s := service.NewAuthService(c, cr, ur, audit.NewMockLogger())
 	auth := NewAuthHandlers(s)
 
 	typeString := reflect.TypeOf(auth).String()
@@ -94,6 +95,7 @@ func TestAuth_Login(t *testing.T) {
 		{"error validation", "not-an-email", "", http.StatusBadRequest},
 		{"invalid credentials", "test@example.com", service.ErrInvalidCredentials.Error(), http.StatusUnauthorized},
 		{"no user", "test@example.com", service.ErrNoUser.Error(), http.StatusUnauthorized},
+		{"suspended", "test@example.com", service.ErrSuspended.Error(), http.StatusForbidden},
 		{"unexpected error", "test@example.com", "unexpected error", http.StatusInternalServerError},
 	}
 
@@ -128,6 +130,7 @@ func TestAuth_Refresh(t *testing.T) {
 		{"missing cookie", nil, http.StatusUnauthorized},
 		{"unauthorized", &http.Cookie{Name: "refresh_token", Value: service.ErrUnauthorized.Error()}, http.StatusUnauthorized},
 		{"no user", &http.Cookie{Name: "refresh_token", Value: service.ErrNoUser.Error()}, http.StatusUnauthorized},
+		{"suspended", &http.Cookie{Name: "refresh_token", Value: service.ErrSuspended.Error()}, http.StatusForbidden},
 		{"unexpected error", &http.Cookie{Name: "refresh_token", Value: "unexpected error"}, http.StatusInternalServerError},
 	}
 
@@ -6,27 +6,39 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	admin_service "github.com/Noblefel/ManorTalk/backend/internal/service/admin"
 	auth_service "github.com/Noblefel/ManorTalk/backend/internal/service/auth"
+	media_service "github.com/Noblefel/ManorTalk/backend/internal/service/media"
+	moderation_service "github.com/Noblefel/ManorTalk/backend/internal/service/moderation"
 	post_service "github.com/Noblefel/ManorTalk/backend/internal/service/post"
 	user_service "github.com/Noblefel/ManorTalk/backend/internal/service/user"
 	"github.com/go-chi/chi/v5"
 )
 
 type testHandlers struct {
-	auth *AuthHandlers
-	user *UserHandlers
-	post *PostHandlers
+	auth  *AuthHandlers
+	user  *UserHandlers
+	post  *PostHandlers
+	media *MediaHandlers
+	mod   *ModerationHandlers
+	admin *AdminHandlers
 }
 
 func newTestHandlers() *testHandlers {
 	authMock := auth_service.NewMockAuthService()
 	userMock := user_service.NewMockUserService()
 	postMock := post_service.NewMockPostService()
+	mediaMock := media_service.NewMockMediaService()
+	moderationMock := moderation_service.NewMockModerationService()
+	adminMock := admin_service.NewMockAdminService()
 
 	return &testHandlers{
-		auth: NewAuthHandlers(authMock),
-		user: NewUserHandlers(userMock),
-		post: NewPostHandlers(postMock),
+		auth:  NewAuthHandlers(authMock),
+		user:  NewUserHandlers(userMock),
+		post:  NewPostHandlers(postMock),
+		media: NewMediaHandlers(mediaMock),
+		mod:   NewModerationHandlers(moderationMock),
+		admin: NewAdminHandlers(adminMock),
 	}
 }
 
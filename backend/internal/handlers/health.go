@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
+)
+
+type HealthHandlers struct {
+	checks  map[string]func(context.Context) error
+	version VersionInfo
+}
+
+// CheckStatus is how one dependency answered its ping
+type CheckStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+// VersionInfo is read from the build info once, it doesn't change while the
+// process runs
+type VersionInfo struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// NewHealthHandlers takes the pings readiness depends on, keyed by the name
+// they are reported under. Each ping is expected to bound itself.
+func NewHealthHandlers(checks map[string]func(context.Context) error) *HealthHandlers {
+	return &HealthHandlers{
+		checks:  checks,
+		version: buildVersion(debug.ReadBuildInfo()),
+	}
+}
+
+// Health only tells the process is up and serving, it doesn't touch any
+// dependency so a slow database doesn't get the process restarted
+func (h *HealthHandlers) Health(w http.ResponseWriter, r *http.Request) {
+	res.Message(w, http.StatusOK, "OK")
+}
+
+// Ready pings every dependency at once and answers 503 when any of them
+// fails. The errors are only logged, they may name internal hosts.
+func (h *HealthHandlers) Ready(w http.ResponseWriter, r *http.Request) {
+	statuses := make(map[string]CheckStatus, len(h.checks))
+	failed := make([]string, 0)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, ping := range h.checks {
+		wg.Add(1)
+
+		go func(name string, ping func(context.Context) error) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := ping(r.Context())
+			status := CheckStatus{
+				Status:    "ok",
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				log.Printf("readiness check %s failed: %v", name, err)
+				status.Status = "failed"
+				failed = append(failed, name)
+			}
+
+			statuses[name] = status
+		}(name, ping)
+	}
+
+	wg.Wait()
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		res.JSON(w, http.StatusServiceUnavailable, res.Response{
+			Message: "Not ready",
+			Data:    statuses,
+			Errors:  failed,
+		})
+		return
+	}
+
+	res.JSON(w, http.StatusOK, res.Response{
+		Message: "Ready",
+		Data:    statuses,
+	})
+}
+
+func (h *HealthHandlers) Version(w http.ResponseWriter, r *http.Request) {
+	res.JSON(w, http.StatusOK, res.Response{
+		Message: "Version",
+		Data:    h.version,
+	})
+}
+
+// buildVersion picks the module version and the vcs stamps go build embeds,
+// which are missing when built outside of a repository
+func buildVersion(info *debug.BuildInfo, ok bool) VersionInfo {
+	if !ok {
+		return VersionInfo{Version: "unknown"}
+	}
+
+	v := VersionInfo{
+		Version:   info.Main.Version,
+		GoVersion: info.GoVersion,
+	}
+
+	if v.Version == "" {
+		v.Version = "unknown"
+	}
+
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			v.Revision = s.Value
+		case "vcs.time":
+			v.Time = s.Value
+		case "vcs.modified":
+			v.Modified = s.Value == "true"
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func TestNewHealthHandlers(t *testing.T) {
+	health := NewHealthHandlers(nil)
+
+	typeString := reflect.TypeOf(health).String()
+
+	if typeString != "*handlers.HealthHandlers" {
+		t.Error("NewHealthHandlers() did not get the correct type, wanted *handlers.HealthHandlers")
+	}
+}
+
+func TestHealth_Health(t *testing.T) {
+	// A failing dependency doesn't matter to liveness
+	h := NewHealthHandlers(map[string]func(context.Context) error{
+		"postgres": func(context.Context) error { return errors.New("down") },
+	})
+
+	r := httptest.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+	h.Health(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Health() returned %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestHealth_Ready(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+
+	var tests = []struct {
+		name       string
+		checks     map[string]func(context.Context) error
+		failed     []string
+		statusCode int
+	}{
+		{"success", map[string]func(context.Context) error{"postgres": ok, "redis": ok}, nil, http.StatusOK},
+		{"no checks", nil, nil, http.StatusOK},
+		{"redis down", map[string]func(context.Context) error{"postgres": ok, "redis": down}, []string{"redis"}, http.StatusServiceUnavailable},
+		{"both down", map[string]func(context.Context) error{"redis": down, "postgres": down}, []string{"postgres", "redis"}, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandlers(tt.checks)
+
+			r := httptest.NewRequest("GET", "/readyz", nil)
+			w := httptest.NewRecorder()
+			h.Ready(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("Ready() returned %d, want %d", w.Code, tt.statusCode)
+			}
+
+			var body struct {
+				Data   map[string]CheckStatus `json:"data"`
+				Errors []string               `json:"errors"`
+			}
+
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(body.Data) != len(tt.checks) {
+				t.Errorf("Ready() reported %d checks, want %d", len(body.Data), len(tt.checks))
+			}
+
+			if !reflect.DeepEqual(body.Errors, tt.failed) {
+				t.Errorf("Ready() reported %v as failed, want %v", body.Errors, tt.failed)
+			}
+
+			for _, name := range tt.failed {
+				if body.Data[name].Status != "failed" {
+					t.Errorf("Ready() reported %s as %q, want failed", name, body.Data[name].Status)
+				}
+			}
+		})
+	}
+}
+
+func TestHealth_Version(t *testing.T) {
+	h := NewHealthHandlers(nil)
+
+	r := httptest.NewRequest("GET", "/version", nil)
+	w := httptest.NewRecorder()
+	h.Version(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Version() returned %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var body struct {
+		Data VersionInfo `json:"data"`
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Data.Version == "" || body.Data.GoVersion == "" {
+		t.Errorf("Version() expected the version and go version, got %+v", body.Data)
+	}
+}
+
+func TestBuildVersion(t *testing.T) {
+	info := &debug.BuildInfo{
+		GoVersion: "go1.22.2",
+		Main:      debug.Module{Version: "v1.2.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2024-05-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	var tests = []struct {
+		name string
+		info *debug.BuildInfo
+		ok   bool
+		want VersionInfo
+	}{
+		{"stamped", info, true, VersionInfo{"v1.2.0", "go1.22.2", "abc123", "2024-05-01T10:00:00Z", true}},
+		{"no version", &debug.BuildInfo{GoVersion: "go1.22.2"}, true, VersionInfo{Version: "unknown", GoVersion: "go1.22.2"}},
+		{"no build info", nil, false, VersionInfo{Version: "unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildVersion(tt.info, tt.ok); got != tt.want {
+				t.Errorf("buildVersion() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	service "github.com/Noblefel/ManorTalk/backend/internal/service/media"
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
+)
+
+type MediaHandlers struct {
+	service service.MediaService
+}
+
+func NewMediaHandlers(s service.MediaService) *MediaHandlers {
+	return &MediaHandlers{
+		service: s,
+	}
+}
+
+func (h *MediaHandlers) Upload(w http.ResponseWriter, r *http.Request) {
+	form, err := readForm(r, "image")
+	if err != nil {
+		formError(w, err)
+		return
+	}
+	defer form.close()
+
+	if form.file == nil {
+		res.Message(w, http.StatusBadRequest, "Image is required")
+		return
+	}
+
+	authId := r.Context().Value("user_id").(int)
+
+	media, err := h.service.Upload(r.Context(), models.MediaUploadInput{File: form.file}, authId)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImageTooLarge):
+			res.Message(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		case errors.Is(err, service.ErrImageSize), errors.Is(err, service.ErrImageInvalid):
+			res.Message(w, http.StatusBadRequest, err.Error())
+			return
+		default:
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems uploading the image")
+			return
+		}
+	}
+
+	res.JSON(w, http.StatusCreated, res.Response{
+		Message: "Image has been uploaded",
+		Data:    media,
+	})
+}
+
+func (h *MediaHandlers) GetLibrary(w http.ResponseWriter, r *http.Request) {
+	authId := r.Context().Value("user_id").(int)
+
+	media, err := h.service.GetLibrary(r.Context(), authId)
+	if err != nil {
+		log.Println(err)
+		res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems retrieving the media")
+		return
+	}
+
+	res.JSON(w, http.StatusOK, res.Response{
+		Data: media,
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	service "github.com/Noblefel/ManorTalk/backend/internal/service/media"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+)
+
+func TestNewMediaHandlers(t *testing.T) {
+	var db *database.DB
+	var c *config.AppConfig
+	mr := postgres.NewMediaRepo(db)
+	s := service.NewMediaService(c, mr, storage.NewMockStore())
+	media := NewMediaHandlers(s)
+
+	typeString := reflect.TypeOf(media).String()
+
+	if typeString != "*handlers.MediaHandlers" {
+		t.Error("NewMediaHandlers() did not get the correct type, wanted *handlers.MediaHandlers")
+	}
+}
+
+func TestMedia_Upload(t *testing.T) {
+	var tests = []struct {
+		name       string
+		noForm     bool
+		noFile     bool
+		content    string
+		statusCode int
+	}{
+		{"success", false, false, "image", http.StatusCreated},
+		{"error parsing form", true, false, "", http.StatusBadRequest},
+		{"no image", false, true, "", http.StatusBadRequest},
+		{"error image invalid", false, false, service.ErrImageInvalid.Error(), http.StatusBadRequest},
+		{"error image too large", false, false, service.ErrImageTooLarge.Error(), http.StatusRequestEntityTooLarge},
+		{"error image size", false, false, service.ErrImageSize.Error(), http.StatusBadRequest},
+		{"unexpected error", false, false, "unexpected error", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			fw := multipart.NewWriter(&b)
+			if !tt.noFile {
+				f, _ := fw.CreateFormFile("image", "x")
+				f.Write([]byte(tt.content))
+			}
+			fw.Close()
+
+			var r *http.Request
+			if tt.noForm {
+				r = httptest.NewRequest("POST", "/media", nil)
+			} else {
+				r = httptest.NewRequest("POST", "/media", &b)
+			}
+
+			ctx := context.WithValue(r.Context(), "user_id", 1)
+			r = r.WithContext(ctx)
+			r.Header.Set("Content-Type", fw.FormDataContentType())
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.media.Upload)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestMedia_GetLibrary(t *testing.T) {
+	var tests = []struct {
+		name       string
+		authId     int
+		statusCode int
+	}{
+		{"success", 1, http.StatusOK},
+		{"unexpected error", repository.UnexpectedKeyInt, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/users/me/media", nil)
+			ctx := context.WithValue(r.Context(), "user_id", tt.authId)
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.media.GetLibrary)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
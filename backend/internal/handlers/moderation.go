@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	service "github.com/Noblefel/ManorTalk/backend/internal/service/moderation"
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/validate"
+	"github.com/go-chi/chi/v5"
+)
+
+type ModerationHandlers struct {
+	service service.ModerationService
+}
+
+func NewModerationHandlers(s service.ModerationService) *ModerationHandlers {
+	return &ModerationHandlers{
+		service: s,
+	}
+}
+
+func (h *ModerationHandlers) Report(w http.ResponseWriter, r *http.Request) {
+	var payload models.ReportInput
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		res.Message(w, http.StatusBadRequest, "Error decoding json")
+		return
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		res.JSON(w, http.StatusBadRequest, res.Response{
+			Message: "Some fields are invalid",
+			Errors:  err,
+		})
+		return
+	}
+
+	authId := r.Context().Value("user_id").(int)
+
+	err := h.service.Report(r.Context(), payload, chi.URLParam(r, "slug"), authId)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoPost):
+			res.Message(w, http.StatusNotFound, err.Error())
+			return
+		case errors.Is(err, service.ErrOwnPost):
+			res.Message(w, http.StatusBadRequest, err.Error())
+			return
+		case errors.Is(err, service.ErrDuplicateReport):
+			res.Message(w, http.StatusConflict, err.Error())
+			return
+		default:
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems submitting the report")
+			return
+		}
+	}
+
+	res.Message(w, http.StatusCreated, "Thank you, the post has been reported")
+}
+
+func (h *ModerationHandlers) GetReports(w http.ResponseWriter, r *http.Request) {
+	authId := r.Context().Value("user_id").(int)
+
+	reports, pgMeta, err := h.service.GetReports(r.Context(), r.URL.Query(), authId)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnauthorized):
+			res.Message(w, http.StatusUnauthorized, err.Error())
+			return
+		case errors.Is(err, service.ErrInvalidStatus):
+			res.Message(w, http.StatusBadRequest, err.Error())
+			return
+		default:
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems retrieving the reports")
+			return
+		}
+	}
+
+	res.JSON(w, http.StatusOK, res.Response{
+		Data: map[string]interface{}{
+			"pagination_meta": pgMeta,
+			"reports":         reports,
+		},
+	})
+}
+
+func (h *ModerationHandlers) Resolve(w http.ResponseWriter, r *http.Request) {
+	var payload models.ResolveReportInput
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		res.Message(w, http.StatusNotFound, service.ErrNoReport.Error())
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		res.Message(w, http.StatusBadRequest, "Error decoding json")
+		return
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		res.JSON(w, http.StatusBadRequest, res.Response{
+			Message: "Some fields are invalid",
+			Errors:  err,
+		})
+		return
+	}
+
+	authId := r.Context().Value("user_id").(int)
+
+	err = h.service.Resolve(r.Context(), payload, id, authId, audit.FromRequest(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnauthorized):
+			res.Message(w, http.StatusUnauthorized, err.Error())
+			return
+		case errors.Is(err, service.ErrNoReport), errors.Is(err, service.ErrNoPost):
+			res.Message(w, http.StatusNotFound, err.Error())
+			return
+		case errors.Is(err, service.ErrResolved):
+			res.Message(w, http.StatusConflict, err.Error())
+			return
+		default:
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems resolving the report")
+			return
+		}
+	}
+
+	res.Message(w, http.StatusOK, "Report has been resolved")
+}
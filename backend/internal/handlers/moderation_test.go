@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
+	service "github.com/Noblefel/ManorTalk/backend/internal/service/moderation"
+	"github.com/gosimple/slug"
+)
+
+func TestNewModerationHandlers(t *testing.T) {
+	var db *database.DB
+	var c *config.AppConfig
+	cr := redis.NewRepo(db)
+	rr := postgres.NewReportRepo(db)
+	pr := postgres.NewPostRepo(db)
+	ur := postgres.NewUserRepo(db)
+	s := service.NewModerationService(c, cr, rr, pr, ur, postgres.NewTxManager(db), audit.NewMockLogger())
+	mod := NewModerationHandlers(s)
+
+	typeString := reflect.TypeOf(mod).String()
+
+	if typeString != "*handlers.ModerationHandlers" {
+		t.Error("NewModerationHandlers() did not get the correct type, wanted *handlers.ModerationHandlers")
+	}
+}
+
+func TestModeration_Report(t *testing.T) {
+	var tests = []struct {
+		name       string
+		slugRoute  string
+		reason     string
+		statusCode int
+	}{
+		{"success", "post-title", "spam", http.StatusCreated},
+		{"error decoding json", "post-title", "", http.StatusBadRequest},
+		{"error validation", "post-title", "not-a-reason", http.StatusBadRequest},
+		{"no post", service.ErrNoPost.Error(), "spam", http.StatusNotFound},
+		{"own post", service.ErrOwnPost.Error(), "spam", http.StatusBadRequest},
+		{"duplicate report", service.ErrDuplicateReport.Error(), "spam", http.StatusConflict},
+		{"unexpected error", "unexpected error", "spam", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.reason != "" {
+				b, _ := json.Marshal(models.ReportInput{Reason: tt.reason})
+				body = bytes.NewBuffer(b)
+			}
+
+			r := httptest.NewRequest("POST", "/posts/{slug}/report", body)
+			ctx := getCtxWithParam(r, params{"slug": tt.slugRoute})
+			ctx = context.WithValue(ctx, "user_id", 1)
+			r = r.WithContext(ctx)
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.mod.Report)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestModeration_GetReports(t *testing.T) {
+	var tests = []struct {
+		name       string
+		query      string
+		statusCode int
+	}{
+		{"success", "status=pending", http.StatusOK},
+		{"unauthorized", slug.Make(service.ErrUnauthorized.Error()) + "=1", http.StatusUnauthorized},
+		{"invalid status", slug.Make(service.ErrInvalidStatus.Error()) + "=1", http.StatusBadRequest},
+		{"unexpected error", slug.Make("unexpected error") + "=1", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/moderation/reports?"+tt.query, nil)
+			ctx := context.WithValue(r.Context(), "user_id", 1)
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.mod.GetReports)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestModeration_Resolve(t *testing.T) {
+	var tests = []struct {
+		name       string
+		idRoute    string
+		action     string
+		authId     int
+		statusCode int
+	}{
+		{"success", "1", "hide", 1, http.StatusOK},
+		{"invalid id", "x", "hide", 1, http.StatusNotFound},
+		{"error decoding json", "1", "", 1, http.StatusBadRequest},
+		{"error validation", "1", "ban", 1, http.StatusBadRequest},
+		{"unauthorized", "1", "hide", repository.NotFoundKeyInt, http.StatusUnauthorized},
+		{"no report", strconv.Itoa(repository.NotFoundKeyInt), "hide", 1, http.StatusNotFound},
+		{"already resolved", strconv.Itoa(repository.IncorrectKeyInt), "hide", 1, http.StatusConflict},
+		{"unexpected error", strconv.Itoa(repository.UnexpectedKeyInt), "hide", 1, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.action != "" {
+				b, _ := json.Marshal(models.ResolveReportInput{Action: tt.action})
+				body = bytes.NewBuffer(b)
+			}
+
+			r := httptest.NewRequest("POST", "/moderation/reports/{id}/resolve", body)
+			ctx := getCtxWithParam(r, params{"id": tt.idRoute})
+			ctx = context.WithValue(ctx, "user_id", tt.authId)
+			r = r.WithContext(ctx)
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.mod.Resolve)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
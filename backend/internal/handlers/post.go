@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	service "github.com/Noblefel/ManorTalk/backend/internal/service/post"
 	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
@@ -26,15 +28,18 @@ func NewPostHandlers(s service.PostService) *PostHandlers {
 }
 
 func (h *PostHandlers) Create(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		res.Message(w, http.StatusBadRequest, "Error parsing form")
+	form, err := readForm(r, "image")
+	if err != nil {
+		formError(w, err)
 		return
 	}
+	defer form.close()
 
-	cId, _ := strconv.Atoi(r.FormValue("category_id"))
+	cId, _ := strconv.Atoi(form.values.Get("category_id"))
 	payload := models.PostCreateInput{
-		Title:      strings.TrimSpace(r.FormValue("title")),
-		Content:    strings.TrimSpace(r.FormValue("content")),
+		Title:      strings.TrimSpace(form.values.Get("title")),
+		Excerpt:    strings.TrimSpace(form.values.Get("excerpt")),
+		Content:    strings.TrimSpace(form.values.Get("content")),
 		CategoryId: cId,
 	}
 	payload.Slug = slug.Make(payload.Title)
@@ -47,26 +52,20 @@ func (h *PostHandlers) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	files, ok := r.MultipartForm.File["image"]
-	if ok {
-		f, err := files[0].Open()
-		if err != nil {
-			res.Message(w, http.StatusBadRequest, "Error opening file")
-		}
-		defer f.Close()
-
-		payload.Image = f
-	}
+	payload.Image = form.file
 
 	userId := r.Context().Value("user_id").(int)
 
-	post, err := h.service.Create(payload, userId)
+	post, err := h.service.Create(r.Context(), payload, userId)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNoCategory):
 			res.Message(w, http.StatusNotFound, err.Error())
 			return
-		case errors.Is(err, service.ErrImageTooLarge), errors.Is(err, service.ErrImageInvalid):
+		case errors.Is(err, service.ErrImageTooLarge):
+			res.Message(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		case errors.Is(err, service.ErrImageSize), errors.Is(err, service.ErrImageInvalid):
 			res.Message(w, http.StatusBadRequest, err.Error())
 			return
 		case errors.Is(err, service.ErrDuplicateTitle):
@@ -86,12 +85,19 @@ func (h *PostHandlers) Create(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PostHandlers) Get(w http.ResponseWriter, r *http.Request) {
-	post, err := h.service.Get(chi.URLParam(r, "slug"))
+	post, err := h.service.Get(r.Context(), chi.URLParam(r, "slug"))
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNoPost):
 			res.Message(w, http.StatusNotFound, err.Error())
 			return
+		case errors.Is(err, service.ErrMoved):
+			w.Header().Set("Location", "/api/posts/"+post.Slug)
+			res.JSON(w, http.StatusMovedPermanently, res.Response{
+				Message: err.Error(),
+				Data:    map[string]string{"slug": post.Slug},
+			})
+			return
 		default:
 			log.Println(err)
 			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems retrieving the post")
@@ -99,7 +105,9 @@ func (h *PostHandlers) Get(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	res.JSON(w, http.StatusOK, res.Response{
+	// No Last-Modified, the author and category in the body change without
+	// the post's updated_at, the ETag covers them all
+	res.ConditionalJSON(w, r, time.Time{}, res.Response{
 		Data: post,
 	})
 }
@@ -107,12 +115,15 @@ func (h *PostHandlers) Get(w http.ResponseWriter, r *http.Request) {
 func (h *PostHandlers) GetMany(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 
-	posts, pgMeta, err := h.service.GetMany(q)
+	posts, pgMeta, err := h.service.GetMany(r.Context(), q)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNoCategory):
 			res.Message(w, http.StatusNotFound, err.Error())
 			return
+		case errors.Is(err, service.ErrInvalidField):
+			res.Message(w, http.StatusBadRequest, err.Error())
+			return
 		default:
 			log.Println(err)
 			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems retrieving the posts")
@@ -120,7 +131,7 @@ func (h *PostHandlers) GetMany(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	res.JSON(w, http.StatusOK, res.Response{
+	res.ConditionalJSON(w, r, time.Time{}, res.Response{
 		Data: map[string]interface{}{
 			"pagination_meta": pgMeta,
 			"posts":           posts,
@@ -129,15 +140,18 @@ func (h *PostHandlers) GetMany(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PostHandlers) Update(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		res.Message(w, http.StatusBadRequest, "Error parsing form")
+	form, err := readForm(r, "image")
+	if err != nil {
+		formError(w, err)
 		return
 	}
+	defer form.close()
 
-	cId, _ := strconv.Atoi(r.FormValue("category_id"))
+	cId, _ := strconv.Atoi(form.values.Get("category_id"))
 	payload := models.PostUpdateInput{
-		Title:      strings.TrimSpace(r.FormValue("title")),
-		Content:    strings.TrimSpace(r.FormValue("content")),
+		Title:      strings.TrimSpace(form.values.Get("title")),
+		Excerpt:    strings.TrimSpace(form.values.Get("excerpt")),
+		Content:    strings.TrimSpace(form.values.Get("content")),
 		CategoryId: cId,
 	}
 	payload.Slug = slug.Make(payload.Title)
@@ -150,22 +164,43 @@ func (h *PostHandlers) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	files, ok := r.MultipartForm.File["image"]
-	if ok {
-		f, err := files[0].Open()
+	if hasIfMatch(r) {
+		current, err := h.service.Get(r.Context(), chi.URLParam(r, "slug"))
 		if err != nil {
-			res.Message(w, http.StatusBadRequest, "Error opening file")
+			switch {
+			case errors.Is(err, service.ErrNoPost), errors.Is(err, service.ErrMoved):
+				res.Message(w, http.StatusNotFound, service.ErrNoPost.Error())
+				return
+			default:
+				log.Println(err)
+				res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems updating the post")
+				return
+			}
 		}
-		defer f.Close()
 
-		payload.Image = f
+		if !matchETag(r, current) {
+			stale(w, service.ErrStale, current.Version)
+			return
+		}
+
+		payload.Version = current.Version
+	} else if payload.Version, err = readVersion(form.values); err != nil {
+		versionError(w, err)
+		return
 	}
 
+	payload.Image = form.file
+
 	authId := r.Context().Value("user_id").(int)
 
-	err := h.service.Update(payload, chi.URLParam(r, "slug"), authId)
+	err = h.service.Update(r.Context(), payload, chi.URLParam(r, "slug"), authId)
 	if err != nil {
+		var staleErr *service.StaleError
+
 		switch {
+		case errors.As(err, &staleErr):
+			stale(w, err, staleErr.Version)
+			return
 		case errors.Is(err, service.ErrNoPost):
 			res.Message(w, http.StatusNotFound, err.Error())
 			return
@@ -175,7 +210,10 @@ func (h *PostHandlers) Update(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, service.ErrUnauthorized):
 			res.Message(w, http.StatusUnauthorized, err.Error())
 			return
-		case errors.Is(err, service.ErrImageTooLarge), errors.Is(err, service.ErrImageInvalid):
+		case errors.Is(err, service.ErrImageTooLarge):
+			res.Message(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		case errors.Is(err, service.ErrImageSize), errors.Is(err, service.ErrImageInvalid):
 			res.Message(w, http.StatusBadRequest, err.Error())
 			return
 		case errors.Is(err, service.ErrDuplicateTitle):
@@ -197,7 +235,7 @@ func (h *PostHandlers) Update(w http.ResponseWriter, r *http.Request) {
 func (h *PostHandlers) Delete(w http.ResponseWriter, r *http.Request) {
 	authId := r.Context().Value("user_id").(int)
 
-	err := h.service.Delete(chi.URLParam(r, "slug"), authId)
+	err := h.service.Delete(r.Context(), chi.URLParam(r, "slug"), authId, audit.FromRequest(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNoPost):
@@ -213,18 +251,63 @@ func (h *PostHandlers) Delete(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	res.Message(w, http.StatusOK, "Post has been deleted")
+	res.Message(w, http.StatusOK, "Post has been moved to trash")
+}
+
+func (h *PostHandlers) GetTrash(w http.ResponseWriter, r *http.Request) {
+	authId := r.Context().Value("user_id").(int)
+
+	posts, err := h.service.GetTrash(r.Context(), authId)
+	if err != nil {
+		log.Println(err)
+		res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems retrieving the trash")
+		return
+	}
+
+	res.JSON(w, http.StatusOK, res.Response{
+		Data: posts,
+	})
+}
+
+func (h *PostHandlers) Restore(w http.ResponseWriter, r *http.Request) {
+	authId := r.Context().Value("user_id").(int)
+
+	err := h.service.Restore(r.Context(), chi.URLParam(r, "slug"), authId, audit.FromRequest(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoPost):
+			res.Message(w, http.StatusNotFound, err.Error())
+			return
+		case errors.Is(err, service.ErrUnauthorized):
+			res.Message(w, http.StatusUnauthorized, err.Error())
+			return
+		default:
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems restoring the post")
+			return
+		}
+	}
+
+	res.Message(w, http.StatusOK, "Post has been restored")
 }
 
 func (h *PostHandlers) GetCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetCategories()
+	categories, err := h.service.GetCategories(r.Context())
 	if err != nil {
 		log.Println(err)
 		res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems retrieving categories")
 		return
 	}
 
-	res.JSON(w, http.StatusOK, res.Response{
+	// The list is as recent as its latest category
+	var modified time.Time
+	for _, c := range categories {
+		if c.UpdatedAt != nil && c.UpdatedAt.After(modified) {
+			modified = *c.UpdatedAt
+		}
+	}
+
+	res.ConditionalJSON(w, r, modified, res.Response{
 		Data: categories,
 	})
 }
@@ -9,11 +9,16 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
 	service "github.com/Noblefel/ManorTalk/backend/internal/service/post"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
 	"github.com/gosimple/slug"
 )
 
@@ -22,7 +27,7 @@ func TestNewPostHandlers(t *testing.T) {
 	var c *config.AppConfig
 	cr := redis.NewRepo(db)
 	pr := postgres.NewPostRepo(db)
-	s := service.NewPostService(c, cr, pr)
+	s := service.NewPostService(c, cr, pr, postgres.NewTxManager(db), storage.NewMockStore(), audit.NewMockLogger())
 	post := NewPostHandlers(s)
 
 	typeString := reflect.TypeOf(post).String()
@@ -49,7 +54,8 @@ func TestPost_Create(t *testing.T) {
 		{"error validation", false, "", http.StatusBadRequest},
 		{"no category", false, service.ErrNoCategory.Error(), http.StatusNotFound},
 		{"error image invalid", false, service.ErrImageInvalid.Error(), http.StatusBadRequest},
-		{"error image too large", false, service.ErrImageTooLarge.Error(), http.StatusBadRequest},
+		{"error image too large", false, service.ErrImageTooLarge.Error(), http.StatusRequestEntityTooLarge},
+		{"error image size", false, service.ErrImageSize.Error(), http.StatusBadRequest},
 		{"duplicate title", false, service.ErrDuplicateTitle.Error(), http.StatusConflict},
 		{"unexpected error", false, "unexpected error", http.StatusInternalServerError},
 	}
@@ -93,6 +99,7 @@ func TestPost_Get(t *testing.T) {
 	}{
 		{"success", "post-title", http.StatusOK},
 		{"no post", service.ErrNoPost.Error(), http.StatusNotFound},
+		{"moved", service.ErrMoved.Error(), http.StatusMovedPermanently},
 		{"unexpected error", "unexpected error", http.StatusInternalServerError},
 	}
 
@@ -112,6 +119,30 @@ func TestPost_Get(t *testing.T) {
 	}
 }
 
+func TestPost_GetNotModified(t *testing.T) {
+	r := httptest.NewRequest("GET", "/posts/{slug}", nil)
+	r = r.WithContext(getCtxWithParam(r, params{"slug": "post-title"}))
+	w := httptest.NewRecorder()
+	h.post.Get(w, r)
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expecting an etag")
+	}
+
+	if lm := w.Header().Get("Last-Modified"); lm != "" {
+		t.Errorf("expecting no last modified, got %q", lm)
+	}
+
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.post.Get(w, r)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("want %d, got %d", http.StatusNotModified, w.Code)
+	}
+}
+
 func TestPost_GetMany(t *testing.T) {
 	var tests = []struct {
 		name       string
@@ -120,6 +151,7 @@ func TestPost_GetMany(t *testing.T) {
 	}{
 		{"success", "page=1&limit=10", http.StatusOK},
 		{"no category", slug.Make(service.ErrNoCategory.Error()) + "=1", http.StatusNotFound},
+		{"invalid field", slug.Make(service.ErrInvalidField.Error()) + "=1", http.StatusBadRequest},
 		{"unexpected error", slug.Make("unexpected error") + "=1", http.StatusInternalServerError},
 	}
 
@@ -143,18 +175,23 @@ func TestPost_Update(t *testing.T) {
 		slugRoute      string
 		noForm         bool
 		failValidation bool
+		version        string
 		statusCode     int
 	}{
-		{"success", "slug", false, false, http.StatusOK},
-		{"error parsing form", "", true, false, http.StatusBadRequest},
-		{"error validation", "", false, true, http.StatusBadRequest},
-		{"no post", service.ErrNoPost.Error(), false, false, http.StatusNotFound},
-		{"unauthorized", service.ErrUnauthorized.Error(), false, false, http.StatusUnauthorized},
-		{"no category", service.ErrNoCategory.Error(), false, false, http.StatusNotFound},
-		{"error image invalid", service.ErrImageInvalid.Error(), false, false, http.StatusBadRequest},
-		{"error image too large", service.ErrImageTooLarge.Error(), false, false, http.StatusBadRequest},
-		{"duplicate title", service.ErrDuplicateTitle.Error(), false, false, http.StatusConflict},
-		{"unexpected error", "unexpected error", false, false, http.StatusInternalServerError},
+		{"success", "slug", false, false, "1", http.StatusOK},
+		{"error parsing form", "", true, false, "1", http.StatusBadRequest},
+		{"error validation", "", false, true, "1", http.StatusBadRequest},
+		{"no post", service.ErrNoPost.Error(), false, false, "1", http.StatusNotFound},
+		{"unauthorized", service.ErrUnauthorized.Error(), false, false, "1", http.StatusUnauthorized},
+		{"no category", service.ErrNoCategory.Error(), false, false, "1", http.StatusNotFound},
+		{"error image invalid", service.ErrImageInvalid.Error(), false, false, "1", http.StatusBadRequest},
+		{"error image too large", service.ErrImageTooLarge.Error(), false, false, "1", http.StatusRequestEntityTooLarge},
+		{"error image size", service.ErrImageSize.Error(), false, false, "1", http.StatusBadRequest},
+		{"duplicate title", service.ErrDuplicateTitle.Error(), false, false, "1", http.StatusConflict},
+		{"unexpected error", "unexpected error", false, false, "1", http.StatusInternalServerError},
+		{"no version", "slug", false, false, "", http.StatusPreconditionRequired},
+		{"invalid version", "slug", false, false, "x", http.StatusBadRequest},
+		{"stale version", service.ErrStale.Error(), false, false, "1", http.StatusPreconditionFailed},
 	}
 
 	for _, tt := range tests {
@@ -163,6 +200,9 @@ func TestPost_Update(t *testing.T) {
 			fw := multipart.NewWriter(&b)
 			fw.WriteField("content", longText)
 			fw.WriteField("category_id", "1")
+			if tt.version != "" {
+				fw.WriteField("version", tt.version)
+			}
 			if tt.failValidation {
 				fw.WriteField("title", "x")
 			} else {
@@ -193,6 +233,50 @@ func TestPost_Update(t *testing.T) {
 	}
 }
 
+func TestPost_UpdateIfMatch(t *testing.T) {
+	etag, _ := res.ETag(res.Response{Data: models.Post{}})
+
+	var tests = []struct {
+		name       string
+		slugRoute  string
+		ifMatch    string
+		statusCode int
+	}{
+		{"success", "slug", etag, http.StatusOK},
+		{"any", "slug", "*", http.StatusOK},
+		{"changed", "slug", `"other"`, http.StatusPreconditionFailed},
+		{"weak", "slug", "W/" + etag, http.StatusPreconditionFailed},
+		{"no post", service.ErrNoPost.Error(), etag, http.StatusNotFound},
+		{"moved", service.ErrMoved.Error(), etag, http.StatusNotFound},
+		{"error getting post", "unexpected error", etag, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			fw := multipart.NewWriter(&b)
+			fw.WriteField("title", "sample title")
+			fw.WriteField("content", longText)
+			fw.WriteField("category_id", "1")
+			fw.Close()
+
+			r := httptest.NewRequest("PATCH", "/posts/{slug}", &b)
+			ctx := getCtxWithParam(r, params{"slug": tt.slugRoute})
+			ctx = context.WithValue(ctx, "user_id", 1)
+			r = r.WithContext(ctx)
+			r.Header.Set("Content-Type", fw.FormDataContentType())
+			r.Header.Set("If-Match", tt.ifMatch)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.post.Update)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
 func TestPost_Delete(t *testing.T) {
 	var tests = []struct {
 		name       string
@@ -222,6 +306,61 @@ func TestPost_Delete(t *testing.T) {
 	}
 }
 
+func TestPost_GetTrash(t *testing.T) {
+	var tests = []struct {
+		name       string
+		authId     int
+		statusCode int
+	}{
+		{"success", 1, http.StatusOK},
+		{"unexpected error", repository.UnexpectedKeyInt, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/users/me/trash", nil)
+			ctx := context.WithValue(r.Context(), "user_id", tt.authId)
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.post.GetTrash)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestPost_Restore(t *testing.T) {
+	var tests = []struct {
+		name       string
+		slugRoute  string
+		statusCode int
+	}{
+		{"success", "post-title", http.StatusOK},
+		{"no post", service.ErrNoPost.Error(), http.StatusNotFound},
+		{"unauthorized", service.ErrUnauthorized.Error(), http.StatusUnauthorized},
+		{"unexpected error", "unexpected error", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/posts/{slug}/restore", nil)
+			ctx := getCtxWithParam(r, params{"slug": tt.slugRoute})
+			ctx = context.WithValue(ctx, "user_id", 1)
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.post.Restore)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
 func TestPost_GetCategories(t *testing.T) {
 	var tests = []struct {
 		name       string
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
+)
+
+var errNoVersion = errors.New("no version")
+
+// hasIfMatch tells whether the client sent the ETag it read, otherwise the
+// version is taken from the form with readVersion
+func hasIfMatch(r *http.Request) bool {
+	return r.Header.Get("If-Match") != ""
+}
+
+// matchETag compares If-Match with the ETag a GET would send for data now
+func matchETag(r *http.Request, data interface{}) bool {
+	etag, err := res.ETag(res.Response{Data: data})
+	if err != nil {
+		return false
+	}
+
+	return res.MatchETag(r.Header.Get("If-Match"), etag)
+}
+
+// readVersion gets the version the client is editing from the version form
+// field, for the clients that don't keep the ETag
+func readVersion(values url.Values) (int, error) {
+	v := values.Get("version")
+	if v == "" {
+		return 0, errNoVersion
+	}
+
+	return strconv.Atoi(v)
+}
+
+// versionError responds to a version readVersion couldn't read
+func versionError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errNoVersion) {
+		res.Message(w, http.StatusPreconditionRequired, "The version being edited is required, as If-Match or a version field")
+		return
+	}
+
+	res.Message(w, http.StatusBadRequest, "Invalid version")
+}
+
+// stale responds to an edit of an outdated version with the current one
+func stale(w http.ResponseWriter, err error, version int) {
+	res.JSON(w, http.StatusPreconditionFailed, res.Response{
+		Message: err.Error(),
+		Data:    map[string]int{"version": version},
+	})
+}
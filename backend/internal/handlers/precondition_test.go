@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
+)
+
+func TestReadVersion(t *testing.T) {
+	var tests = []struct {
+		name    string
+		field   string
+		version int
+		isError bool
+	}{
+		{"field", "4", 4, false},
+		{"none", "", 0, true},
+		{"invalid", "x", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := readVersion(url.Values{"version": {tt.field}})
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+
+			if version != tt.version {
+				t.Errorf("want %d, got %d", tt.version, version)
+			}
+		})
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	data := map[string]int{"version": 3}
+	etag, _ := res.ETag(res.Response{Data: data})
+
+	var tests = []struct {
+		name    string
+		ifMatch string
+		want    bool
+	}{
+		{"same", etag, true},
+		{"listed", `"other", ` + etag, true},
+		{"any", "*", true},
+		{"weak", "W/" + etag, false},
+		{"version", `"3"`, false},
+		{"other", `"other"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/", nil)
+			r.Header.Set("If-Match", tt.ifMatch)
+
+			if got := matchETag(r, data); got != tt.want {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestVersionError(t *testing.T) {
+	w := httptest.NewRecorder()
+	versionError(w, errNoVersion)
+
+	if w.Code != http.StatusPreconditionRequired {
+		t.Errorf("want %d, got %d", http.StatusPreconditionRequired, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	versionError(w, errors.New("invalid syntax"))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestStale(t *testing.T) {
+	w := httptest.NewRecorder()
+	stale(w, errors.New("stale"), 5)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("want %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+
+	var body struct {
+		Data struct{ Version int }
+	}
+	json.NewDecoder(w.Body).Decode(&body)
+
+	if body.Data.Version != 5 {
+		t.Errorf("want version 5, got %d", body.Data.Version)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
+)
+
+// uploadForm is a multipart form read in a single pass, it has to be closed
+type uploadForm struct {
+	values url.Values
+	// file is nil when the field had no file
+	file io.ReadSeeker
+	tmp  *os.File
+}
+
+// readForm streams the multipart body rather than parsing it up front, so
+// only the file of the given field is kept. It goes to a temporary file,
+// which keeps the memory used down to the other fields. The body should be
+// capped with middleware.LimitBody, which is what bounds the disk used.
+func readForm(r *http.Request, field string) (*uploadForm, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	form := &uploadForm{values: url.Values{}}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+
+		if err != nil {
+			form.close()
+			return nil, err
+		}
+
+		// Browsers send an empty file part when no file was picked
+		if part.FileName() == "" {
+			b, err := io.ReadAll(part)
+			if err != nil {
+				form.close()
+				return nil, err
+			}
+
+			form.values.Add(part.FormName(), string(b))
+			continue
+		}
+
+		if part.FormName() != field || form.file != nil {
+			if _, err := io.Copy(io.Discard, part); err != nil {
+				form.close()
+				return nil, err
+			}
+			continue
+		}
+
+		if form.tmp, err = os.CreateTemp("", "manortalk-upload-*"); err != nil {
+			return nil, err
+		}
+
+		if _, err := io.Copy(form.tmp, part); err != nil {
+			form.close()
+			return nil, err
+		}
+
+		if _, err := form.tmp.Seek(0, io.SeekStart); err != nil {
+			form.close()
+			return nil, err
+		}
+
+		form.file = form.tmp
+	}
+}
+
+// close removes the temporary file of the upload, if there is one
+func (f *uploadForm) close() {
+	if f.tmp == nil {
+		return
+	}
+
+	f.tmp.Close()
+	if err := os.Remove(f.tmp.Name()); err != nil {
+		log.Println("unable to remove upload: ", err)
+	}
+}
+
+// formError responds to a form readForm couldn't read
+func formError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		res.Message(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+		return
+	}
+
+	res.Message(w, http.StatusBadRequest, "Error parsing form")
+}
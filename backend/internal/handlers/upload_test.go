@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestReadForm(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	var b bytes.Buffer
+	fw := multipart.NewWriter(&b)
+	fw.WriteField("title", "sample title")
+	fw.CreateFormFile("avatar", "")
+	f, _ := fw.CreateFormFile("image", "a.png")
+	f.Write([]byte("first"))
+	f, _ = fw.CreateFormFile("image", "b.png")
+	f.Write([]byte("second"))
+	fw.Close()
+
+	r := httptest.NewRequest("POST", "/", &b)
+	r.Header.Set("Content-Type", fw.FormDataContentType())
+
+	form, err := readForm(r, "image")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if form.values.Get("title") != "sample title" {
+		t.Errorf("unexpected title %q", form.values.Get("title"))
+	}
+
+	content, _ := io.ReadAll(form.file)
+	if string(content) != "first" {
+		t.Errorf("want the first file, got %q", content)
+	}
+
+	form.close()
+
+	if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+		t.Errorf("want the temporary file removed, got %d files", len(entries))
+	}
+}
+
+func TestReadForm_NoFile(t *testing.T) {
+	var b bytes.Buffer
+	fw := multipart.NewWriter(&b)
+	fw.WriteField("title", "sample title")
+	fw.Close()
+
+	r := httptest.NewRequest("POST", "/", &b)
+	r.Header.Set("Content-Type", fw.FormDataContentType())
+
+	form, err := readForm(r, "image")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if form.file != nil {
+		t.Error("expecting no file")
+	}
+}
+
+func TestFormError(t *testing.T) {
+	var tests = []struct {
+		name       string
+		limit      int64
+		statusCode int
+	}{
+		{"too large", 10, http.StatusRequestEntityTooLarge},
+		{"malformed", 0, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			t.Setenv("TMPDIR", tmp)
+
+			var b bytes.Buffer
+			fw := multipart.NewWriter(&b)
+			f, _ := fw.CreateFormFile("image", "a.png")
+			f.Write(make([]byte, 100))
+			if tt.limit == 0 {
+				// Never closed, so the form ends abruptly
+				b.Truncate(b.Len() - 10)
+			} else {
+				fw.Close()
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", &b)
+			r.Header.Set("Content-Type", fw.FormDataContentType())
+			if tt.limit > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, tt.limit)
+			}
+
+			_, err := readForm(r, "image")
+			if err == nil {
+				t.Fatal("expecting error")
+			}
+
+			formError(w, err)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+
+			if entries, _ := os.ReadDir(tmp); len(entries) != 0 {
+				t.Errorf("want the temporary file removed, got %d files", len(entries))
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	service "github.com/Noblefel/ManorTalk/backend/internal/service/user"
 	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
@@ -42,7 +44,7 @@ func (h *UserHandlers) CheckUsername(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.CheckUsername(username)
+	err := h.service.CheckUsername(r.Context(), username)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrDuplicateUsername):
@@ -59,7 +61,7 @@ func (h *UserHandlers) CheckUsername(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandlers) Get(w http.ResponseWriter, r *http.Request) {
-	user, err := h.service.Get(chi.URLParam(r, "username"))
+	user, err := h.service.Get(r.Context(), chi.URLParam(r, "username"))
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrNoUser):
@@ -72,21 +74,24 @@ func (h *UserHandlers) Get(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	res.JSON(w, http.StatusOK, res.Response{
+	// The posts count doesn't move updated_at, so only the ETag is sent
+	res.ConditionalJSON(w, r, time.Time{}, res.Response{
 		Data: user,
 	})
 }
 
 func (h *UserHandlers) UpdateProfile(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		res.Message(w, http.StatusBadRequest, "Error parsing form")
+	form, err := readForm(r, "avatar")
+	if err != nil {
+		formError(w, err)
 		return
 	}
+	defer form.close()
 
 	payload := models.UpdateProfileInput{
-		Name:     r.FormValue("name"),
-		Username: r.FormValue("username"),
-		Bio:      r.FormValue("bio"),
+		Name:     form.values.Get("name"),
+		Username: form.values.Get("username"),
+		Bio:      form.values.Get("bio"),
 	}
 
 	if err := validate.Struct(payload); err != nil {
@@ -97,29 +102,53 @@ func (h *UserHandlers) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	files, ok := r.MultipartForm.File["avatar"]
-	if ok {
-		f, err := files[0].Open()
+	if hasIfMatch(r) {
+		current, err := h.service.Get(r.Context(), chi.URLParam(r, "username"))
 		if err != nil {
-			res.Message(w, http.StatusBadRequest, "Error opening file")
+			switch {
+			case errors.Is(err, service.ErrNoUser):
+				res.Message(w, http.StatusNotFound, err.Error())
+				return
+			default:
+				log.Println(err)
+				res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems updating the profile")
+				return
+			}
+		}
+
+		if !matchETag(r, current) {
+			stale(w, service.ErrStale, current.Version)
+			return
 		}
-		defer f.Close()
 
-		payload.Avatar = f
+		payload.Version = current.Version
+	} else if payload.Version, err = readVersion(form.values); err != nil {
+		versionError(w, err)
+		return
 	}
 
+	payload.Avatar = form.file
+
 	authId := r.Context().Value("user_id").(int)
 
-	avatar, err := h.service.UpdateProfile(payload, chi.URLParam(r, "username"), authId)
+	avatar, err := h.service.UpdateProfile(r.Context(), payload, chi.URLParam(r, "username"), authId, audit.FromRequest(r))
 	if err != nil {
+		var staleErr *service.StaleError
+
 		switch {
+		case errors.As(err, &staleErr):
+			stale(w, err, staleErr.Version)
+			return
 		case errors.Is(err, service.ErrNoUser):
 			res.Message(w, http.StatusNotFound, err.Error())
 			return
 		case errors.Is(err, service.ErrUnauthorized):
 			res.Message(w, http.StatusUnauthorized, err.Error())
 			return
-		case errors.Is(err, service.ErrAvatarTooLarge), errors.Is(err, service.ErrAvatarInvalid):
+		case errors.Is(err, service.ErrAvatarTooLarge):
+			res.Message(w, http.StatusRequestEntityTooLarge, err.Error())
+			return
+		case errors.Is(err, service.ErrAvatarSize), errors.Is(err, service.ErrAvatarInvalid):
 			res.Message(w, http.StatusBadRequest, err.Error())
 			return
 		case errors.Is(err, service.ErrDuplicateUsername):
@@ -137,3 +166,65 @@ func (h *UserHandlers) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		Data:    avatar,
 	})
 }
+
+func (h *UserHandlers) Suspend(w http.ResponseWriter, r *http.Request) {
+	var payload models.SuspendInput
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		res.Message(w, http.StatusBadRequest, "Error decoding json")
+		return
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		res.JSON(w, http.StatusBadRequest, res.Response{
+			Message: "Some fields are invalid",
+			Errors:  err,
+		})
+		return
+	}
+
+	authId := r.Context().Value("user_id").(int)
+
+	err := h.service.Suspend(r.Context(), payload, chi.URLParam(r, "username"), authId, audit.FromRequest(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoUser):
+			res.Message(w, http.StatusNotFound, err.Error())
+			return
+		case errors.Is(err, service.ErrUnauthorized):
+			res.Message(w, http.StatusUnauthorized, err.Error())
+			return
+		default:
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems suspending the user")
+			return
+		}
+	}
+
+	res.Message(w, http.StatusOK, "User has been suspended")
+}
+
+func (h *UserHandlers) Unsuspend(w http.ResponseWriter, r *http.Request) {
+	authId := r.Context().Value("user_id").(int)
+
+	err := h.service.Unsuspend(r.Context(), chi.URLParam(r, "username"), authId, audit.FromRequest(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrNoUser):
+			res.Message(w, http.StatusNotFound, err.Error())
+			return
+		case errors.Is(err, service.ErrUnauthorized):
+			res.Message(w, http.StatusUnauthorized, err.Error())
+			return
+		case errors.Is(err, service.ErrNotSuspended):
+			res.Message(w, http.StatusConflict, err.Error())
+			return
+		default:
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems lifting the suspension")
+			return
+		}
+	}
+
+	res.Message(w, http.StatusOK, "Suspension has been lifted")
+}
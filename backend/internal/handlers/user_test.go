@@ -10,13 +10,17 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
 	service "github.com/Noblefel/ManorTalk/backend/internal/service/user"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
 )
 
 func TestNewUserHandlers(t *testing.T) {
@@ -24,7 +28,7 @@ func TestNewUserHandlers(t *testing.T) {
 	var c *config.AppConfig
 	cr := redis.NewRepo(db)
 	ur := postgres.NewUserRepo(db)
-	s := service.NewUserService(c, cr, ur)
+	s := service.NewUserService(c, cr, ur, postgres.NewTxManager(db), storage.NewMockStore(), audit.NewMockLogger())
 	user := NewUserHandlers(s)
 
 	typeString := reflect.TypeOf(user).String()
@@ -96,23 +100,61 @@ func TestUser_Get(t *testing.T) {
 	}
 }
 
+func TestUser_GetNotModified(t *testing.T) {
+	r := httptest.NewRequest("GET", "/users/{username}", nil)
+	r = r.WithContext(getCtxWithParam(r, params{"username": "example"}))
+	w := httptest.NewRecorder()
+	h.user.Get(w, r)
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expecting an etag")
+	}
+
+	if lm := w.Header().Get("Last-Modified"); lm != "" {
+		t.Errorf("expecting no last modified, got %q", lm)
+	}
+
+	// A date alone can't tell the profile is unchanged
+	r.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).Format(http.TimeFormat))
+	w = httptest.NewRecorder()
+	h.user.Get(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("want %d, got %d", http.StatusOK, w.Code)
+	}
+
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.user.Get(w, r)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("want %d, got %d", http.StatusNotModified, w.Code)
+	}
+}
+
 func TestUser_UpdateProfile(t *testing.T) {
 	var tests = []struct {
 		name           string
 		noForm         bool
 		failValidation bool
 		username       string
+		version        string
 		statusCode     int
 	}{
-		{"success", false, false, "test", http.StatusOK},
-		{"error parsing form", true, false, "", http.StatusBadRequest},
-		{"error validation", false, true, "", http.StatusBadRequest},
-		{"no user", false, false, service.ErrNoUser.Error(), http.StatusNotFound},
-		{"unauthorized", false, false, service.ErrUnauthorized.Error(), http.StatusUnauthorized},
-		{"avatar invalid type", false, false, service.ErrAvatarInvalid.Error(), http.StatusBadRequest},
-		{"avatar too large", false, false, service.ErrAvatarTooLarge.Error(), http.StatusBadRequest},
-		{"duplicate username", false, false, service.ErrDuplicateUsername.Error(), http.StatusConflict},
-		{"unexpected error", false, false, "unexpected error", http.StatusInternalServerError},
+		{"success", false, false, "test", "1", http.StatusOK},
+		{"error parsing form", true, false, "", "1", http.StatusBadRequest},
+		{"error validation", false, true, "", "1", http.StatusBadRequest},
+		{"no user", false, false, service.ErrNoUser.Error(), "1", http.StatusNotFound},
+		{"unauthorized", false, false, service.ErrUnauthorized.Error(), "1", http.StatusUnauthorized},
+		{"avatar invalid type", false, false, service.ErrAvatarInvalid.Error(), "1", http.StatusBadRequest},
+		{"avatar too large", false, false, service.ErrAvatarTooLarge.Error(), "1", http.StatusRequestEntityTooLarge},
+		{"avatar size", false, false, service.ErrAvatarSize.Error(), "1", http.StatusBadRequest},
+		{"duplicate username", false, false, service.ErrDuplicateUsername.Error(), "1", http.StatusConflict},
+		{"unexpected error", false, false, "unexpected error", "1", http.StatusInternalServerError},
+		{"no version", false, false, "test", "", http.StatusPreconditionRequired},
+		{"invalid version", false, false, "test", "x", http.StatusBadRequest},
+		{"stale version", false, false, service.ErrStale.Error(), "1", http.StatusPreconditionFailed},
 	}
 
 	for _, tt := range tests {
@@ -124,6 +166,9 @@ func TestUser_UpdateProfile(t *testing.T) {
 				v = ""
 			}
 			fw.WriteField("username", v)
+			if tt.version != "" {
+				fw.WriteField("version", tt.version)
+			}
 			fw.CreateFormFile("avatar", "x")
 			fw.Close()
 
@@ -148,3 +193,110 @@ func TestUser_UpdateProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestUser_UpdateProfileIfMatch(t *testing.T) {
+	etag, _ := res.ETag(res.Response{Data: models.User{}})
+
+	var tests = []struct {
+		name       string
+		username   string
+		ifMatch    string
+		statusCode int
+	}{
+		{"success", "test", etag, http.StatusOK},
+		{"changed", "test", `"other"`, http.StatusPreconditionFailed},
+		{"no user", service.ErrNoUser.Error(), etag, http.StatusNotFound},
+		{"error getting user", "unexpected error", etag, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			fw := multipart.NewWriter(&b)
+			fw.WriteField("username", "test")
+			fw.Close()
+
+			r := httptest.NewRequest("PATCH", "/users/{username}", &b)
+			ctx := getCtxWithParam(r, params{"username": tt.username})
+			ctx = context.WithValue(ctx, "user_id", 1)
+			r = r.WithContext(ctx)
+			r.Header.Set("Content-Type", fw.FormDataContentType())
+			r.Header.Set("If-Match", tt.ifMatch)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.user.UpdateProfile)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestUser_Suspend(t *testing.T) {
+	var tests = []struct {
+		name       string
+		username   string
+		reason     string
+		statusCode int
+	}{
+		{"success", "test", "spam", http.StatusOK},
+		{"error decoding json", "test", "", http.StatusBadRequest},
+		{"no user", service.ErrNoUser.Error(), "spam", http.StatusNotFound},
+		{"unauthorized", service.ErrUnauthorized.Error(), "spam", http.StatusUnauthorized},
+		{"unexpected error", "unexpected error", "spam", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.reason != "" {
+				b, _ := json.Marshal(models.SuspendInput{Reason: tt.reason, Days: 7})
+				body = bytes.NewBuffer(b)
+			}
+
+			r := httptest.NewRequest("POST", "/users/{username}/suspension", body)
+			ctx := getCtxWithParam(r, params{"username": tt.username})
+			ctx = context.WithValue(ctx, "user_id", 1)
+			r = r.WithContext(ctx)
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.user.Suspend)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestUser_Unsuspend(t *testing.T) {
+	var tests = []struct {
+		name       string
+		username   string
+		statusCode int
+	}{
+		{"success", "test", http.StatusOK},
+		{"no user", service.ErrNoUser.Error(), http.StatusNotFound},
+		{"unauthorized", service.ErrUnauthorized.Error(), http.StatusUnauthorized},
+		{"not suspended", service.ErrNotSuspended.Error(), http.StatusConflict},
+		{"unexpected error", "unexpected error", http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("DELETE", "/users/{username}/suspension", nil)
+			ctx := getCtxWithParam(r, params{"username": tt.username})
+			ctx = context.WithValue(ctx, "user_id", 1)
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(h.user.Unsuspend)
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package lifecycle keeps track of the long running parts of the application
+// and stops them in the reverse order they were added, so nothing is closed
+// while something added after it may still be using it.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+type hook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+type Manager struct {
+	mu    sync.Mutex
+	hooks []hook
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+// OnStop registers a function to run on Shutdown. The context is the one
+// given to Shutdown.
+func (m *Manager) OnStop(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.hooks = append(m.hooks, hook{name, stop})
+}
+
+// Go runs the worker in its own goroutine. On Shutdown its context is
+// canceled and the manager waits for it to return before moving on.
+func (m *Manager) Go(name string, worker func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		worker(ctx)
+	}()
+
+	m.OnStop(name, func(stopCtx context.Context) error {
+		cancel()
+
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	})
+}
+
+// Shutdown runs the stop functions from last to first. One failing or
+// running out of time doesn't keep the rest from running, all the errors are
+// joined instead.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	var errs []error
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stopping %s: %w", hooks[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
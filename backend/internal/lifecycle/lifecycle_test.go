@@ -0,0 +1,69 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManager_Shutdown(t *testing.T) {
+	m := New()
+	var order []string
+
+	m.OnStop("db", func(ctx context.Context) error {
+		order = append(order, "db")
+		return nil
+	})
+
+	m.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		order = append(order, "worker")
+	})
+
+	m.OnStop("server", func(ctx context.Context) error {
+		order = append(order, "server")
+		return errors.New("some error")
+	})
+
+	err := m.Shutdown(context.Background())
+	if err == nil || err.Error() != "stopping server: some error" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	want := []string{"server", "worker", "db"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("want %v, got %v", want, order)
+	}
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Errorf("expecting the second shutdown to do nothing, got %v", err)
+	}
+}
+
+func TestManager_ShutdownTimeout(t *testing.T) {
+	m := New()
+	closed := false
+
+	m.OnStop("db", func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+
+	m.Go("stuck", func(ctx context.Context) {
+		time.Sleep(time.Second)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := m.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded, got %v", err)
+	}
+
+	if !closed {
+		t.Error("expecting the hooks after the stuck worker to still run")
+	}
+}
@@ -2,19 +2,33 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 	res "github.com/Noblefel/ManorTalk/backend/internal/utils/response"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
 )
 
+// notSuspendedTTL bounds how long a suspension that failed to be cached goes
+// unnoticed, as the users without one are cached for that long
+const notSuspendedTTL = time.Minute
+
 type Middleware struct {
-	c *config.AppConfig
+	c         *config.AppConfig
+	cacheRepo repository.CacheRepo
+	userRepo  repository.UserRepo
 }
 
-func New(c *config.AppConfig) *Middleware { return &Middleware{c} }
+func New(c *config.AppConfig, cr repository.CacheRepo, ur repository.UserRepo) *Middleware {
+	return &Middleware{c, cr, ur}
+}
 
 func (m *Middleware) Auth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -36,7 +50,117 @@ func (m *Middleware) Auth(next http.Handler) http.Handler {
 			return
 		}
 
+		// Access tokens outlive a suspension, so they have to be checked on every request
+		suspended, err := m.suspended(r.Context(), tokenDetails.UserId)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				res.Message(w, http.StatusUnauthorized, "Invalid Token")
+				return
+			}
+
+			log.Println(err)
+			res.Message(w, http.StatusInternalServerError, "Sorry, we had some problems verifying your request")
+			return
+		}
+
+		if suspended {
+			res.Message(w, http.StatusForbidden, "Your account has been suspended")
+			return
+		}
+
 		ctx := context.WithValue(r.Context(), "user_id", tokenDetails.UserId)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// suspended asks the cache first. The database has the final say when the
+// cache misses or fails, and its answer is cached for the next requests.
+func (m *Middleware) suspended(ctx context.Context, userId int) (bool, error) {
+	suspended, err := m.cacheRepo.HasSuspension(ctx, userId)
+	if err == nil {
+		return suspended, nil
+	}
+
+	if !errors.Is(err, repository.ErrCacheMiss) {
+		log.Println("checking cached suspension: ", err)
+	}
+
+	user, err := m.userRepo.GetUser(ctx, models.UserFilters{Id: userId})
+	if err != nil {
+		return false, err
+	}
+
+	if !user.IsSuspended() {
+		if err := m.cacheRepo.SetNotSuspended(ctx, userId, notSuspendedTTL); err != nil {
+			log.Println("unable to cache suspension: ", err)
+		}
+
+		return false, nil
+	}
+
+	var d time.Duration
+	if user.SuspendedUntil != nil {
+		d = time.Until(*user.SuspendedUntil)
+	}
+
+	if err := m.cacheRepo.SetSuspension(ctx, userId, d); err != nil {
+		log.Println("unable to cache suspension: ", err)
+	}
+
+	return true, nil
+}
+
+// LimitBody caps the request body at n bytes. Requests that declare a larger
+// body are rejected with 413 right away, the others fail once they read past it.
+func (m *Middleware) LimitBody(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				res.Message(w, http.StatusRequestEntityTooLarge, "Request body is too large")
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CacheControl sets the Cache-Control policy of successful responses. Errors
+// get no-store instead, so that a failure isn't served from a cache.
+func (m *Middleware) CacheControl(policy string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&cacheControlWriter{ResponseWriter: w, policy: policy}, r)
+		})
+	}
+}
+
+// cacheControlWriter sets the header once the status code is known
+type cacheControlWriter struct {
+	http.ResponseWriter
+	policy      string
+	wroteHeader bool
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+
+		if code < http.StatusBadRequest {
+			w.Header().Set("Cache-Control", w.policy)
+		} else {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
@@ -1,19 +1,26 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
 )
 
 func TestNewMiddleware(t *testing.T) {
 	var c *config.AppConfig
-	middleware := New(c)
+	middleware := New(c, redis.NewMockRepo(), postgres.NewMockUserRepo())
 
 	typeString := reflect.TypeOf(middleware).String()
 	if typeString != "*middleware.Middleware" {
@@ -24,7 +31,7 @@ func TestNewMiddleware(t *testing.T) {
 var m = New(&config.AppConfig{
 	AccessTokenKey: "test",
 	AccessTokenExp: 5 * time.Minute,
-})
+}, redis.NewMockRepo(), postgres.NewMockUserRepo())
 
 func TestMiddleware_Auth(t *testing.T) {
 	var sampleToken, _ = token.Generate(token.Details{
@@ -45,6 +52,18 @@ func TestMiddleware_Auth(t *testing.T) {
 		UserId:    2,
 	})
 
+	var suspendedToken, _ = token.Generate(token.Details{
+		SecretKey: m.c.AccessTokenKey,
+		UserId:    repository.SuspendedKeyInt,
+		Duration:  m.c.AccessTokenExp,
+	})
+
+	var unexpectedToken, _ = token.Generate(token.Details{
+		SecretKey: m.c.AccessTokenKey,
+		UserId:    repository.UnexpectedKeyInt,
+		Duration:  m.c.AccessTokenExp,
+	})
+
 	var tests = []struct {
 		name           string
 		authorization  string
@@ -56,6 +75,8 @@ func TestMiddleware_Auth(t *testing.T) {
 		{"empty authorization header", "", 0, http.StatusUnauthorized},
 		{"expired token", sampleToken3, 0, http.StatusUnauthorized},
 		{"invalid token", "asdcapsdjapcjsdpoajd", 0, http.StatusUnauthorized},
+		{"suspended user", suspendedToken, 0, http.StatusForbidden},
+		{"error checking suspension", unexpectedToken, 0, http.StatusInternalServerError},
 	}
 
 	for _, tt := range tests {
@@ -85,3 +106,136 @@ func TestMiddleware_Auth(t *testing.T) {
 		})
 	}
 }
+
+// missingCacheRepo has nothing cached, and keeps what the middleware caches
+type missingCacheRepo struct {
+	repository.CacheRepo
+	cached map[int]bool
+}
+
+func (r missingCacheRepo) HasSuspension(ctx context.Context, userId int) (bool, error) {
+	return false, repository.ErrCacheMiss
+}
+
+func (r missingCacheRepo) SetSuspension(ctx context.Context, userId int, d time.Duration) error {
+	r.cached[userId] = true
+	return nil
+}
+
+func (r missingCacheRepo) SetNotSuspended(ctx context.Context, userId int, d time.Duration) error {
+	r.cached[userId] = false
+	return nil
+}
+
+func TestMiddleware_AuthCacheMiss(t *testing.T) {
+	var tests = []struct {
+		name       string
+		userId     int
+		statusCode int
+		cached     bool
+	}{
+		{"not suspended", 1, http.StatusOK, true},
+		{"suspended", repository.SuspendedKeyInt, http.StatusForbidden, true},
+		{"deleted user", repository.NotFoundKeyInt, http.StatusUnauthorized, false},
+		{"error getting user", repository.UnexpectedKeyInt, http.StatusInternalServerError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cr := missingCacheRepo{redis.NewMockRepo(), make(map[int]bool)}
+			mw := New(m.c, cr, postgres.NewMockUserRepo())
+
+			accessToken, _ := token.Generate(token.Details{
+				SecretKey: m.c.AccessTokenKey,
+				UserId:    tt.userId,
+				Duration:  m.c.AccessTokenExp,
+			})
+
+			r := httptest.NewRequest("GET", "/", nil)
+			r.Header.Set("Authorization", accessToken)
+			w := httptest.NewRecorder()
+			mw.Auth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+
+			suspended, ok := cr.cached[tt.userId]
+			if ok != tt.cached {
+				t.Errorf("want the answer cached to be %v", tt.cached)
+			}
+
+			if ok && suspended != (tt.statusCode == http.StatusForbidden) {
+				t.Errorf("cached the wrong answer, suspended %v", suspended)
+			}
+		})
+	}
+}
+
+func TestMiddleware_LimitBody(t *testing.T) {
+	var tests = []struct {
+		name        string
+		body        string
+		hideLength  bool
+		statusCode  int
+		expectError bool
+	}{
+		{"success", "12345", false, http.StatusOK, false},
+		{"declared too large", "123456789", false, http.StatusRequestEntityTooLarge, false},
+		{"read too large", "123456789", true, http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.ReadAll(r.Body)
+
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) != tt.expectError {
+					t.Errorf("unexpected read error %v", err)
+				}
+			})
+
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			if tt.hideLength {
+				r.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			h := m.LimitBody(5)(next)
+			h.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestMiddleware_CacheControl(t *testing.T) {
+	var tests = []struct {
+		name   string
+		code   int
+		policy string
+	}{
+		{"success", http.StatusOK, "public, max-age=60"},
+		{"not modified", http.StatusNotModified, "public, max-age=60"},
+		{"error", http.StatusNotFound, "no-store"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.code)
+			})
+
+			r := httptest.NewRequest("GET", "/", nil)
+			w := httptest.NewRecorder()
+			h := m.CacheControl("public, max-age=60")(next)
+			h.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Cache-Control"); got != tt.policy {
+				t.Errorf("want %q, got %q", tt.policy, got)
+			}
+		})
+	}
+}
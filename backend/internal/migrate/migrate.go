@@ -0,0 +1,384 @@
+// Package migrate applies the numbered SQL files of the migrations package.
+// The applied versions are kept in a table of their own, and an advisory lock
+// makes sure only one process migrates at a time, e.g. when several API
+// instances start together.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// The tables the applied versions are kept in
+const (
+	MigrationsTable = "schema_versions"
+	SeedersTable    = "seed_versions"
+)
+
+var (
+	ErrNoMigration = errors.New("no such migration")
+	ErrNoChange    = errors.New("no change")
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration along with when it was applied, nil if it wasn't
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// filePattern matches names like 0001_create_users_table.up.sql
+var filePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads the migrations at the root of fsys, ordered by version. Every
+// version needs an up file, the down file is optional.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, e := range entries {
+		m := filePattern.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+
+		version, _ := strconv.Atoi(m[1])
+
+		b, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("version %d is used by both %s and %s", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.Up = string(b)
+		} else {
+			mig.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("version %d (%s) has no up file", mig.Version, mig.Name)
+		}
+
+		migrations = append(migrations, *mig)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *sql.DB
+	table      string
+	migrations []Migration
+}
+
+// New loads the migrations of fsys, to be tracked in the given table. The
+// migrations and seeders use separate tables.
+func New(db *sql.DB, fsys fs.FS, table string) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("loading migrations: %w", err)
+	}
+
+	return &Migrator{
+		db:         db,
+		table:      table,
+		migrations: migrations,
+	}, nil
+}
+
+// Latest is the highest version there is, 0 when there are none
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration and returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the latest applied migration
+func (m *Migrator) Down(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; ok {
+				done = append(done, m.migrations[i])
+				return m.down(ctx, conn, m.migrations[i])
+			}
+		}
+
+		return ErrNoChange
+	})
+
+	return done, err
+}
+
+// To applies the pending migrations up to the version, then reverts the
+// applied ones above it. Version 0 reverts them all.
+func (m *Migrator) To(ctx context.Context, version int) ([]Migration, error) {
+	if version != 0 && !m.exists(version) {
+		return nil, fmt.Errorf("%w: %d", ErrNoMigration, version)
+	}
+
+	var done []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok || mig.Version > version {
+				continue
+			}
+
+			if err := m.up(ctx, conn, mig); err != nil {
+				return err
+			}
+
+			done = append(done, mig)
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok || mig.Version <= version {
+				continue
+			}
+
+			if err := m.down(ctx, conn, mig); err != nil {
+				return err
+			}
+
+			done = append(done, mig)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Force records the migrations up to the version as applied and the rest as
+// not, without running any of them. It's meant for a database that was
+// migrated by hand before.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version != 0 && !m.exists(version) {
+		return fmt.Errorf("%w: %d", ErrNoMigration, version)
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+m.table); err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+
+			if err := m.record(ctx, tx, mig); err != nil {
+				return err
+			}
+		}
+
+		return tx.Commit()
+	})
+}
+
+// Status lists every migration and whether it was applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			s := Status{Migration: mig}
+			if at, ok := applied[mig.Version]; ok {
+				s.AppliedAt = &at
+			}
+
+			statuses = append(statuses, s)
+		}
+
+		return nil
+	})
+
+	return statuses, err
+}
+
+func (m *Migrator) exists(version int) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withLock runs fn holding the advisory lock of the table. Session locks
+// belong to a connection, so everything goes through the same one.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("getting connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", m.lockKey()); err != nil {
+		return fmt.Errorf("acquiring lock: %w", err)
+	}
+
+	defer func() {
+		// A fresh context, the lock has to be released even if ctx is done
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockKey())
+	}()
+
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`, m.table)
+
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("creating %s table: %w", m.table, err)
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) lockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(m.table))
+	return int64(h.Sum64())
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM "+m.table)
+	if err != nil {
+		return nil, fmt.Errorf("getting applied versions: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+
+	for rows.Next() {
+		var version int
+		var at time.Time
+
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+
+		applied[version] = at
+	}
+
+	return applied, rows.Err()
+}
+
+// up runs the migration along with its record in a single transaction, so
+// a failing one leaves nothing behind
+func (m *Migrator) up(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+		return fmt.Errorf("applying %d_%s: %w", mig.Version, mig.Name, err)
+	}
+
+	if err := m.record(ctx, tx, mig); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) down(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if mig.Down != "" {
+		if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+			return fmt.Errorf("reverting %d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE version = $1", m.table)
+	if _, err := tx.ExecContext(ctx, query, mig.Version); err != nil {
+		return fmt.Errorf("removing version %d: %w", mig.Version, err)
+	}
+
+	return tx.Commit()
+}
+
+func (m *Migrator) record(ctx context.Context, tx *sql.Tx, mig Migration) error {
+	query := fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES ($1, $2, $3)", m.table)
+
+	if _, err := tx.ExecContext(ctx, query, mig.Version, mig.Name, time.Now()); err != nil {
+		return fmt.Errorf("recording version %d: %w", mig.Version, err)
+	}
+
+	return nil
+}
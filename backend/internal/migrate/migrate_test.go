@@ -0,0 +1,98 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Noblefel/ManorTalk/backend/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	var tests = []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int
+		isError  bool
+	}{
+		{"success", fstest.MapFS{
+			"0002_b.up.sql":   {Data: []byte("b")},
+			"0001_a.up.sql":   {Data: []byte("a")},
+			"0001_a.down.sql": {Data: []byte("-a")},
+			"README.md":       {Data: []byte("skipped")},
+		}, []int{1, 2}, false},
+		{"no up file", fstest.MapFS{
+			"0001_a.down.sql": {Data: []byte("-a")},
+		}, nil, true},
+		{"version reused", fstest.MapFS{
+			"0001_a.up.sql": {Data: []byte("a")},
+			"0001_b.up.sql": {Data: []byte("b")},
+		}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := Load(tt.files)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+
+			if len(loaded) != len(tt.versions) {
+				t.Fatalf("want %d migrations, got %d", len(tt.versions), len(loaded))
+			}
+
+			for i, v := range tt.versions {
+				if loaded[i].Version != v {
+					t.Errorf("want version %d at %d, got %d", v, i, loaded[i].Version)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if len(loaded) == 0 || loaded[0].Name != "setup" {
+		t.Errorf("expecting the migrations to start with setup, got %d migrations", len(loaded))
+	}
+
+	for i, m := range loaded {
+		if m.Version != i+1 {
+			t.Errorf("want version %d, got %d (%s)", i+1, m.Version, m.Name)
+		}
+	}
+
+	seeders, err := Load(migrations.Seeders)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if len(seeders) != 2 {
+		t.Errorf("want 2 seeders, got %d", len(seeders))
+	}
+}
+
+func TestMigrator_To(t *testing.T) {
+	m, err := New(nil, fstest.MapFS{"0001_a.up.sql": {Data: []byte("a")}}, MigrationsTable)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if m.Latest() != 1 {
+		t.Errorf("want latest version 1, got %d", m.Latest())
+	}
+
+	// Unknown versions are refused before touching the database
+	if _, err := m.To(context.Background(), 5); err == nil {
+		t.Error("expecting error")
+	}
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type AuditEvent struct {
+	Id         int                    `json:"id"`
+	ActorId    int                    `json:"actor_id,omitempty"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"target_type,omitempty"`
+	TargetId   int                    `json:"target_id,omitempty"`
+	IP         string                 `json:"ip,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+type AuditEventsFilters struct {
+	ActorId int
+	Action  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"io"
+	"time"
+)
+
+// Media is an image uploaded to be embedded inside post content
+type Media struct {
+	Id          int          `json:"id"`
+	UserId      int          `json:"user_id"`
+	Name        string       `json:"name"`
+	URL         string       `json:"url"`
+	ContentType string       `json:"content_type"`
+	Size        int64        `json:"size"`
+	CreatedAt   time.Time    `json:"created_at"`
+	Posts       []MediaUsage `json:"posts"`
+}
+
+// MediaUsage is a post whose content references the media
+type MediaUsage struct {
+	Id    int    `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
+
+type MediaUploadInput struct {
+	File io.ReadSeeker
+}
@@ -1,23 +1,95 @@
 package models
 
 import (
+	"encoding/json"
 	"io"
 	"time"
 )
 
+// PostListFields are the fields a list of posts can be narrowed down to, and
+// PostListDefault the summary returned when none are asked for.
+var (
+	PostListFields = []string{
+		"id", "user_id", "title", "slug", "excerpt", "image", "content", "content_html",
+		"word_count", "reading_time", "category_id", "created_at", "updated_at",
+	}
+	PostListDefault = []string{
+		"id", "user_id", "title", "slug", "excerpt", "image",
+		"word_count", "reading_time", "category_id", "created_at", "updated_at",
+	}
+	PostIncludes = []string{"user", "category"}
+)
+
 type Post struct {
-	Id         int       `json:"id,omitempty"`
-	UserId     int       `json:"user_id,omitempty"`
-	Title      string    `json:"title,omitempty"`
-	Slug       string    `json:"slug,omitempty"`
-	Excerpt    string    `json:"excerpt,omitempty"`
-	Image      string    `json:"image,omitempty"`
-	Content    string    `json:"content,omitempty"`
-	CategoryId int       `json:"category_id,omitempty"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	Category   Category  `json:"category,omitempty"`
-	User       User      `json:"user,omitempty"`
+	Id            int        `json:"id,omitempty"`
+	UserId        int        `json:"user_id,omitempty"`
+	Title         string     `json:"title,omitempty"`
+	Slug          string     `json:"slug,omitempty"`
+	Excerpt       string     `json:"excerpt,omitempty"`
+	Image         string     `json:"image,omitempty"`
+	ImageSrcset   Srcset     `json:"image_srcset,omitempty"`
+	ImageVariants string     `json:"-"` // generated variants, e.g. "400,1200"
+	Content       string     `json:"content,omitempty"`
+	ContentHTML   string     `json:"content_html,omitempty"`
+	WordCount     int        `json:"word_count"`
+	ReadingTime   int        `json:"reading_time"`
+	TOC           []TOCEntry `json:"toc,omitempty"`
+	CategoryId    int        `json:"category_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	HiddenAt      *time.Time `json:"hidden_at,omitempty"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	Version       int        `json:"version,omitempty"`
+	Category      Category   `json:"category,omitempty"`
+	User          User       `json:"user,omitempty"`
+
+	fields []string
+}
+
+// Select narrows the JSON form of the post down to the given keys
+func (p *Post) Select(keys []string) {
+	p.fields = keys
+}
+
+func (p Post) MarshalJSON() ([]byte, error) {
+	type post Post
+
+	b, err := json.Marshal(post(p))
+	if err != nil || p.fields == nil {
+		return b, err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]json.RawMessage, len(p.fields))
+	for _, key := range p.fields {
+		if v, ok := all[key]; ok {
+			selected[key] = v
+		}
+	}
+
+	return json.Marshal(selected)
+}
+
+// Srcset holds the URLs of an image's resized variants by format, then by
+// width descriptor (e.g. "webp" -> "400w" -> URL)
+type Srcset map[string]map[string]string
+
+// TOCEntry is a heading of the post, linked by its anchor id
+type TOCEntry struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Anchor string `json:"anchor"`
+}
+
+// SlugRedirect points a former slug of a post to its current one
+type SlugRedirect struct {
+	PostId    int
+	Slug      string
+	Canonical string
 }
 
 type PostCreateInput struct {
@@ -35,7 +107,9 @@ type PostUpdateInput struct {
 	Excerpt    string `json:"excerpt" validate:"max=255"`
 	Content    string `json:"content" validate:"required,min=50"`
 	CategoryId int    `json:"category_id" validate:"required"`
-	Image      io.ReadSeeker
+	// Version is the one the post had when it was read for editing
+	Version int `json:"version"`
+	Image   io.ReadSeeker
 }
 
 type Category struct {
@@ -53,4 +127,6 @@ type PostsFilters struct {
 	Cursor   int
 	UserId   int
 	Limit    int
+	Fields   []string
+	Include  []string
 }
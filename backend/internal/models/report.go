@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+const (
+	ReportPending   = "pending"
+	ReportDismissed = "dismissed"
+	ReportResolved  = "resolved"
+)
+
+const (
+	ActionDismiss = "dismiss"
+	ActionHide    = "hide"
+	ActionDelete  = "delete"
+	ActionSuspend = "suspend"
+)
+
+type Report struct {
+	Id          int        `json:"id"`
+	PostId      int        `json:"post_id,omitempty"`
+	ReporterId  int        `json:"reporter_id"`
+	Reason      string     `json:"reason"`
+	Note        string     `json:"note,omitempty"`
+	Status      string     `json:"status"`
+	Action      string     `json:"action,omitempty"`
+	ModeratorId int        `json:"moderator_id,omitempty"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Post        Post       `json:"post,omitempty"`
+	Reporter    User       `json:"reporter,omitempty"`
+}
+
+type ReportInput struct {
+	Reason string `json:"reason" validate:"required,oneof=spam harassment hate violence sexual misinformation other"`
+	Note   string `json:"note" validate:"max=1000"`
+}
+
+type ResolveReportInput struct {
+	Action string `json:"action" validate:"required,oneof=dismiss hide delete suspend"`
+	// Days of suspension for the suspend action, 0 bans the author permanently
+	Days int `json:"days" validate:"min=0,max=36500"`
+}
+
+type ReportsFilters struct {
+	Status string
+	Reason string
+	Limit  int
+}
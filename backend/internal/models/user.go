@@ -5,17 +5,55 @@ import (
 	"time"
 )
 
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
-	Id         int       `json:"id,omitempty"`
-	Name       string    `json:"name,omitempty"`
-	Username   string    `json:"username,omitempty"`
-	Avatar     string    `json:"avatar,omitempty"`
-	Bio        string    `json:"bio,omitempty"`
-	Email      string    `json:"email,omitempty"`
-	Password   string    `json:"password,omitempty"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	PostsCount int       `json:"posts_count,omitempty"`
+	Id               int        `json:"id,omitempty"`
+	Name             string     `json:"name,omitempty"`
+	Username         string     `json:"username,omitempty"`
+	Avatar           string     `json:"avatar,omitempty"`
+	AvatarSrcset     Srcset     `json:"avatar_srcset,omitempty"`
+	AvatarVariants   string     `json:"-"` // generated variants, e.g. "64x64"
+	Bio              string     `json:"bio,omitempty"`
+	Email            string     `json:"email,omitempty"`
+	Password         string     `json:"password,omitempty"`
+	Role             string     `json:"role,omitempty"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	PostsCount       int        `json:"posts_count,omitempty"`
+	Version          int        `json:"version,omitempty"`
+}
+
+// Session is a refresh token that hasn't expired yet, users have one at most
+type Session struct {
+	UserId    int       `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IsModerator reports whether the user is allowed to act on reports
+func (u User) IsModerator() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
+}
+
+// IsSuspended reports whether the user is currently serving a suspension
+func (u User) IsSuspended() bool {
+	if u.SuspendedAt == nil {
+		return false
+	}
+
+	return u.SuspendedUntil == nil || u.SuspendedUntil.After(time.Now())
+}
+
+// IsBanned reports whether the user has been suspended permanently
+func (u User) IsBanned() bool {
+	return u.SuspendedAt != nil && u.SuspendedUntil == nil
 }
 
 type UserRegisterInput struct {
@@ -37,7 +75,25 @@ type UpdateProfileInput struct {
 	Name     string `json:"name" validate:"max=255"`
 	Username string `json:"username" validate:"required,min=3,max=40,excludesall=~%^;'<>()[]@!#/&*"`
 	Bio      string `json:"bio" validate:"max=2000"`
-	Avatar   io.ReadSeeker
+	// Version is the one the user had when it was read for editing
+	Version int `json:"version"`
+	Avatar  io.ReadSeeker
+}
+
+type SuspendInput struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+	// Days of suspension, 0 means the user is banned permanently
+	Days int `json:"days" validate:"min=0,max=36500"`
+}
+
+// Until returns when the suspension ends, or nil if it is permanent
+func (i SuspendInput) Until() *time.Time {
+	if i.Days == 0 {
+		return nil
+	}
+
+	until := time.Now().AddDate(0, 0, i.Days)
+	return &until
 }
 
 type UserFilters struct {
@@ -0,0 +1,208 @@
+// Package reconcile compares the uploaded images in the store against the
+// ones the database references. Files left behind by failed or interrupted
+// writes are orphans, files the database points to but the store lacks are
+// missing. It also records the variants of the images saved before they
+// were recorded.
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
+)
+
+type Report struct {
+	// Orphans are stored files no row references
+	Orphans []storage.Object
+	// Missing are the keys of referenced images that aren't stored
+	Missing []string
+	// Removed counts the orphans deleted
+	Removed int
+}
+
+type Reconciler struct {
+	c         *config.AppConfig
+	postRepo  repository.PostRepo
+	userRepo  repository.UserRepo
+	mediaRepo repository.MediaRepo
+	store     storage.Store
+}
+
+func New(c *config.AppConfig, pr repository.PostRepo, ur repository.UserRepo, mr repository.MediaRepo, st storage.Store) *Reconciler {
+	return &Reconciler{
+		c:         c,
+		postRepo:  pr,
+		userRepo:  ur,
+		mediaRepo: mr,
+		store:     st,
+	}
+}
+
+// kind is a folder of the store and the images the database keeps in it,
+// mapped to their recorded variants
+type kind struct {
+	prefix   string
+	images   func(ctx context.Context) (map[string]string, error)
+	variants img.Variants
+	record   func(ctx context.Context, name, variants string) (bool, error)
+}
+
+func (r *Reconciler) kinds() []kind {
+	return []kind{
+		{"post/", r.postRepo.GetPostImages, r.c.Images.Post, r.postRepo.SetImageVariants},
+		{"avatar/", r.userRepo.GetAvatars, r.c.Images.Avatar, r.userRepo.SetAvatarVariants},
+		{"media/", r.mediaNames, nil, nil},
+	}
+}
+
+// mediaNames lists the media without variants, as they have none
+func (r *Reconciler) mediaNames(ctx context.Context) (map[string]string, error) {
+	names, err := r.mediaRepo.GetMediaNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[string]string, len(names))
+	for _, name := range names {
+		images[name] = ""
+	}
+
+	return images, nil
+}
+
+// Run reports the orphans older than minAge, which leaves alone the uploads
+// of requests still in flight, and the missing images. Orphans are deleted
+// when remove is true.
+func (r *Reconciler) Run(ctx context.Context, minAge time.Duration, remove bool) (Report, error) {
+	var report Report
+
+	cutoff := time.Now().Add(-minAge)
+
+	for _, k := range r.kinds() {
+		// Listing the store first means a file written in between is either
+		// referenced already or too recent to be an orphan
+		objects, err := r.store.List(ctx, k.prefix)
+		if err != nil {
+			return report, fmt.Errorf("listing %s: %w", k.prefix, err)
+		}
+
+		images, err := k.images(ctx)
+		if err != nil {
+			return report, fmt.Errorf("getting %s images: %w", k.prefix, err)
+		}
+
+		stored := make(map[string]bool, len(objects))
+		for _, obj := range objects {
+			stored[obj.Key] = true
+		}
+
+		expected := make(map[string]bool)
+		for _, name := range sortedNames(images) {
+			key := k.prefix + name
+			if !stored[key] {
+				report.Missing = append(report.Missing, key)
+			}
+
+			// The configured variants are kept too, for the images whose
+			// variants aren't recorded yet
+			variants := append(img.ParseVariants(images[name]), k.variants...)
+			for _, vk := range img.Keys(key, variants...) {
+				expected[vk] = true
+			}
+		}
+
+		for _, obj := range objects {
+			if !expected[obj.Key] && obj.ModTime.Before(cutoff) {
+				report.Orphans = append(report.Orphans, obj)
+			}
+		}
+	}
+
+	if !remove {
+		return report, nil
+	}
+
+	var errs []error
+	for _, obj := range report.Orphans {
+		err := r.store.Delete(ctx, obj.Key)
+		if err != nil && !errors.Is(err, storage.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+
+		report.Removed++
+	}
+
+	if len(errs) > 0 {
+		return report, fmt.Errorf("removing orphans: %w", errors.Join(errs...))
+	}
+
+	return report, nil
+}
+
+// Backfill records the variants found in the store for the images saved
+// before they were recorded, and returns how many images it recorded. Only
+// their widths are known, which is all a srcset needs.
+func (r *Reconciler) Backfill(ctx context.Context) (int, error) {
+	var n int
+
+	for _, k := range r.kinds() {
+		if k.record == nil {
+			continue
+		}
+
+		objects, err := r.store.List(ctx, k.prefix)
+		if err != nil {
+			return n, fmt.Errorf("listing %s: %w", k.prefix, err)
+		}
+
+		images, err := k.images(ctx)
+		if err != nil {
+			return n, fmt.Errorf("getting %s images: %w", k.prefix, err)
+		}
+
+		found := make(map[string]img.Variants)
+		for _, obj := range objects {
+			if key, width, ok := img.OriginalKey(obj.Key); ok {
+				found[key] = append(found[key], img.Variant{Width: width})
+			}
+		}
+
+		for _, name := range sortedNames(images) {
+			if images[name] != "" {
+				continue
+			}
+
+			variants := found[k.prefix+name]
+			sort.Slice(variants, func(i, j int) bool { return variants[i].Width < variants[j].Width })
+
+			recorded, err := k.record(ctx, name, variants.String())
+			if err != nil {
+				return n, fmt.Errorf("recording variants of %s%s: %w", k.prefix, name, err)
+			}
+
+			if recorded {
+				n++
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func sortedNames(images map[string]string) []string {
+	names := make([]string, 0, len(images))
+	for name := range images {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,180 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
+)
+
+// newTestReconciler stores the images referenced by the mock repos, i.e.
+// post/example.jpg with its variants and avatar/example.png without the
+// original, along with a few orphans
+func newTestReconciler(t *testing.T) (*Reconciler, storage.Store) {
+	var tc config.AppConfig
+	tc.Images.Post = []img.Variant{{Width: 400}}
+
+	st := storage.NewMockStore()
+	keys := append(img.Keys("post/example.jpg", tc.Images.Post...),
+		"post/orphan.jpg",
+		"avatar/example-64w.png",
+		"media/example.png",
+		"media/orphan.png",
+	)
+
+	for _, key := range keys {
+		if err := st.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := New(&tc,
+		postgres.NewMockPostRepo(),
+		postgres.NewMockUserRepo(),
+		postgres.NewMockMediaRepo(),
+		st,
+	)
+
+	return r, st
+}
+
+func TestReconciler_Run(t *testing.T) {
+	r, st := newTestReconciler(t)
+
+	report, err := r.Run(context.Background(), 0, false)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	var orphans []string
+	for _, obj := range report.Orphans {
+		orphans = append(orphans, obj.Key)
+	}
+
+	want := []string{"post/orphan.jpg", "avatar/example-64w.png", "media/orphan.png"}
+	if !reflect.DeepEqual(orphans, want) {
+		t.Errorf("want orphans %v, got %v", want, orphans)
+	}
+
+	if !reflect.DeepEqual(report.Missing, []string{"avatar/example.png"}) {
+		t.Errorf("unexpected missing %v", report.Missing)
+	}
+
+	if report.Removed != 0 {
+		t.Errorf("want nothing removed on a dry run, got %d", report.Removed)
+	}
+
+	if _, err := st.Get(context.Background(), "post/orphan.jpg"); err != nil {
+		t.Errorf("want orphan kept on a dry run, got %v", err)
+	}
+}
+
+func TestReconciler_RunDelete(t *testing.T) {
+	r, st := newTestReconciler(t)
+
+	report, err := r.Run(context.Background(), 0, true)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if report.Removed != 3 {
+		t.Errorf("want 3 removed, got %d", report.Removed)
+	}
+
+	for _, key := range []string{"post/orphan.jpg", "media/orphan.png"} {
+		if _, err := st.Get(context.Background(), key); err != storage.ErrNotExist {
+			t.Errorf("want %s removed, got %v", key, err)
+		}
+	}
+
+	for _, key := range []string{"post/example.jpg", "post/example-400w.webp", "media/example.png"} {
+		if _, err := st.Get(context.Background(), key); err != nil {
+			t.Errorf("want %s kept, got %v", key, err)
+		}
+	}
+}
+
+func TestReconciler_RunMinAge(t *testing.T) {
+	r, _ := newTestReconciler(t)
+
+	// Everything was just written, so nothing is old enough to be an orphan
+	report, err := r.Run(context.Background(), time.Hour, true)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if len(report.Orphans) != 0 || report.Removed != 0 {
+		t.Errorf("want no orphans, got %d", len(report.Orphans))
+	}
+}
+
+// recordingPostRepo keeps the variants recorded for each image
+type recordingPostRepo struct {
+	repository.PostRepo
+	recorded map[string]string
+}
+
+func (r recordingPostRepo) SetImageVariants(ctx context.Context, image, variants string) (bool, error) {
+	r.recorded[image] = variants
+	return true, nil
+}
+
+func TestReconciler_Backfill(t *testing.T) {
+	r, st := newTestReconciler(t)
+	if err := st.Put(context.Background(), "post/example-1200w.jpg", strings.NewReader("x"), 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	recorded := make(map[string]string)
+	r.postRepo = recordingPostRepo{r.postRepo, recorded}
+
+	// Both example images have no variants recorded, the avatar's variant
+	// is stored even though its original is missing
+	n, err := r.Backfill(context.Background())
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if n != 2 {
+		t.Errorf("want 2 recorded, got %d", n)
+	}
+
+	if recorded["example.jpg"] != "400,1200" {
+		t.Errorf("want the stored widths recorded, got %q", recorded["example.jpg"])
+	}
+}
+
+func TestReconciler_BackfillListError(t *testing.T) {
+	r, st := newTestReconciler(t)
+	r.store = failingStore{st}
+
+	if _, err := r.Backfill(context.Background()); err == nil {
+		t.Error("expecting error")
+	}
+}
+
+// failingStore fails to list anything
+type failingStore struct {
+	storage.Store
+}
+
+func (s failingStore) List(ctx context.Context, prefix string) ([]storage.Object, error) {
+	return nil, errors.New("some error")
+}
+
+func TestReconciler_RunListError(t *testing.T) {
+	r, st := newTestReconciler(t)
+	r.store = failingStore{st}
+
+	if _, err := r.Run(context.Background(), 0, true); err == nil {
+		t.Error("expecting error")
+	}
+}
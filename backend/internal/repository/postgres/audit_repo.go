@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+)
+
+type AuditRepo struct {
+	db *database.DB
+}
+
+func NewAuditRepo(db *database.DB) repository.AuditRepo {
+	return &AuditRepo{
+		db: db,
+	}
+}
+
+func (r *AuditRepo) CreateEvent(ctx context.Context, e models.AuditEvent) error {
+	metadata, err := json.Marshal(e.Metadata)
+	if err != nil {
+		return err
+	}
+
+	if e.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	query := `
+		INSERT INTO audit_events (
+			actor_id, 
+			action, 
+			target_type, 
+			target_id, 
+			ip, 
+			user_agent, 
+			metadata, 
+			created_at
+		)
+		VALUES (NULLIF($1, 0), $2, NULLIF($3, ''), NULLIF($4, 0), NULLIF($5, ''), NULLIF($6, ''), $7, $8)
+	`
+
+	_, err = r.db.Sql.ExecContext(ctx, query,
+		e.ActorId,
+		e.Action,
+		e.TargetType,
+		e.TargetId,
+		e.IP,
+		e.UserAgent,
+		metadata,
+		time.Now(),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// eventsWhere builds the WHERE clause shared by GetEvents and CountEvents
+func eventsWhere(filters models.AuditEventsFilters) (string, []interface{}) {
+	var args []interface{}
+	where := "\nWHERE 1 = 1"
+
+	if filters.ActorId != 0 {
+		args = append(args, filters.ActorId)
+		where += "\nAND actor_id = $" + strconv.Itoa(len(args))
+	}
+
+	if filters.Action != "" {
+		args = append(args, filters.Action)
+		where += "\nAND action = $" + strconv.Itoa(len(args))
+	}
+
+	if !filters.From.IsZero() {
+		args = append(args, filters.From)
+		where += "\nAND created_at >= $" + strconv.Itoa(len(args))
+	}
+
+	if !filters.To.IsZero() {
+		args = append(args, filters.To)
+		where += "\nAND created_at < $" + strconv.Itoa(len(args))
+	}
+
+	return where, args
+}
+
+func (r *AuditRepo) GetEvents(ctx context.Context, pgMeta *pagination.Meta, filters models.AuditEventsFilters) ([]models.AuditEvent, error) {
+	events := []models.AuditEvent{}
+
+	query := `
+		SELECT 
+			id, 
+			COALESCE(actor_id, 0), 
+			action, 
+			COALESCE(target_type, ''), 
+			COALESCE(target_id, 0), 
+			COALESCE(ip, ''), 
+			COALESCE(user_agent, ''), 
+			metadata, 
+			created_at
+		FROM audit_events`
+
+	where, args := eventsWhere(filters)
+	query += where
+	query += "\nORDER BY id DESC"
+
+	args = append(args, pgMeta.Offset)
+	query += "\nOFFSET $" + strconv.Itoa(len(args))
+
+	args = append(args, filters.Limit)
+	query += "\nLIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := r.db.Sql.QueryContext(ctx, query, args...)
+	if err != nil {
+		return events, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.AuditEvent
+		var metadata []byte
+
+		err = rows.Scan(
+			&e.Id,
+			&e.ActorId,
+			&e.Action,
+			&e.TargetType,
+			&e.TargetId,
+			&e.IP,
+			&e.UserAgent,
+			&metadata,
+			&e.CreatedAt,
+		)
+
+		if err != nil {
+			return events, err
+		}
+
+		if err = json.Unmarshal(metadata, &e.Metadata); err != nil {
+			return events, err
+		}
+
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return events, err
+	}
+
+	return events, nil
+}
+
+func (r *AuditRepo) CountEvents(ctx context.Context, filters models.AuditEventsFilters) (int, error) {
+	var count int
+
+	where, args := eventsWhere(filters)
+	query := "SELECT COUNT(*) FROM audit_events" + where
+
+	err := r.db.Sql.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+)
+
+type mockAuditRepo struct{}
+
+func NewMockAuditRepo() repository.AuditRepo {
+	return &mockAuditRepo{}
+}
+
+func (r *mockAuditRepo) CreateEvent(ctx context.Context, e models.AuditEvent) error {
+	if e.ActorId == repository.UnexpectedKeyInt {
+		return errors.New("some error")
+	}
+
+	return nil
+}
+
+func (r *mockAuditRepo) GetEvents(ctx context.Context, pgMeta *pagination.Meta, filters models.AuditEventsFilters) ([]models.AuditEvent, error) {
+	events := []models.AuditEvent{}
+
+	if filters.Action == repository.UnexpectedKey {
+		return events, errors.New("some error")
+	}
+
+	return events, nil
+}
+
+func (r *mockAuditRepo) CountEvents(ctx context.Context, filters models.AuditEventsFilters) (int, error) {
+	if filters.Action == repository.UnexpectedKey {
+		return 0, errors.New("some error")
+	}
+
+	return 1, nil
+}
@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+)
+
+type MediaRepo struct {
+	conn
+}
+
+func NewMediaRepo(db *database.DB) repository.MediaRepo {
+	return &MediaRepo{
+		conn: conn{db: db},
+	}
+}
+
+func (r *MediaRepo) CreateMedia(ctx context.Context, m models.Media) (models.Media, error) {
+	query := `
+		INSERT INTO media (
+			user_id,
+			name,
+			content_type,
+			size,
+			created_at
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	err := r.q().QueryRowContext(ctx, query,
+		m.UserId,
+		m.Name,
+		m.ContentType,
+		m.Size,
+		time.Now(),
+	).Scan(&m.Id, &m.CreatedAt)
+
+	if err != nil {
+		return m, err
+	}
+
+	return m, nil
+}
+
+// GetMediaByUser lists the user's media, newest first, along with the posts
+// that reference each of them
+func (r *MediaRepo) GetMediaByUser(ctx context.Context, userId int) ([]models.Media, error) {
+	media := []models.Media{}
+
+	query := `
+		SELECT
+			m.id,
+			m.user_id,
+			m.name,
+			m.content_type,
+			m.size,
+			m.created_at,
+			COALESCE(p.id, 0),
+			COALESCE(p.title, ''),
+			COALESCE(p.slug, '')
+		FROM media m
+		LEFT JOIN post_media pm ON (pm.media_id = m.id)
+		LEFT JOIN posts p ON (pm.post_id = p.id)
+		WHERE m.user_id = $1
+		ORDER BY m.id DESC, p.id ASC
+	`
+
+	rows, err := r.q().QueryContext(ctx, query, userId)
+	if err != nil {
+		return media, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.Media
+		var usage models.MediaUsage
+
+		err = rows.Scan(
+			&m.Id,
+			&m.UserId,
+			&m.Name,
+			&m.ContentType,
+			&m.Size,
+			&m.CreatedAt,
+			&usage.Id,
+			&usage.Title,
+			&usage.Slug,
+		)
+
+		if err != nil {
+			return media, err
+		}
+
+		// Rows of the same media are next to each other, one for each post
+		if n := len(media); n == 0 || media[n-1].Id != m.Id {
+			m.Posts = []models.MediaUsage{}
+			media = append(media, m)
+		}
+
+		if usage.Id != 0 {
+			last := &media[len(media)-1]
+			last.Posts = append(last.Posts, usage)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return media, err
+	}
+
+	return media, nil
+}
+
+// DeleteUnusedMedia removes the media uploaded before the given time that no
+// post references anymore, and returns them so their files can be removed
+func (r *MediaRepo) DeleteUnusedMedia(ctx context.Context, before time.Time) ([]models.Media, error) {
+	media := []models.Media{}
+
+	query := `
+		DELETE FROM media m
+		WHERE m.created_at < $1
+		AND NOT EXISTS (SELECT 1 FROM post_media pm WHERE pm.media_id = m.id)
+		RETURNING id, user_id, name
+	`
+
+	rows, err := r.q().QueryContext(ctx, query, before)
+	if err != nil {
+		return media, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.Media
+
+		if err = rows.Scan(&m.Id, &m.UserId, &m.Name); err != nil {
+			return media, err
+		}
+
+		media = append(media, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return media, err
+	}
+
+	return media, nil
+}
+
+// GetMediaNames lists the file name of every media
+func (r *MediaRepo) GetMediaNames(ctx context.Context) ([]string, error) {
+	names := []string{}
+
+	query := `SELECT name FROM media`
+
+	rows, err := r.q().QueryContext(ctx, query)
+	if err != nil {
+		return names, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+
+		if err = rows.Scan(&name); err != nil {
+			return names, err
+		}
+
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return names, err
+	}
+
+	return names, nil
+}
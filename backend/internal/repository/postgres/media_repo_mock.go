@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+)
+
+type mockMediaRepo struct{}
+
+func NewMockMediaRepo() repository.MediaRepo {
+	return &mockMediaRepo{}
+}
+
+func (r *mockMediaRepo) CreateMedia(ctx context.Context, m models.Media) (models.Media, error) {
+	if m.UserId == repository.UnexpectedKeyInt {
+		return m, errors.New("some error")
+	}
+
+	m.Id = 1
+	return m, nil
+}
+
+func (r *mockMediaRepo) GetMediaByUser(ctx context.Context, userId int) ([]models.Media, error) {
+	media := []models.Media{}
+
+	if userId == repository.UnexpectedKeyInt {
+		return media, errors.New("some error")
+	}
+
+	media = append(media, models.Media{Id: 1, UserId: userId, Name: "example.png"})
+	return media, nil
+}
+
+func (r *mockMediaRepo) DeleteUnusedMedia(ctx context.Context, before time.Time) ([]models.Media, error) {
+	media := []models.Media{}
+
+	if before.IsZero() {
+		return media, errors.New("some error")
+	}
+
+	media = append(media,
+		models.Media{Id: 1, Name: "example.png"},
+		models.Media{Id: 2, Name: repository.UnexpectedKey + ".png"},
+	)
+
+	return media, nil
+}
+
+func (r *mockMediaRepo) GetMediaNames(ctx context.Context) ([]string, error) {
+	return []string{"example.png"}, nil
+}
@@ -1,7 +1,11 @@
 package postgres
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
@@ -11,41 +15,137 @@ import (
 )
 
 type PostRepo struct {
-	db *database.DB
+	conn
 }
 
 func NewPostRepo(db *database.DB) repository.PostRepo {
 	return &PostRepo{
-		db: db,
+		conn: conn{db: db},
 	}
 }
 
-func (r *PostRepo) GetPosts(pgMeta *pagination.Meta, filters models.PostsFilters) ([]models.Post, error) {
+// postListColumns maps the fields a list of posts can select to their column
+var postListColumns = map[string]string{
+	"id":                   "p.id",
+	"user_id":              "p.user_id",
+	"title":                "p.title",
+	"slug":                 "p.slug",
+	"excerpt":              "COALESCE(p.excerpt, '')",
+	"image":                "COALESCE(p.image, '')",
+	"image_variants":       "COALESCE(p.image_variants, '')",
+	"content":              "p.content",
+	"content_html":         "COALESCE(p.content_html, '')",
+	"word_count":           "p.word_count",
+	"reading_time":         "p.reading_time",
+	"category_id":          "p.category_id",
+	"created_at":           "p.created_at",
+	"updated_at":           "p.updated_at",
+	"category.name":        "c.name",
+	"category.slug":        "c.slug",
+	"user.name":            "COALESCE(u.name, '')",
+	"user.username":        "u.username",
+	"user.avatar":          "COALESCE(u.avatar, '')",
+	"user.avatar_variants": "COALESCE(u.avatar_variants, '')",
+}
+
+// postListKeys lists the columns to select for the given fields and includes.
+// The id is always selected, as cursor pagination relies on it, and so are
+// the variants of the images for their srcset.
+func postListKeys(filters models.PostsFilters) []string {
+	keys := []string{"id"}
+
+	for _, f := range filters.Fields {
+		if f != "id" {
+			keys = append(keys, f)
+		}
+
+		if f == "image" {
+			keys = append(keys, "image_variants")
+		}
+	}
+
+	for _, inc := range filters.Include {
+		switch inc {
+		case "category":
+			keys = append(keys, "category.name", "category.slug")
+		case "user":
+			keys = append(keys, "user.name", "user.username", "user.avatar", "user.avatar_variants")
+		}
+	}
+
+	return keys
+}
+
+func postListDest(p *models.Post, key string) interface{} {
+	switch key {
+	case "id":
+		return &p.Id
+	case "user_id":
+		return &p.UserId
+	case "title":
+		return &p.Title
+	case "slug":
+		return &p.Slug
+	case "excerpt":
+		return &p.Excerpt
+	case "image":
+		return &p.Image
+	case "image_variants":
+		return &p.ImageVariants
+	case "content":
+		return &p.Content
+	case "content_html":
+		return &p.ContentHTML
+	case "word_count":
+		return &p.WordCount
+	case "reading_time":
+		return &p.ReadingTime
+	case "category_id":
+		return &p.CategoryId
+	case "created_at":
+		return &p.CreatedAt
+	case "updated_at":
+		return &p.UpdatedAt
+	case "category.name":
+		return &p.Category.Name
+	case "category.slug":
+		return &p.Category.Slug
+	case "user.name":
+		return &p.User.Name
+	case "user.username":
+		return &p.User.Username
+	case "user.avatar":
+		return &p.User.Avatar
+	case "user.avatar_variants":
+		return &p.User.AvatarVariants
+	default:
+		return nil
+	}
+}
+
+func (r *PostRepo) GetPosts(ctx context.Context, pgMeta *pagination.Meta, filters models.PostsFilters) ([]models.Post, error) {
 	posts := []models.Post{}
 
-	query := `
-		SELECT 
-			p.id, 
-			p.user_id, 
-			p.title, 
-			p.slug, 
-			COALESCE(p.excerpt, ''),
-			COALESCE(p.image, ''),  
-			p.content, 
-			p.category_id, 
-			p.created_at, 
-			p.updated_at,
-			c.name, 
-			c.slug,
-			COALESCE(u.name, ''), 
-			u.username, 
-			COALESCE(u.avatar, '')
+	keys := postListKeys(filters)
+	columns := make([]string, 0, len(keys))
+	for _, key := range keys {
+		column, ok := postListColumns[key]
+		if !ok {
+			return posts, fmt.Errorf("unknown post field %q", key)
+		}
+
+		columns = append(columns, column)
+	}
+
+	query := "SELECT " + strings.Join(columns, ", ") + `
 		FROM posts p
 		LEFT JOIN categories c ON (p.category_id = c.id)
 		LEFT JOIN users u ON (p.user_id = u.id)`
 
 	var args []interface{}
-	query += "\nWHERE 1 = 1" // placeholder
+	query += "\nWHERE p.hidden_at IS NULL AND p.deleted_at IS NULL"
+	// Posts of banned users are kept for appeals, but left out of listings
+	query += "\nAND (u.suspended_at IS NULL OR u.suspended_until IS NOT NULL)"
 
 	if filters.Category != "" {
 		args = append(args, filters.Category)
@@ -88,7 +188,7 @@ func (r *PostRepo) GetPosts(pgMeta *pagination.Meta, filters models.PostsFilters
 	args = append(args, filters.Limit)
 	query += "\nLIMIT $" + strconv.Itoa(len(args))
 
-	rows, err := r.db.Sql.Query(query, args...)
+	rows, err := r.q().QueryContext(ctx, query, args...)
 	if err != nil {
 		return posts, err
 	}
@@ -97,24 +197,12 @@ func (r *PostRepo) GetPosts(pgMeta *pagination.Meta, filters models.PostsFilters
 	for rows.Next() {
 		var post models.Post
 
-		err = rows.Scan(
-			&post.Id,
-			&post.UserId,
-			&post.Title,
-			&post.Slug,
-			&post.Excerpt,
-			&post.Image,
-			&post.Content,
-			&post.CategoryId,
-			&post.CreatedAt,
-			&post.UpdatedAt,
-			&post.Category.Name,
-			&post.Category.Slug,
-			&post.User.Name,
-			&post.User.Username,
-			&post.User.Avatar,
-		)
+		dest := make([]interface{}, len(keys))
+		for i, key := range keys {
+			dest[i] = postListDest(&post, key)
+		}
 
+		err = rows.Scan(dest...)
 		if err != nil {
 			return posts, err
 		}
@@ -129,7 +217,7 @@ func (r *PostRepo) GetPosts(pgMeta *pagination.Meta, filters models.PostsFilters
 	return posts, nil
 }
 
-func (r *PostRepo) GetPostBySlug(slug string) (models.Post, error) {
+func (r *PostRepo) GetPostBySlug(ctx context.Context, slug string) (models.Post, error) {
 	var post models.Post
 
 	query := `
@@ -140,35 +228,53 @@ func (r *PostRepo) GetPostBySlug(slug string) (models.Post, error) {
 			p.slug, 
 			COALESCE(p.excerpt, ''), 
 			COALESCE(p.image, ''), 
+			COALESCE(p.image_variants, ''),
 			p.content, 
+			COALESCE(p.content_html, ''),
+			p.word_count,
+			p.reading_time,
+			p.toc,
 			p.category_id, 
 			p.created_at, 
 			p.updated_at,
+			p.hidden_at,
+			p.version,
 			COALESCE(u.name, ''), 
 			u.username, 
 			COALESCE(u.avatar, ''),
+			COALESCE(u.avatar_variants, ''),
 			c.name, 
 			c.slug 
 		FROM posts p
 		LEFT JOIN users u ON (p.user_id = u.id)
 		LEFT JOIN categories c ON (p.category_id = c.id)
-		WHERE p.slug = $1 
+		WHERE p.slug = $1 AND p.deleted_at IS NULL
 	`
 
-	err := r.db.Sql.QueryRow(query, slug).Scan(
+	var toc []byte
+
+	err := r.q().QueryRowContext(ctx, query, slug).Scan(
 		&post.Id,
 		&post.UserId,
 		&post.Title,
 		&post.Slug,
 		&post.Excerpt,
 		&post.Image,
+		&post.ImageVariants,
 		&post.Content,
+		&post.ContentHTML,
+		&post.WordCount,
+		&post.ReadingTime,
+		&toc,
 		&post.CategoryId,
 		&post.CreatedAt,
 		&post.UpdatedAt,
+		&post.HiddenAt,
+		&post.Version,
 		&post.User.Name,
 		&post.User.Username,
 		&post.User.Avatar,
+		&post.User.AvatarVariants,
 		&post.Category.Name,
 		&post.Category.Slug,
 	)
@@ -177,12 +283,23 @@ func (r *PostRepo) GetPostBySlug(slug string) (models.Post, error) {
 		return post, err
 	}
 
+	if toc != nil {
+		if err := json.Unmarshal(toc, &post.TOC); err != nil {
+			return post, err
+		}
+	}
+
 	return post, nil
 }
 
-func (r *PostRepo) CreatePost(p models.Post) (models.Post, error) {
+func (r *PostRepo) CreatePost(ctx context.Context, p models.Post) (models.Post, error) {
 	var post models.Post
 
+	toc, err := json.Marshal(p.TOC)
+	if err != nil {
+		return post, err
+	}
+
 	query := `
 		INSERT INTO posts (
 			user_id, 
@@ -190,12 +307,17 @@ func (r *PostRepo) CreatePost(p models.Post) (models.Post, error) {
 			slug, 
 			excerpt,
 			image, 
+			image_variants,
 			content, 
+			content_html,
+			word_count,
+			reading_time,
+			toc,
 			category_id, 
 			created_at, 
 			updated_at
 		)
-		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING 
 			id, 
 			user_id, 
@@ -203,19 +325,28 @@ func (r *PostRepo) CreatePost(p models.Post) (models.Post, error) {
 			slug, 
 			excerpt,
 			COALESCE(image, ''), 
+			COALESCE(image_variants, ''),
 			content, 
+			COALESCE(content_html, ''),
+			word_count,
+			reading_time,
 			category_id, 
 			created_at, 
 			updated_at
 	`
 
-	err := r.db.Sql.QueryRow(query,
+	err = r.q().QueryRowContext(ctx, query,
 		p.UserId,
 		p.Title,
 		p.Slug,
 		p.Excerpt,
 		p.Image,
+		p.ImageVariants,
 		p.Content,
+		p.ContentHTML,
+		p.WordCount,
+		p.ReadingTime,
+		toc,
 		p.CategoryId,
 		time.Now(),
 		time.Now(),
@@ -226,7 +357,11 @@ func (r *PostRepo) CreatePost(p models.Post) (models.Post, error) {
 		&post.Slug,
 		&post.Excerpt,
 		&post.Image,
+		&post.ImageVariants,
 		&post.Content,
+		&post.ContentHTML,
+		&post.WordCount,
+		&post.ReadingTime,
 		&post.CategoryId,
 		&post.CreatedAt,
 		&post.UpdatedAt,
@@ -235,35 +370,95 @@ func (r *PostRepo) CreatePost(p models.Post) (models.Post, error) {
 	if err != nil {
 		return post, err
 	}
+	post.TOC = p.TOC
 
 	return post, nil
 }
 
-func (r *PostRepo) UpdatePost(p models.Post) error {
+// UpdatePost saves the post if it is still at p.Version, and returns the new
+// version. Otherwise it returns the current version along with
+// repository.ErrStale. The variants are only saved along with a new image.
+func (r *PostRepo) UpdatePost(ctx context.Context, p models.Post) (int, error) {
+	toc, err := json.Marshal(p.TOC)
+	if err != nil {
+		return 0, err
+	}
+
 	query := `
-		UPDATE posts 
-			SET 
-				title = $1, 
-				slug = $2, 
-				excerpt = $3, 
-				image = COALESCE(NULLIF($4, ''), image),
-				content = $5, 
-				category_id = $6, 
-				updated_at = $7 
-		WHERE id = $8
+		WITH updated AS (
+			UPDATE posts 
+				SET 
+					title = $1, 
+					slug = $2, 
+					excerpt = $3, 
+					image = COALESCE(NULLIF($4, ''), image),
+					image_variants = CASE 
+						WHEN $4 <> '' AND $4 IS DISTINCT FROM image THEN NULLIF($14, '') 
+						ELSE image_variants 
+					END,
+					content = $5, 
+					content_html = $6,
+					word_count = $7,
+					reading_time = $8,
+					toc = $9,
+					category_id = $10, 
+					updated_at = $11,
+					version = version + 1
+			WHERE id = $12 AND version = $13
+			RETURNING version
+		)
+		SELECT version, true FROM updated
+		UNION ALL
+		SELECT version, false FROM posts 
+		WHERE id = $12 AND NOT EXISTS (SELECT 1 FROM updated)
 	`
 
-	_, err := r.db.Sql.Exec(query,
+	var version int
+	var updated bool
+
+	err = r.q().QueryRowContext(ctx, query,
 		p.Title,
 		p.Slug,
 		p.Excerpt,
 		p.Image,
 		p.Content,
+		p.ContentHTML,
+		p.WordCount,
+		p.ReadingTime,
+		toc,
 		p.CategoryId,
 		time.Now(),
 		p.Id,
-	)
+		p.Version,
+		p.ImageVariants,
+	).Scan(&version, &updated)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !updated {
+		return version, repository.ErrStale
+	}
+
+	return version, nil
+}
 
+func (r *PostRepo) HidePost(ctx context.Context, id int) error {
+	query := `UPDATE posts SET hidden_at = $1 WHERE id = $2`
+
+	_, err := r.q().ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *PostRepo) DeletePost(ctx context.Context, id int) error {
+	query := `UPDATE posts SET deleted_at = $1 WHERE id = $2`
+
+	_, err := r.q().ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return err
 	}
@@ -271,10 +466,10 @@ func (r *PostRepo) UpdatePost(p models.Post) error {
 	return nil
 }
 
-func (r *PostRepo) DeletePost(id int) error {
-	query := `DELETE FROM posts WHERE id = $1`
+func (r *PostRepo) RestorePost(ctx context.Context, id int) error {
+	query := `UPDATE posts SET deleted_at = NULL WHERE id = $1`
 
-	_, err := r.db.Sql.Exec(query, id)
+	_, err := r.q().ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -282,19 +477,149 @@ func (r *PostRepo) DeletePost(id int) error {
 	return nil
 }
 
-func (r *PostRepo) CountPosts(filters models.PostsFilters) (int, error) {
+func (r *PostRepo) GetTrashedPosts(ctx context.Context, userId int) ([]models.Post, error) {
+	posts := []models.Post{}
+
+	query := `
+		SELECT 
+			p.id, 
+			p.user_id, 
+			p.title, 
+			p.slug, 
+			COALESCE(p.excerpt, ''),
+			COALESCE(p.image, ''),  
+			p.category_id, 
+			p.created_at, 
+			p.updated_at,
+			p.deleted_at,
+			c.name, 
+			c.slug
+		FROM posts p
+		LEFT JOIN categories c ON (p.category_id = c.id)
+		WHERE p.user_id = $1 AND p.deleted_at IS NOT NULL AND p.hidden_at IS NULL
+		ORDER BY p.deleted_at DESC
+	`
+
+	rows, err := r.q().QueryContext(ctx, query, userId)
+	if err != nil {
+		return posts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var post models.Post
+
+		err = rows.Scan(
+			&post.Id,
+			&post.UserId,
+			&post.Title,
+			&post.Slug,
+			&post.Excerpt,
+			&post.Image,
+			&post.CategoryId,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+			&post.DeletedAt,
+			&post.Category.Name,
+			&post.Category.Slug,
+		)
+
+		if err != nil {
+			return posts, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
+
+	return posts, nil
+}
+
+func (r *PostRepo) GetTrashedPostBySlug(ctx context.Context, slug string) (models.Post, error) {
+	var post models.Post
+
+	query := `
+		SELECT 
+			id, 
+			user_id, 
+			title, 
+			slug, 
+			COALESCE(image, ''), 
+			deleted_at,
+			hidden_at
+		FROM posts
+		WHERE slug = $1 AND deleted_at IS NOT NULL
+	`
+
+	err := r.q().QueryRowContext(ctx, query, slug).Scan(
+		&post.Id,
+		&post.UserId,
+		&post.Title,
+		&post.Slug,
+		&post.Image,
+		&post.DeletedAt,
+		&post.HiddenAt,
+	)
+
+	if err != nil {
+		return post, err
+	}
+
+	return post, nil
+}
+
+func (r *PostRepo) PurgePosts(ctx context.Context, before time.Time) ([]models.Post, error) {
+	posts := []models.Post{}
+
+	query := `
+		DELETE FROM posts 
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		RETURNING id, slug, COALESCE(image, ''), COALESCE(image_variants, '')
+	`
+
+	rows, err := r.q().QueryContext(ctx, query, before)
+	if err != nil {
+		return posts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var post models.Post
+
+		if err = rows.Scan(&post.Id, &post.Slug, &post.Image, &post.ImageVariants); err != nil {
+			return posts, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
+
+	return posts, nil
+}
+
+func (r *PostRepo) CountPosts(ctx context.Context, filters models.PostsFilters) (int, error) {
 	var count int
 	query := `		
 	SELECT 
 		COUNT(*)
 	FROM posts p
 	LEFT JOIN categories c ON (p.category_id = c.id)
+	LEFT JOIN users u ON (p.user_id = u.id)
 	`
 
+	query += "WHERE p.hidden_at IS NULL AND p.deleted_at IS NULL\n"
+	query += "AND (u.suspended_at IS NULL OR u.suspended_until IS NOT NULL)\n"
+
 	if filters.Category != "" {
-		query += "WHERE c.slug = $1\n"
+		query += "AND c.slug = $1\n"
 	} else {
-		query += "WHERE c.slug != $1\n"
+		query += "AND c.slug != $1\n"
 	}
 
 	if filters.Search != "" {
@@ -303,7 +628,7 @@ func (r *PostRepo) CountPosts(filters models.PostsFilters) (int, error) {
 		query += "AND p.title != $2\n"
 	}
 
-	err := r.db.Sql.QueryRow(query, filters.Category, filters.Search).Scan(&count)
+	err := r.q().QueryRowContext(ctx, query, filters.Category, filters.Search).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -311,12 +636,209 @@ func (r *PostRepo) CountPosts(filters models.PostsFilters) (int, error) {
 	return count, nil
 }
 
-func (r *PostRepo) GetCategories() ([]models.Category, error) {
+// GetSlugRedirect finds the current slug of a renamed post, as long as the
+// post can still be read
+func (r *PostRepo) GetSlugRedirect(ctx context.Context, slug string) (models.SlugRedirect, error) {
+	var redirect models.SlugRedirect
+
+	query := `
+		SELECT h.post_id, h.slug, p.slug
+		FROM post_slug_history h
+		JOIN posts p ON (h.post_id = p.id)
+		WHERE h.slug = $1 AND p.deleted_at IS NULL AND p.hidden_at IS NULL
+	`
+
+	err := r.q().QueryRowContext(ctx, query, slug).Scan(
+		&redirect.PostId,
+		&redirect.Slug,
+		&redirect.Canonical,
+	)
+
+	if err != nil {
+		return redirect, err
+	}
+
+	return redirect, nil
+}
+
+// SaveSlugHistory records the old slug of a renamed post. If the post was
+// renamed back to one of its former slugs, that entry is released.
+func (r *PostRepo) SaveSlugHistory(ctx context.Context, postId int, oldSlug, newSlug string) error {
+	query := `
+		WITH released AS (
+			DELETE FROM post_slug_history WHERE post_id = $1 AND slug = $3
+		)
+		INSERT INTO post_slug_history (post_id, slug, created_at)
+		VALUES ($1, $2, $4)
+		ON CONFLICT (slug) DO NOTHING
+	`
+
+	_, err := r.q().ExecContext(ctx, query, postId, oldSlug, newSlug, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetPostMedia replaces the media referenced by the post. Names that don't
+// belong to any media are ignored.
+func (r *PostRepo) SetPostMedia(ctx context.Context, postId int, names []string) error {
+	query := `
+		WITH cleared AS (
+			DELETE FROM post_media 
+			WHERE post_id = $1 
+			AND media_id NOT IN (SELECT id FROM media WHERE name = ANY($2))
+		)
+		INSERT INTO post_media (post_id, media_id)
+		SELECT $1, id FROM media WHERE name = ANY($2)
+		ON CONFLICT DO NOTHING
+	`
+
+	_, err := r.q().ExecContext(ctx, query, postId, names)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetPostImages maps the image of every post, trashed ones included, to the
+// variants recorded with it
+func (r *PostRepo) GetPostImages(ctx context.Context) (map[string]string, error) {
+	images := make(map[string]string)
+
+	query := `
+		SELECT image, COALESCE(image_variants, '') 
+		FROM posts 
+		WHERE image IS NOT NULL AND image <> ''
+	`
+
+	rows, err := r.q().QueryContext(ctx, query)
+	if err != nil {
+		return images, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, variants string
+
+		if err = rows.Scan(&name, &variants); err != nil {
+			return images, err
+		}
+
+		images[name] = variants
+	}
+
+	if err = rows.Err(); err != nil {
+		return images, err
+	}
+
+	return images, nil
+}
+
+// SetImageVariants records the variants of an image saved before they were
+// recorded, and reports whether it had no record yet
+func (r *PostRepo) SetImageVariants(ctx context.Context, image, variants string) (bool, error) {
+	query := `UPDATE posts SET image_variants = $2 WHERE image = $1 AND image_variants IS NULL`
+
+	result, err := r.q().ExecContext(ctx, query, image, variants)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// GetUnrenderedPosts returns up to limit posts with an id above afterId,
+// ordered by it, whose rendered fields were never stored. Only the fields
+// rendering needs are filled in.
+func (r *PostRepo) GetUnrenderedPosts(ctx context.Context, afterId, limit int) ([]models.Post, error) {
+	posts := []models.Post{}
+
+	query := `
+		SELECT id, content, COALESCE(excerpt, ''), version
+		FROM posts
+		WHERE id > $1 AND content <> '' 
+			AND (COALESCE(content_html, '') = '' OR word_count = 0 OR toc IS NULL)
+		ORDER BY id
+		LIMIT $2
+	`
+
+	rows, err := r.q().QueryContext(ctx, query, afterId, limit)
+	if err != nil {
+		return posts, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var post models.Post
+
+		if err = rows.Scan(&post.Id, &post.Content, &post.Excerpt, &post.Version); err != nil {
+			return posts, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return posts, err
+	}
+
+	return posts, nil
+}
+
+// SetRendered stores the rendered fields of the post, unless it was edited
+// since it was read. The version is left alone as the content is the same.
+func (r *PostRepo) SetRendered(ctx context.Context, p models.Post) (bool, error) {
+	toc, err := json.Marshal(p.TOC)
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE posts 
+			SET 
+				content_html = $1,
+				excerpt = $2,
+				word_count = $3,
+				reading_time = $4,
+				toc = $5
+		WHERE id = $6 AND version = $7
+	`
+
+	result, err := r.q().ExecContext(ctx, query,
+		p.ContentHTML,
+		p.Excerpt,
+		p.WordCount,
+		p.ReadingTime,
+		toc,
+		p.Id,
+		p.Version,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (r *PostRepo) GetCategories(ctx context.Context) ([]models.Category, error) {
 	categories := []models.Category{}
 
-	query := `SELECT id, name, slug FROM categories`
+	query := `SELECT id, name, slug, updated_at FROM categories`
 
-	rows, err := r.db.Sql.Query(query)
+	rows, err := r.q().QueryContext(ctx, query)
 	if err != nil {
 		return categories, err
 	}
@@ -329,6 +851,7 @@ func (r *PostRepo) GetCategories() ([]models.Category, error) {
 			&c.Id,
 			&c.Name,
 			&c.Slug,
+			&c.UpdatedAt,
 		)
 
 		if err != nil {
@@ -345,12 +868,12 @@ func (r *PostRepo) GetCategories() ([]models.Category, error) {
 	return categories, nil
 }
 
-func (r *PostRepo) GetCategoryById(id int) (models.Category, error) {
+func (r *PostRepo) GetCategoryById(ctx context.Context, id int) (models.Category, error) {
 	var category models.Category
 
 	query := `SELECT id, name, slug FROM categories WHERE id = $1`
 
-	err := r.db.Sql.QueryRow(query, id).Scan(
+	err := r.q().QueryRowContext(ctx, query, id).Scan(
 		&category.Id,
 		&category.Name,
 		&category.Slug,
@@ -363,12 +886,12 @@ func (r *PostRepo) GetCategoryById(id int) (models.Category, error) {
 	return category, nil
 }
 
-func (r *PostRepo) GetCategoryBySlug(slug string) (models.Category, error) {
+func (r *PostRepo) GetCategoryBySlug(ctx context.Context, slug string) (models.Category, error) {
 	var category models.Category
 
 	query := `SELECT id, name, slug FROM categories WHERE slug = $1`
 
-	err := r.db.Sql.QueryRow(query, slug).Scan(
+	err := r.q().QueryRowContext(ctx, query, slug).Scan(
 		&category.Id,
 		&category.Name,
 		&category.Slug,
@@ -1,8 +1,11 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
@@ -15,21 +18,20 @@ func NewMockPostRepo() repository.PostRepo {
 	return &mockPostRepo{}
 }
 
-func (r *mockPostRepo) CreatePost(p models.Post) (models.Post, error) {
-	var post models.Post
-
+func (r *mockPostRepo) CreatePost(ctx context.Context, p models.Post) (models.Post, error) {
 	if p.Title == repository.DuplicateKey {
-		return post, errors.New("duplicate key value")
+		return p, errors.New("duplicate key value")
 	}
 
 	if p.Title == repository.UnexpectedKey {
-		return post, errors.New("some error")
+		return p, errors.New("some error")
 	}
 
-	return post, nil
+	p.Id = 1
+	return p, nil
 }
 
-func (r *mockPostRepo) GetPosts(pgMeta *pagination.Meta, filters models.PostsFilters) ([]models.Post, error) {
+func (r *mockPostRepo) GetPosts(ctx context.Context, pgMeta *pagination.Meta, filters models.PostsFilters) ([]models.Post, error) {
 	posts := []models.Post{}
 
 	if filters.Order == repository.UnexpectedKey {
@@ -39,10 +41,10 @@ func (r *mockPostRepo) GetPosts(pgMeta *pagination.Meta, filters models.PostsFil
 	return posts, nil
 }
 
-func (r *mockPostRepo) GetPostBySlug(slug string) (models.Post, error) {
+func (r *mockPostRepo) GetPostBySlug(ctx context.Context, slug string) (models.Post, error) {
 	var post models.Post
 
-	if slug == repository.NotFoundKey {
+	if slug == repository.NotFoundKey || slug == repository.MovedKey || slug == repository.IncorrectKey {
 		return post, sql.ErrNoRows
 	}
 
@@ -58,24 +60,44 @@ func (r *mockPostRepo) GetPostBySlug(slug string) (models.Post, error) {
 	return post, nil
 }
 
-func (r *mockPostRepo) UpdatePost(p models.Post) error {
+func (r *mockPostRepo) UpdatePost(ctx context.Context, p models.Post) (int, error) {
 
 	if p.Title == repository.DuplicateKey {
-		return errors.New("duplicate key value")
+		return 0, errors.New("duplicate key value")
 	}
 
 	if p.Title == repository.UnexpectedKey {
-		return errors.New("some error")
+		return 0, errors.New("some error")
 	}
 
 	if p.Title == repository.NotFoundKey {
-		return sql.ErrNoRows
+		return 0, sql.ErrNoRows
+	}
+
+	if p.Title == repository.StaleKey {
+		return p.Version + 2, repository.ErrStale
+	}
+
+	return p.Version + 1, nil
+}
+
+func (r *mockPostRepo) HidePost(ctx context.Context, id int) error {
+	if id == repository.UnexpectedKeyInt {
+		return errors.New("some error")
+	}
+
+	return nil
+}
+
+func (r *mockPostRepo) DeletePost(ctx context.Context, id int) error {
+	if id == repository.UnexpectedKeyInt {
+		return errors.New("some error")
 	}
 
 	return nil
 }
 
-func (r *mockPostRepo) DeletePost(id int) error {
+func (r *mockPostRepo) RestorePost(ctx context.Context, id int) error {
 	if id == repository.UnexpectedKeyInt {
 		return errors.New("some error")
 	}
@@ -83,7 +105,52 @@ func (r *mockPostRepo) DeletePost(id int) error {
 	return nil
 }
 
-func (r *mockPostRepo) CountPosts(filters models.PostsFilters) (int, error) {
+func (r *mockPostRepo) GetTrashedPosts(ctx context.Context, userId int) ([]models.Post, error) {
+	posts := []models.Post{}
+
+	if userId == repository.UnexpectedKeyInt {
+		return posts, errors.New("some error")
+	}
+
+	return posts, nil
+}
+
+func (r *mockPostRepo) GetTrashedPostBySlug(ctx context.Context, slug string) (models.Post, error) {
+	var post models.Post
+
+	if slug == repository.NotFoundKey {
+		return post, sql.ErrNoRows
+	}
+
+	if slug == repository.UnexpectedKey {
+		return post, errors.New("some error")
+	}
+
+	if slug == "get-invalid-post" {
+		post.Id = repository.UnexpectedKeyInt
+		return post, nil
+	}
+
+	if slug == repository.HiddenKey {
+		now := time.Now()
+		post.HiddenAt = &now
+		return post, nil
+	}
+
+	return post, nil
+}
+
+func (r *mockPostRepo) PurgePosts(ctx context.Context, before time.Time) ([]models.Post, error) {
+	posts := []models.Post{}
+
+	if before.IsZero() {
+		return posts, errors.New("some error")
+	}
+
+	return posts, nil
+}
+
+func (r *mockPostRepo) CountPosts(ctx context.Context, filters models.PostsFilters) (int, error) {
 	if filters.Order == repository.UnexpectedKey {
 		return 0, errors.New("some error")
 	}
@@ -91,11 +158,78 @@ func (r *mockPostRepo) CountPosts(filters models.PostsFilters) (int, error) {
 	return 1, nil
 }
 
-func (r *mockPostRepo) GetCategories() ([]models.Category, error) {
+func (r *mockPostRepo) GetSlugRedirect(ctx context.Context, slug string) (models.SlugRedirect, error) {
+	var redirect models.SlugRedirect
+
+	if slug == repository.MovedKey {
+		redirect.PostId = 1
+		redirect.Slug = slug
+		redirect.Canonical = "example"
+		return redirect, nil
+	}
+
+	if slug == repository.IncorrectKey {
+		return redirect, errors.New("some error")
+	}
+
+	return redirect, sql.ErrNoRows
+}
+
+func (r *mockPostRepo) SaveSlugHistory(ctx context.Context, postId int, oldSlug, newSlug string) error {
+	if postId == repository.UnexpectedKeyInt {
+		return errors.New("some error")
+	}
+
+	return nil
+}
+
+func (r *mockPostRepo) SetPostMedia(ctx context.Context, postId int, names []string) error {
+	for _, name := range names {
+		if strings.Contains(name, repository.UnexpectedKey) {
+			return errors.New("some error")
+		}
+	}
+
+	return nil
+}
+
+func (r *mockPostRepo) GetPostImages(ctx context.Context) (map[string]string, error) {
+	return map[string]string{"example.jpg": ""}, nil
+}
+
+func (r *mockPostRepo) SetImageVariants(ctx context.Context, image, variants string) (bool, error) {
+	if image == repository.UnexpectedKey {
+		return false, errors.New("some error")
+	}
+
+	return true, nil
+}
+
+func (r *mockPostRepo) GetUnrenderedPosts(ctx context.Context, afterId, limit int) ([]models.Post, error) {
+	if afterId == repository.UnexpectedKeyInt {
+		return nil, errors.New("some error")
+	}
+
+	if afterId > 0 {
+		return []models.Post{}, nil
+	}
+
+	return []models.Post{{Id: 1, Content: "## Example\n\nSome content", Version: 1}}, nil
+}
+
+func (r *mockPostRepo) SetRendered(ctx context.Context, p models.Post) (bool, error) {
+	if p.Excerpt == repository.UnexpectedKey {
+		return false, errors.New("some error")
+	}
+
+	return true, nil
+}
+
+func (r *mockPostRepo) GetCategories(ctx context.Context) ([]models.Category, error) {
 	return nil, nil
 }
 
-func (r *mockPostRepo) GetCategoryById(id int) (models.Category, error) {
+func (r *mockPostRepo) GetCategoryById(ctx context.Context, id int) (models.Category, error) {
 	var category models.Category
 
 	if id == repository.NotFoundKeyInt {
@@ -109,7 +243,7 @@ func (r *mockPostRepo) GetCategoryById(id int) (models.Category, error) {
 	return category, nil
 }
 
-func (r *mockPostRepo) GetCategoryBySlug(slug string) (models.Category, error) {
+func (r *mockPostRepo) GetCategoryBySlug(ctx context.Context, slug string) (models.Category, error) {
 	var category models.Category
 
 	if slug == repository.NotFoundKey {
@@ -0,0 +1,234 @@
+package postgres
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+)
+
+type ReportRepo struct {
+	conn
+}
+
+func NewReportRepo(db *database.DB) repository.ReportRepo {
+	return &ReportRepo{
+		conn: conn{db: db},
+	}
+}
+
+func (r *ReportRepo) CreateReport(ctx context.Context, rp models.Report) error {
+	query := `
+		INSERT INTO reports (
+			post_id, 
+			reporter_id, 
+			reason, 
+			note, 
+			status, 
+			created_at, 
+			updated_at
+		)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7)
+	`
+
+	_, err := r.q().ExecContext(ctx, query,
+		rp.PostId,
+		rp.ReporterId,
+		rp.Reason,
+		rp.Note,
+		models.ReportPending,
+		time.Now(),
+		time.Now(),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReportRepo) GetReports(ctx context.Context, pgMeta *pagination.Meta, filters models.ReportsFilters) ([]models.Report, error) {
+	reports := []models.Report{}
+
+	query := `
+		SELECT 
+			r.id, 
+			COALESCE(r.post_id, 0), 
+			r.reporter_id, 
+			r.reason, 
+			COALESCE(r.note, ''), 
+			r.status, 
+			COALESCE(r.action, ''), 
+			COALESCE(r.moderator_id, 0), 
+			r.resolved_at, 
+			r.created_at, 
+			r.updated_at,
+			COALESCE(p.title, ''), 
+			COALESCE(p.slug, ''), 
+			COALESCE(p.user_id, 0),
+			u.username
+		FROM reports r
+		LEFT JOIN posts p ON (r.post_id = p.id)
+		LEFT JOIN users u ON (r.reporter_id = u.id)
+		WHERE r.status = $1`
+
+	args := []interface{}{filters.Status}
+
+	if filters.Reason != "" {
+		args = append(args, filters.Reason)
+		query += "\nAND r.reason = $" + strconv.Itoa(len(args))
+	}
+
+	query += "\nORDER BY r.id ASC"
+
+	args = append(args, pgMeta.Offset)
+	query += "\nOFFSET $" + strconv.Itoa(len(args))
+
+	args = append(args, filters.Limit)
+	query += "\nLIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := r.q().QueryContext(ctx, query, args...)
+	if err != nil {
+		return reports, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var report models.Report
+
+		err = rows.Scan(
+			&report.Id,
+			&report.PostId,
+			&report.ReporterId,
+			&report.Reason,
+			&report.Note,
+			&report.Status,
+			&report.Action,
+			&report.ModeratorId,
+			&report.ResolvedAt,
+			&report.CreatedAt,
+			&report.UpdatedAt,
+			&report.Post.Title,
+			&report.Post.Slug,
+			&report.Post.UserId,
+			&report.Reporter.Username,
+		)
+
+		if err != nil {
+			return reports, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	if err = rows.Err(); err != nil {
+		return reports, err
+	}
+
+	return reports, nil
+}
+
+func (r *ReportRepo) GetReportById(ctx context.Context, id int) (models.Report, error) {
+	var report models.Report
+
+	query := `
+		SELECT 
+			r.id, 
+			COALESCE(r.post_id, 0), 
+			r.reporter_id, 
+			r.reason, 
+			COALESCE(r.note, ''), 
+			r.status, 
+			COALESCE(r.action, ''), 
+			COALESCE(r.moderator_id, 0), 
+			r.resolved_at, 
+			r.created_at, 
+			r.updated_at,
+			COALESCE(p.title, ''), 
+			COALESCE(p.slug, ''), 
+			COALESCE(p.user_id, 0),
+			COALESCE(p.image, '')
+		FROM reports r
+		LEFT JOIN posts p ON (r.post_id = p.id)
+		WHERE r.id = $1
+	`
+
+	err := r.q().QueryRowContext(ctx, query, id).Scan(
+		&report.Id,
+		&report.PostId,
+		&report.ReporterId,
+		&report.Reason,
+		&report.Note,
+		&report.Status,
+		&report.Action,
+		&report.ModeratorId,
+		&report.ResolvedAt,
+		&report.CreatedAt,
+		&report.UpdatedAt,
+		&report.Post.Title,
+		&report.Post.Slug,
+		&report.Post.UserId,
+		&report.Post.Image,
+	)
+
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+func (r *ReportRepo) CountReports(ctx context.Context, filters models.ReportsFilters) (int, error) {
+	var count int
+
+	query := `SELECT COUNT(*) FROM reports WHERE status = $1`
+	args := []interface{}{filters.Status}
+
+	if filters.Reason != "" {
+		args = append(args, filters.Reason)
+		query += " AND reason = $2"
+	}
+
+	err := r.q().QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// ResolveReports closes every pending report of the same post in one go,
+// so the queue doesn't keep showing a post that has already been handled.
+func (r *ReportRepo) ResolveReports(ctx context.Context, rp models.Report) error {
+	query := `
+		UPDATE reports 
+			SET 
+				status = $1, 
+				action = $2, 
+				moderator_id = $3, 
+				resolved_at = $4, 
+				updated_at = $4 
+		WHERE status = $5 AND (id = $6 OR post_id = $7)
+	`
+
+	_, err := r.q().ExecContext(ctx, query,
+		rp.Status,
+		rp.Action,
+		rp.ModeratorId,
+		time.Now(),
+		models.ReportPending,
+		rp.Id,
+		rp.PostId,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+)
+
+type mockReportRepo struct{}
+
+func NewMockReportRepo() repository.ReportRepo {
+	return &mockReportRepo{}
+}
+
+func (r *mockReportRepo) CreateReport(ctx context.Context, rp models.Report) error {
+	if rp.Reason == repository.DuplicateKey {
+		return errors.New("duplicate key value")
+	}
+
+	if rp.Reason == repository.UnexpectedKey {
+		return errors.New("some error")
+	}
+
+	return nil
+}
+
+func (r *mockReportRepo) GetReports(ctx context.Context, pgMeta *pagination.Meta, filters models.ReportsFilters) ([]models.Report, error) {
+	reports := []models.Report{}
+
+	if filters.Reason == repository.UnexpectedKey {
+		return reports, errors.New("some error")
+	}
+
+	return reports, nil
+}
+
+func (r *mockReportRepo) GetReportById(ctx context.Context, id int) (models.Report, error) {
+	var report models.Report
+	report.Id = id
+	report.PostId = 1
+	report.Status = models.ReportPending
+
+	if id == repository.NotFoundKeyInt {
+		return report, sql.ErrNoRows
+	}
+
+	if id == repository.UnexpectedKeyInt {
+		return report, errors.New("some error")
+	}
+
+	if id == repository.IncorrectKeyInt {
+		report.Status = models.ReportResolved
+		return report, nil
+	}
+
+	// A post written by the staff member of the same key
+	if id == repository.AdminKeyInt || id == repository.ModeratorKeyInt {
+		report.Post.UserId = id
+		return report, nil
+	}
+
+	return report, nil
+}
+
+func (r *mockReportRepo) CountReports(ctx context.Context, filters models.ReportsFilters) (int, error) {
+	if filters.Reason == repository.UnexpectedKey {
+		return 0, errors.New("some error")
+	}
+
+	return 1, nil
+}
+
+func (r *mockReportRepo) ResolveReports(ctx context.Context, rp models.Report) error {
+	if rp.Id == repository.UnexpectedKeyInt {
+		return errors.New("some error")
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+)
+
+// querier is what *sql.DB and *sql.Tx have in common
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// conn is embedded by the repos. The transaction is only set on the ones
+// handed out by TxManager.WithinTx.
+type conn struct {
+	db *database.DB
+	tx *sql.Tx
+}
+
+func (c conn) q() querier {
+	if c.tx != nil {
+		return c.tx
+	}
+
+	return c.db.Sql
+}
+
+type TxManager struct {
+	db *database.DB
+}
+
+func NewTxManager(db *database.DB) repository.TxManager {
+	return &TxManager{
+		db: db,
+	}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(r repository.Repos) error) error {
+	tx, err := m.db.Sql.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+
+	// Does nothing once committed
+	defer tx.Rollback()
+
+	c := conn{db: m.db, tx: tx}
+
+	err = fn(repository.Repos{
+		Post:   &PostRepo{conn: c},
+		User:   &UserRepo{conn: c},
+		Media:  &MediaRepo{conn: c},
+		Report: &ReportRepo{conn: c},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+)
+
+// mockTxManager hands out the repos it was given. They keep nothing that
+// would need rolling back, so only a canceled context is simulated, which
+// is when beginning a real transaction fails.
+type mockTxManager struct {
+	repos repository.Repos
+}
+
+func NewMockTxManager(repos repository.Repos) repository.TxManager {
+	return &mockTxManager{
+		repos: repos,
+	}
+}
+
+func (m *mockTxManager) WithinTx(ctx context.Context, fn func(r repository.Repos) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return fn(m.repos)
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"time"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
@@ -9,16 +10,16 @@ import (
 )
 
 type UserRepo struct {
-	db *database.DB
+	conn
 }
 
 func NewUserRepo(db *database.DB) repository.UserRepo {
 	return &UserRepo{
-		db: db,
+		conn: conn{db: db},
 	}
 }
 
-func (r *UserRepo) CreateUser(username, email, password string) (int, error) {
+func (r *UserRepo) CreateUser(ctx context.Context, username, email, password string) (int, error) {
 	query := `
 		INSERT INTO users (
 			username, 
@@ -33,7 +34,7 @@ func (r *UserRepo) CreateUser(username, email, password string) (int, error) {
 
 	var id int
 
-	err := r.db.Sql.QueryRow(query,
+	err := r.q().QueryRowContext(ctx, query,
 		username,
 		email,
 		password,
@@ -48,7 +49,7 @@ func (r *UserRepo) CreateUser(username, email, password string) (int, error) {
 	return id, nil
 }
 
-func (r *UserRepo) GetUser(filters models.UserFilters) (models.User, error) {
+func (r *UserRepo) GetUser(ctx context.Context, filters models.UserFilters) (models.User, error) {
 	var user models.User
 
 	query := `
@@ -57,14 +58,20 @@ func (r *UserRepo) GetUser(filters models.UserFilters) (models.User, error) {
 		COALESCE(u.name,''), 
 		u.username, 
 		COALESCE(u.avatar,''), 
+		COALESCE(u.avatar_variants,''), 
 		COALESCE(u.bio, ''),
 		u.email, 
 		u.password, 
+		u.role, 
+		u.suspended_at, 
+		u.suspended_until, 
+		COALESCE(u.suspension_reason, ''), 
 		u.created_at, 
 		u.updated_at, 
+		u.version,
 		COUNT(p.id) AS posts_count
 	FROM users u 
-	LEFT JOIN posts p ON (p.user_id = u.id)`
+	LEFT JOIN posts p ON (p.user_id = u.id AND p.deleted_at IS NULL)`
 
 	var arg interface{}
 	if filters.Email != "" {
@@ -80,16 +87,22 @@ func (r *UserRepo) GetUser(filters models.UserFilters) (models.User, error) {
 
 	query += "\nGROUP BY u.id"
 
-	err := r.db.Sql.QueryRow(query, arg).Scan(
+	err := r.q().QueryRowContext(ctx, query, arg).Scan(
 		&user.Id,
 		&user.Name,
 		&user.Username,
 		&user.Avatar,
+		&user.AvatarVariants,
 		&user.Bio,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.SuspendedAt,
+		&user.SuspendedUntil,
+		&user.SuspensionReason,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
 		&user.PostsCount,
 	)
 
@@ -100,21 +113,39 @@ func (r *UserRepo) GetUser(filters models.UserFilters) (models.User, error) {
 	return user, nil
 }
 
-func (r *UserRepo) UpdateUser(u models.User) error {
+// UpdateUser saves the user if it is still at u.Version, and returns the new
+// version. Otherwise it returns the current version along with
+// repository.ErrStale. The variants are only saved along with a new avatar.
+func (r *UserRepo) UpdateUser(ctx context.Context, u models.User) (int, error) {
 	query := `
-	UPDATE users 
-		SET 
-			name = NULLIF($1, ''), 
-			username = $2, 
-			avatar = COALESCE(NULLIF($3, ''), avatar),
-			bio = NULLIF($4, ''), 
-			email = COALESCE(NULLIF($5, ''), email), 
-			password = COALESCE(NULLIF($6, ''), password), 
-			updated_at = $7 
-	WHERE id = $8
+	WITH updated AS (
+		UPDATE users 
+			SET 
+				name = NULLIF($1, ''), 
+				username = $2, 
+				avatar = COALESCE(NULLIF($3, ''), avatar),
+				avatar_variants = CASE 
+					WHEN $3 <> '' AND $3 IS DISTINCT FROM avatar THEN NULLIF($10, '') 
+					ELSE avatar_variants 
+				END,
+				bio = NULLIF($4, ''), 
+				email = COALESCE(NULLIF($5, ''), email), 
+				password = COALESCE(NULLIF($6, ''), password), 
+				updated_at = $7,
+				version = version + 1
+		WHERE id = $8 AND version = $9
+		RETURNING version
+	)
+	SELECT version, true FROM updated
+	UNION ALL
+	SELECT version, false FROM users 
+	WHERE id = $8 AND NOT EXISTS (SELECT 1 FROM updated)
 `
 
-	_, err := r.db.Sql.Exec(query,
+	var version int
+	var updated bool
+
+	err := r.q().QueryRowContext(ctx, query,
 		u.Name,
 		u.Username,
 		u.Avatar,
@@ -123,11 +154,131 @@ func (r *UserRepo) UpdateUser(u models.User) error {
 		u.Password,
 		time.Now(),
 		u.Id,
-	)
+		u.Version,
+		u.AvatarVariants,
+	).Scan(&version, &updated)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !updated {
+		return version, repository.ErrStale
+	}
+
+	return version, nil
+}
+
+// SuspendUser suspends the user until the given time, or permanently if it is nil
+func (r *UserRepo) SuspendUser(ctx context.Context, id int, until *time.Time, reason string) error {
+	query := `
+	UPDATE users 
+		SET 
+			suspended_at = $1, 
+			suspended_until = $2, 
+			suspension_reason = NULLIF($3, ''), 
+			updated_at = $1 
+	WHERE id = $4
+`
 
+	_, err := r.q().ExecContext(ctx, query, time.Now(), until, reason, id)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (r *UserRepo) UnsuspendUser(ctx context.Context, id int) error {
+	query := `
+	UPDATE users 
+		SET 
+			suspended_at = NULL, 
+			suspended_until = NULL, 
+			suspension_reason = NULL, 
+			updated_at = $1 
+	WHERE id = $2
+`
+
+	_, err := r.q().ExecContext(ctx, query, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *UserRepo) SetRole(ctx context.Context, id int, role string) error {
+	query := `UPDATE users SET role = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := r.q().ExecContext(ctx, query, role, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetPassword replaces the password, which is expected to be hashed already
+func (r *UserRepo) SetPassword(ctx context.Context, id int, password string) error {
+	query := `UPDATE users SET password = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := r.q().ExecContext(ctx, query, password, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAvatars maps the avatar of every user that has one to the variants
+// recorded with it
+func (r *UserRepo) GetAvatars(ctx context.Context) (map[string]string, error) {
+	avatars := make(map[string]string)
+
+	query := `
+		SELECT avatar, COALESCE(avatar_variants, '') 
+		FROM users 
+		WHERE avatar IS NOT NULL AND avatar <> ''
+	`
+
+	rows, err := r.q().QueryContext(ctx, query)
+	if err != nil {
+		return avatars, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, variants string
+
+		if err = rows.Scan(&name, &variants); err != nil {
+			return avatars, err
+		}
+
+		avatars[name] = variants
+	}
+
+	if err = rows.Err(); err != nil {
+		return avatars, err
+	}
+
+	return avatars, nil
+}
+
+// SetAvatarVariants records the variants of an avatar saved before they were
+// recorded, and reports whether it had no record yet
+func (r *UserRepo) SetAvatarVariants(ctx context.Context, avatar, variants string) (bool, error) {
+	query := `UPDATE users SET avatar_variants = $2 WHERE avatar = $1 AND avatar_variants IS NULL`
+
+	result, err := r.q().ExecContext(ctx, query, avatar, variants)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
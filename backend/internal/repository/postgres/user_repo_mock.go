@@ -1,8 +1,10 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
@@ -15,7 +17,7 @@ func NewMockUserRepo() repository.UserRepo {
 	return &mockUserRepo{}
 }
 
-func (r *mockUserRepo) CreateUser(username, email, password string) (int, error) {
+func (r *mockUserRepo) CreateUser(ctx context.Context, username, email, password string) (int, error) {
 	if email == repository.DuplicateKey {
 		return 0, errors.New("duplicate key value")
 	}
@@ -27,10 +29,11 @@ func (r *mockUserRepo) CreateUser(username, email, password string) (int, error)
 	return 1, nil
 }
 
-func (r *mockUserRepo) GetUser(filters models.UserFilters) (models.User, error) {
+func (r *mockUserRepo) GetUser(ctx context.Context, filters models.UserFilters) (models.User, error) {
 	var user models.User
 	pw, _ := bcrypt.GenerateFromPassword([]byte("password"), 7)
 	user.Password = string(pw)
+	user.Role = models.RoleUser
 
 	if filters.Id == repository.NotFoundKeyInt ||
 		filters.Email == repository.NotFoundKey ||
@@ -44,6 +47,27 @@ func (r *mockUserRepo) GetUser(filters models.UserFilters) (models.User, error)
 		return user, errors.New("unexpected error")
 	}
 
+	if filters.Id == repository.AdminKeyInt {
+		user.Id = repository.AdminKeyInt
+		user.Role = models.RoleAdmin
+		return user, nil
+	}
+
+	if filters.Id == repository.ModeratorKeyInt {
+		user.Id = repository.ModeratorKeyInt
+		user.Role = models.RoleModerator
+		return user, nil
+	}
+
+	if filters.Id == repository.SuspendedKeyInt ||
+		filters.Email == repository.SuspendedKey ||
+		filters.Username == repository.SuspendedKey {
+		now := time.Now()
+		user.Id = repository.SuspendedKeyInt
+		user.SuspendedAt = &now
+		return user, nil
+	}
+
 	if filters.Email == "get-invalid-user" || filters.Username == "get-invalid-user" {
 		user.Id = repository.UnexpectedKeyInt
 		return user, nil
@@ -52,19 +76,67 @@ func (r *mockUserRepo) GetUser(filters models.UserFilters) (models.User, error)
 	return user, nil
 }
 
-func (r *mockUserRepo) UpdateUser(u models.User) error {
+func (r *mockUserRepo) UpdateUser(ctx context.Context, u models.User) (int, error) {
 
 	if u.Name == repository.DuplicateKey {
-		return errors.New("duplicate key value")
+		return 0, errors.New("duplicate key value")
 	}
 
 	if u.Name == repository.UnexpectedKey {
-		return errors.New("some error")
+		return 0, errors.New("some error")
 	}
 
 	if u.Name == repository.NotFoundKey {
-		return sql.ErrNoRows
+		return 0, sql.ErrNoRows
+	}
+
+	if u.Name == repository.StaleKey {
+		return u.Version + 2, repository.ErrStale
+	}
+
+	return u.Version + 1, nil
+}
+
+func (r *mockUserRepo) SuspendUser(ctx context.Context, id int, until *time.Time, reason string) error {
+	if id == repository.UnexpectedKeyInt {
+		return errors.New("some error")
+	}
+
+	return nil
+}
+
+func (r *mockUserRepo) UnsuspendUser(ctx context.Context, id int) error {
+	if id == repository.UnexpectedKeyInt {
+		return errors.New("some error")
 	}
 
 	return nil
 }
+
+func (r *mockUserRepo) SetRole(ctx context.Context, id int, role string) error {
+	if id == repository.UnexpectedKeyInt {
+		return errors.New("some error")
+	}
+
+	return nil
+}
+
+func (r *mockUserRepo) SetPassword(ctx context.Context, id int, password string) error {
+	if id == repository.UnexpectedKeyInt {
+		return errors.New("some error")
+	}
+
+	return nil
+}
+
+func (r *mockUserRepo) GetAvatars(ctx context.Context) (map[string]string, error) {
+	return map[string]string{"example.png": ""}, nil
+}
+
+func (r *mockUserRepo) SetAvatarVariants(ctx context.Context, avatar, variants string) (bool, error) {
+	if avatar == repository.UnexpectedKey {
+		return false, errors.New("some error")
+	}
+
+	return true, nil
+}
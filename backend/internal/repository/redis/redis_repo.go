@@ -2,11 +2,18 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
+	"github.com/redis/go-redis/v9"
 )
 
 type RedisRepo struct {
@@ -19,9 +26,9 @@ func NewRepo(db *database.DB) repository.CacheRepo {
 	}
 }
 
-func (r *RedisRepo) SetRefreshToken(td token.Details) error {
+func (r *RedisRepo) SetRefreshToken(ctx context.Context, td token.Details) error {
 	_, err := r.db.Redis.Set(
-		context.Background(),
+		ctx,
 		fmt.Sprint("refresh_token-", td.UserId),
 		td.UniqueId,
 		td.Duration,
@@ -34,9 +41,9 @@ func (r *RedisRepo) SetRefreshToken(td token.Details) error {
 	return nil
 }
 
-func (r *RedisRepo) GetRefreshToken(td token.Details) (string, error) {
+func (r *RedisRepo) GetRefreshToken(ctx context.Context, td token.Details) (string, error) {
 	uuid, err := r.db.Redis.Get(
-		context.Background(),
+		ctx,
 		fmt.Sprint("refresh_token-", td.UserId),
 	).Result()
 
@@ -47,9 +54,9 @@ func (r *RedisRepo) GetRefreshToken(td token.Details) (string, error) {
 	return uuid, nil
 }
 
-func (r *RedisRepo) DelRefreshToken(td token.Details) error {
+func (r *RedisRepo) DelRefreshToken(ctx context.Context, td token.Details) error {
 	_, err := r.db.Redis.Del(
-		context.Background(),
+		ctx,
 		fmt.Sprint("refresh_token-", td.UserId),
 	).Result()
 
@@ -59,3 +66,248 @@ func (r *RedisRepo) DelRefreshToken(td token.Details) error {
 
 	return nil
 }
+
+// SetSuspension marks the user as suspended for d, or indefinitely if d is 0
+func (r *RedisRepo) SetSuspension(ctx context.Context, userId int, d time.Duration) error {
+	_, err := r.db.Redis.Set(
+		ctx,
+		fmt.Sprint("suspension-", userId),
+		1,
+		d,
+	).Result()
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetNotSuspended remembers for d that the user isn't suspended, so their
+// requests don't all read the database
+func (r *RedisRepo) SetNotSuspended(ctx context.Context, userId int, d time.Duration) error {
+	_, err := r.db.Redis.Set(
+		ctx,
+		fmt.Sprint("suspension-", userId),
+		0,
+		d,
+	).Result()
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// HasSuspension returns repository.ErrCacheMiss when neither a suspension nor
+// its absence is cached
+func (r *RedisRepo) HasSuspension(ctx context.Context, userId int) (bool, error) {
+	v, err := r.db.Redis.Get(
+		ctx,
+		fmt.Sprint("suspension-", userId),
+	).Result()
+
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, repository.ErrCacheMiss
+		}
+
+		return false, err
+	}
+
+	return v != "0", nil
+}
+
+func (r *RedisRepo) DelSuspension(ctx context.Context, userId int) error {
+	_, err := r.db.Redis.Del(
+		ctx,
+		fmt.Sprint("suspension-", userId),
+	).Result()
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RedisRepo) GetPost(ctx context.Context, slug string) (models.Post, error) {
+	var post models.Post
+	err := r.get(ctx, fmt.Sprint("post-", slug), &post)
+	return post, err
+}
+
+// SetPost caches the post and files its slug under the author, so that
+// DelPostsByUser can find it when the author's name or avatar change
+func (r *RedisRepo) SetPost(ctx context.Context, p models.Post, d time.Duration) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprint("user_posts-", p.UserId)
+
+	_, err = r.db.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, fmt.Sprint("post-", p.Slug), b, d)
+		pipe.SAdd(ctx, key, p.Slug)
+		pipe.Expire(ctx, key, d)
+		return nil
+	})
+
+	return err
+}
+
+func (r *RedisRepo) DelPost(ctx context.Context, slug string) error {
+	_, err := r.db.Redis.Del(
+		ctx,
+		fmt.Sprint("post-", slug),
+	).Result()
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RedisRepo) DelPostsByUser(ctx context.Context, userId int) error {
+	key := fmt.Sprint("user_posts-", userId)
+
+	slugs, err := r.db.Redis.SMembers(ctx, key).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key}
+	for _, slug := range slugs {
+		keys = append(keys, fmt.Sprint("post-", slug))
+	}
+
+	_, err = r.db.Redis.Del(ctx, keys...).Result()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RedisRepo) GetProfile(ctx context.Context, username string) (models.User, error) {
+	var user models.User
+	err := r.get(ctx, fmt.Sprint("profile-", username), &user)
+	return user, err
+}
+
+func (r *RedisRepo) SetProfile(ctx context.Context, u models.User, d time.Duration) error {
+	return r.set(ctx, fmt.Sprint("profile-", u.Username), u, d)
+}
+
+func (r *RedisRepo) DelProfile(ctx context.Context, username string) error {
+	_, err := r.db.Redis.Del(
+		ctx,
+		fmt.Sprint("profile-", username),
+	).Result()
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RedisRepo) GetCategories(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.get(ctx, "categories", &categories)
+	return categories, err
+}
+
+func (r *RedisRepo) SetCategories(ctx context.Context, categories []models.Category, d time.Duration) error {
+	return r.set(ctx, "categories", categories, d)
+}
+
+// get decodes the JSON cached under the key into v, returning
+// repository.ErrCacheMiss if there is none
+func (r *RedisRepo) get(ctx context.Context, key string, v interface{}) error {
+	b, err := r.db.Redis.Get(ctx, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return repository.ErrCacheMiss
+		}
+
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func (r *RedisRepo) set(ctx context.Context, key string, v interface{}, d time.Duration) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Redis.Set(ctx, key, b, d).Result()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FlushCache removes the cached posts, profiles and categories, returning
+// how many keys were deleted. Tokens and suspensions are left alone.
+func (r *RedisRepo) FlushCache(ctx context.Context) (int, error) {
+	keys := []string{"categories"}
+
+	for _, pattern := range []string{"post-*", "profile-*", "user_posts-*"} {
+		iter := r.db.Redis.Scan(ctx, 0, pattern, 100).Iterator()
+		for iter.Next(ctx) {
+			keys = append(keys, iter.Val())
+		}
+
+		if err := iter.Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.db.Redis.Del(ctx, keys...).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n), nil
+}
+
+// GetSessions lists the refresh tokens that are still stored
+func (r *RedisRepo) GetSessions(ctx context.Context) ([]models.Session, error) {
+	var sessions []models.Session
+
+	iter := r.db.Redis.Scan(ctx, 0, "refresh_token-*", 100).Iterator()
+	for iter.Next(ctx) {
+		userId, err := strconv.Atoi(strings.TrimPrefix(iter.Val(), "refresh_token-"))
+		if err != nil {
+			continue
+		}
+
+		ttl, err := r.db.Redis.TTL(ctx, iter.Val()).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		// Expired in between the scan and now
+		if ttl < 0 {
+			continue
+		}
+
+		sessions = append(sessions, models.Session{
+			UserId:    userId,
+			ExpiresAt: time.Now().Add(ttl),
+		})
+	}
+
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
@@ -1,19 +1,34 @@
 package redis
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
 )
 
-type mockRedisRepo struct{}
+// mockRedisRepo keeps cached posts, profiles and categories in memory,
+// failing on slugs and usernames that hold repository.UnexpectedKey
+type mockRedisRepo struct {
+	mu         sync.Mutex
+	posts      map[string]models.Post
+	profiles   map[string]models.User
+	categories []models.Category
+}
 
 func NewMockRepo() repository.CacheRepo {
-	return &mockRedisRepo{}
+	return &mockRedisRepo{
+		posts:    make(map[string]models.Post),
+		profiles: make(map[string]models.User),
+	}
 }
 
-func (r *mockRedisRepo) SetRefreshToken(td token.Details) error {
+func (r *mockRedisRepo) SetRefreshToken(ctx context.Context, td token.Details) error {
 	if td.UserId == repository.UnexpectedKeyInt {
 		return errors.New("Some error")
 	}
@@ -21,7 +36,7 @@ func (r *mockRedisRepo) SetRefreshToken(td token.Details) error {
 	return nil
 }
 
-func (r *mockRedisRepo) GetRefreshToken(td token.Details) (string, error) {
+func (r *mockRedisRepo) GetRefreshToken(ctx context.Context, td token.Details) (string, error) {
 	if td.UniqueId == repository.IncorrectKey {
 		return "", errors.New("Some error")
 	}
@@ -29,10 +44,178 @@ func (r *mockRedisRepo) GetRefreshToken(td token.Details) (string, error) {
 	return "uuid", nil
 }
 
-func (r *mockRedisRepo) DelRefreshToken(td token.Details) error {
+func (r *mockRedisRepo) DelRefreshToken(ctx context.Context, td token.Details) error {
 	if td.UserId == repository.UnexpectedKeyInt {
 		return errors.New("Some error")
 	}
 
 	return nil
 }
+
+func (r *mockRedisRepo) SetSuspension(ctx context.Context, userId int, d time.Duration) error {
+	if userId == repository.UnexpectedKeyInt {
+		return errors.New("Some error")
+	}
+
+	return nil
+}
+
+func (r *mockRedisRepo) SetNotSuspended(ctx context.Context, userId int, d time.Duration) error {
+	if userId == repository.UnexpectedKeyInt {
+		return errors.New("Some error")
+	}
+
+	return nil
+}
+
+func (r *mockRedisRepo) HasSuspension(ctx context.Context, userId int) (bool, error) {
+	if userId == repository.UnexpectedKeyInt {
+		return false, errors.New("Some error")
+	}
+
+	return userId == repository.SuspendedKeyInt, nil
+}
+
+func (r *mockRedisRepo) DelSuspension(ctx context.Context, userId int) error {
+	if userId == repository.UnexpectedKeyInt {
+		return errors.New("Some error")
+	}
+
+	return nil
+}
+
+func (r *mockRedisRepo) GetPost(ctx context.Context, slug string) (models.Post, error) {
+	if strings.Contains(slug, repository.UnexpectedKey) {
+		return models.Post{}, errors.New("Some error")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	post, ok := r.posts[slug]
+	if !ok {
+		return post, repository.ErrCacheMiss
+	}
+
+	return post, nil
+}
+
+func (r *mockRedisRepo) SetPost(ctx context.Context, p models.Post, d time.Duration) error {
+	if strings.Contains(p.Slug, repository.UnexpectedKey) {
+		return errors.New("Some error")
+	}
+
+	r.mu.Lock()
+	r.posts[p.Slug] = p
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *mockRedisRepo) DelPost(ctx context.Context, slug string) error {
+	if strings.Contains(slug, repository.UnexpectedKey) {
+		return errors.New("Some error")
+	}
+
+	r.mu.Lock()
+	delete(r.posts, slug)
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *mockRedisRepo) DelPostsByUser(ctx context.Context, userId int) error {
+	if userId == repository.UnexpectedKeyInt {
+		return errors.New("Some error")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for slug, post := range r.posts {
+		if post.UserId == userId {
+			delete(r.posts, slug)
+		}
+	}
+
+	return nil
+}
+
+func (r *mockRedisRepo) GetProfile(ctx context.Context, username string) (models.User, error) {
+	if strings.Contains(username, repository.UnexpectedKey) {
+		return models.User{}, errors.New("Some error")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.profiles[username]
+	if !ok {
+		return user, repository.ErrCacheMiss
+	}
+
+	return user, nil
+}
+
+func (r *mockRedisRepo) SetProfile(ctx context.Context, u models.User, d time.Duration) error {
+	if strings.Contains(u.Username, repository.UnexpectedKey) {
+		return errors.New("Some error")
+	}
+
+	r.mu.Lock()
+	r.profiles[u.Username] = u
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *mockRedisRepo) DelProfile(ctx context.Context, username string) error {
+	if strings.Contains(username, repository.UnexpectedKey) {
+		return errors.New("Some error")
+	}
+
+	r.mu.Lock()
+	delete(r.profiles, username)
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *mockRedisRepo) GetCategories(ctx context.Context) ([]models.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.categories == nil {
+		return nil, repository.ErrCacheMiss
+	}
+
+	return r.categories, nil
+}
+
+func (r *mockRedisRepo) SetCategories(ctx context.Context, categories []models.Category, d time.Duration) error {
+	r.mu.Lock()
+	r.categories = categories
+	r.mu.Unlock()
+
+	return nil
+}
+
+func (r *mockRedisRepo) FlushCache(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := len(r.posts) + len(r.profiles)
+	if r.categories != nil {
+		n++
+	}
+
+	r.posts = make(map[string]models.Post)
+	r.profiles = make(map[string]models.User)
+	r.categories = nil
+
+	return n, nil
+}
+
+func (r *mockRedisRepo) GetSessions(ctx context.Context) ([]models.Session, error) {
+	return []models.Session{{UserId: 1, ExpiresAt: time.Now().Add(time.Hour)}}, nil
+}
@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
@@ -14,29 +18,116 @@ const (
 	UnexpectedKey    = "unexpected-error"
 	IncorrectKey     = "something-incorrect"
 	DuplicateKey     = "already-exists"
+	AdminKeyInt      = -3
+	IncorrectKeyInt  = -4
+	SuspendedKeyInt  = -5
+	SuspendedKey     = "suspended"
+	ModeratorKeyInt  = -6
+	MovedKey         = "moved"
+	StaleKey         = "stale"
+	HiddenKey        = "hidden"
+)
+
+var (
+	// ErrCacheMiss is returned by the CacheRepo getters when nothing is cached
+	ErrCacheMiss = errors.New("cache miss")
+	// ErrStale is returned by updates of a row whose version has changed
+	// since it was read
+	ErrStale = errors.New("stale version")
 )
 
+// Repos are the repositories bound to a single transaction
+type Repos struct {
+	Post   PostRepo
+	User   UserRepo
+	Media  MediaRepo
+	Report ReportRepo
+}
+
+type TxManager interface {
+	// WithinTx runs fn in a transaction, which is committed if fn returns
+	// nil and rolled back otherwise. The error of fn is returned as is.
+	WithinTx(ctx context.Context, fn func(r Repos) error) error
+}
+
 type CacheRepo interface {
-	SetRefreshToken(td token.Details) error
-	GetRefreshToken(td token.Details) (string, error)
-	DelRefreshToken(td token.Details) error
+	SetRefreshToken(ctx context.Context, td token.Details) error
+	GetRefreshToken(ctx context.Context, td token.Details) (string, error)
+	DelRefreshToken(ctx context.Context, td token.Details) error
+
+	SetSuspension(ctx context.Context, userId int, d time.Duration) error
+	SetNotSuspended(ctx context.Context, userId int, d time.Duration) error
+	HasSuspension(ctx context.Context, userId int) (bool, error)
+	DelSuspension(ctx context.Context, userId int) error
+
+	GetPost(ctx context.Context, slug string) (models.Post, error)
+	SetPost(ctx context.Context, p models.Post, d time.Duration) error
+	DelPost(ctx context.Context, slug string) error
+	DelPostsByUser(ctx context.Context, userId int) error
+	GetProfile(ctx context.Context, username string) (models.User, error)
+	SetProfile(ctx context.Context, u models.User, d time.Duration) error
+	DelProfile(ctx context.Context, username string) error
+	GetCategories(ctx context.Context) ([]models.Category, error)
+	SetCategories(ctx context.Context, categories []models.Category, d time.Duration) error
+	FlushCache(ctx context.Context) (int, error)
+
+	GetSessions(ctx context.Context) ([]models.Session, error)
 }
 
 type UserRepo interface {
-	CreateUser(username, email, password string) (int, error)
-	GetUser(filters models.UserFilters) (models.User, error)
-	UpdateUser(u models.User) error
+	CreateUser(ctx context.Context, username, email, password string) (int, error)
+	GetUser(ctx context.Context, filters models.UserFilters) (models.User, error)
+	UpdateUser(ctx context.Context, u models.User) (int, error)
+	SuspendUser(ctx context.Context, id int, until *time.Time, reason string) error
+	UnsuspendUser(ctx context.Context, id int) error
+	SetRole(ctx context.Context, id int, role string) error
+	SetPassword(ctx context.Context, id int, password string) error
+	GetAvatars(ctx context.Context) (map[string]string, error)
+	SetAvatarVariants(ctx context.Context, avatar, variants string) (bool, error)
 }
 
 type PostRepo interface {
-	CreatePost(p models.Post) (models.Post, error)
-	GetPosts(pgMeta *pagination.Meta, filters models.PostsFilters) ([]models.Post, error)
-	GetPostBySlug(slug string) (models.Post, error)
-	UpdatePost(p models.Post) error
-	DeletePost(id int) error
-	CountPosts(filters models.PostsFilters) (int, error)
-
-	GetCategories() ([]models.Category, error)
-	GetCategoryById(id int) (models.Category, error)
-	GetCategoryBySlug(slug string) (models.Category, error)
+	CreatePost(ctx context.Context, p models.Post) (models.Post, error)
+	GetPosts(ctx context.Context, pgMeta *pagination.Meta, filters models.PostsFilters) ([]models.Post, error)
+	GetPostBySlug(ctx context.Context, slug string) (models.Post, error)
+	UpdatePost(ctx context.Context, p models.Post) (int, error)
+	HidePost(ctx context.Context, id int) error
+	DeletePost(ctx context.Context, id int) error
+	RestorePost(ctx context.Context, id int) error
+	GetTrashedPosts(ctx context.Context, userId int) ([]models.Post, error)
+	GetTrashedPostBySlug(ctx context.Context, slug string) (models.Post, error)
+	PurgePosts(ctx context.Context, before time.Time) ([]models.Post, error)
+	CountPosts(ctx context.Context, filters models.PostsFilters) (int, error)
+	GetSlugRedirect(ctx context.Context, slug string) (models.SlugRedirect, error)
+	SaveSlugHistory(ctx context.Context, postId int, oldSlug, newSlug string) error
+	SetPostMedia(ctx context.Context, postId int, names []string) error
+	GetPostImages(ctx context.Context) (map[string]string, error)
+	SetImageVariants(ctx context.Context, image, variants string) (bool, error)
+	GetUnrenderedPosts(ctx context.Context, afterId, limit int) ([]models.Post, error)
+	SetRendered(ctx context.Context, p models.Post) (bool, error)
+
+	GetCategories(ctx context.Context) ([]models.Category, error)
+	GetCategoryById(ctx context.Context, id int) (models.Category, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (models.Category, error)
+}
+
+type MediaRepo interface {
+	CreateMedia(ctx context.Context, m models.Media) (models.Media, error)
+	GetMediaByUser(ctx context.Context, userId int) ([]models.Media, error)
+	DeleteUnusedMedia(ctx context.Context, before time.Time) ([]models.Media, error)
+	GetMediaNames(ctx context.Context) ([]string, error)
+}
+
+type ReportRepo interface {
+	CreateReport(ctx context.Context, r models.Report) error
+	GetReports(ctx context.Context, pgMeta *pagination.Meta, filters models.ReportsFilters) ([]models.Report, error)
+	GetReportById(ctx context.Context, id int) (models.Report, error)
+	CountReports(ctx context.Context, filters models.ReportsFilters) (int, error)
+	ResolveReports(ctx context.Context, r models.Report) error
+}
+
+type AuditRepo interface {
+	CreateEvent(ctx context.Context, e models.AuditEvent) error
+	GetEvents(ctx context.Context, pgMeta *pagination.Meta, filters models.AuditEventsFilters) ([]models.AuditEvent, error)
+	CountEvents(ctx context.Context, filters models.AuditEventsFilters) (int, error)
 }
@@ -1,12 +1,17 @@
 package router
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/handlers"
 	"github.com/Noblefel/ManorTalk/backend/internal/middleware"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/admin"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/auth"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/media"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/moderation"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/post"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/user"
 	"github.com/go-chi/chi/v5"
@@ -14,24 +19,51 @@ import (
 	"github.com/go-chi/cors"
 )
 
+// Cache-Control policies, the public ones being revalidated with the ETags of
+// res.ConditionalJSON once they expire
+const (
+	cacheShort   = "public, max-age=30"
+	cacheLong    = "public, max-age=3600"
+	cachePrivate = "private, no-store"
+	// cacheRevalidate is for what an author edits, so the editor never reads
+	// an outdated copy and fails the If-Match of the save
+	cacheRevalidate = "no-cache"
+)
+
 type router struct {
-	m    *middleware.Middleware
-	auth *handlers.AuthHandlers
-	user *handlers.UserHandlers
-	post *handlers.PostHandlers
+	c      *config.AppConfig
+	m      *middleware.Middleware
+	auth   *handlers.AuthHandlers
+	user   *handlers.UserHandlers
+	post   *handlers.PostHandlers
+	media  *handlers.MediaHandlers
+	mod    *handlers.ModerationHandlers
+	admin  *handlers.AdminHandlers
+	health *handlers.HealthHandlers
 }
 
 func NewRouter(
 	c *config.AppConfig,
+	cr repository.CacheRepo,
+	ur repository.UserRepo,
+	checks map[string]func(context.Context) error,
 	as auth.AuthService,
 	us user.UserService,
 	ps post.PostService,
+	mds media.MediaService,
+	ms moderation.ModerationService,
+	ads admin.AdminService,
 ) *router {
 	return &router{
-		m:    middleware.New(c),
-		auth: handlers.NewAuthHandlers(as),
-		user: handlers.NewUserHandlers(us),
-		post: handlers.NewPostHandlers(ps),
+		c:      c,
+		m:      middleware.New(c, cr, ur),
+		auth:   handlers.NewAuthHandlers(as),
+		user:   handlers.NewUserHandlers(us),
+		post:   handlers.NewPostHandlers(ps),
+		media:  handlers.NewMediaHandlers(mds),
+		mod:    handlers.NewModerationHandlers(ms),
+		admin:  handlers.NewAdminHandlers(ads),
+		health: handlers.NewHealthHandlers(checks),
 	}
 }
 
@@ -46,10 +78,13 @@ func (r *router) Routes() http.Handler {
 	))
 
 	mux.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedOrigins: []string{"https://*", "http://*"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
+		AllowedHeaders: []string{
+			"Accept", "Authorization", "Content-Type", "X-CSRF-Token",
+			"If-Match", "If-None-Match", "If-Modified-Since",
+		},
+		ExposedHeaders:   []string{"Link", "ETag", "Last-Modified"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -57,15 +92,33 @@ func (r *router) Routes() http.Handler {
 	mux.NotFound(handlers.NotFound)
 	mux.MethodNotAllowed(handlers.MethodNotAllowed)
 
+	// Outside of /api for the orchestrators and load balancers
+	mux.Group(func(mux chi.Router) {
+		mux.Use(r.m.CacheControl(cachePrivate))
+		mux.Get("/healthz", r.health.Health)
+		mux.Get("/readyz", r.health.Ready)
+		mux.Get("/version", r.health.Version)
+	})
+
 	api := chi.NewRouter()
 	mux.Mount("/api/", api)
 
 	r.authRouter(api)
 	r.postRouter(api)
 	r.userRouter(api)
-
-	fileServer := http.FileServer(http.Dir("./images/"))
-	mux.Handle("/images/*", http.StripPrefix("/images", fileServer))
+	r.mediaRouter(api)
+	r.moderationRouter(api)
+	r.adminRouter(api)
+
+	// Other storage drivers serve the uploads themselves
+	if st := r.c.Storage; st.Driver == "" || st.Driver == "local" {
+		// Validate rejects a base url without a path, it's only skipped here
+		// for the configs that weren't validated
+		if path, err := st.ServePath(); err == nil {
+			fileServer := http.FileServer(http.Dir(st.Dir))
+			mux.Handle(path+"/*", http.StripPrefix(path, fileServer))
+		}
+	}
 
 	return mux
 }
@@ -81,15 +134,17 @@ func (r *router) authRouter(api *chi.Mux) {
 
 func (r *router) postRouter(api *chi.Mux) {
 	api.Route("/posts", func(api chi.Router) {
-		api.Get("/", r.post.GetMany)
-		api.Get("/{slug}", r.post.Get)
-		api.Get("/categories", r.post.GetCategories)
+		api.With(r.m.CacheControl(cacheShort)).Get("/", r.post.GetMany)
+		api.With(r.m.CacheControl(cacheRevalidate)).Get("/{slug}", r.post.Get)
+		api.With(r.m.CacheControl(cacheLong)).Get("/categories", r.post.GetCategories)
 
 		api.Group(func(api chi.Router) {
 			api.Use(r.m.Auth)
-			api.Post("/", r.post.Create)
-			api.Patch("/{slug}", r.post.Update)
+			api.With(r.limitUpload(r.c.Uploads.Post)).Post("/", r.post.Create)
+			api.With(r.limitUpload(r.c.Uploads.Post)).Patch("/{slug}", r.post.Update)
 			api.Delete("/{slug}", r.post.Delete)
+			api.Post("/{slug}/restore", r.post.Restore)
+			api.Post("/{slug}/report", r.mod.Report)
 		})
 	})
 }
@@ -97,11 +152,42 @@ func (r *router) postRouter(api *chi.Mux) {
 func (r *router) userRouter(api *chi.Mux) {
 	api.Route("/users", func(api chi.Router) {
 		api.Post("/check-username", r.user.CheckUsername)
-		api.Get("/{username}", r.user.Get)
+		api.With(r.m.CacheControl(cacheRevalidate)).Get("/{username}", r.user.Get)
 
 		api.Group(func(api chi.Router) {
 			api.Use(r.m.Auth)
-			api.Patch("/{username}", r.user.UpdateProfile)
+			api.With(r.m.CacheControl(cachePrivate)).Get("/me/trash", r.post.GetTrash)
+			api.With(r.m.CacheControl(cachePrivate)).Get("/me/media", r.media.GetLibrary)
+			api.With(r.limitUpload(r.c.Uploads.Avatar)).Patch("/{username}", r.user.UpdateProfile)
+			api.Post("/{username}/suspension", r.user.Suspend)
+			api.Delete("/{username}/suspension", r.user.Unsuspend)
 		})
 	})
 }
+
+func (r *router) mediaRouter(api *chi.Mux) {
+	api.Route("/media", func(api chi.Router) {
+		api.Use(r.m.Auth)
+		api.With(r.limitUpload(r.c.Uploads.Media)).Post("/", r.media.Upload)
+	})
+}
+
+func (r *router) moderationRouter(api *chi.Mux) {
+	api.Route("/moderation", func(api chi.Router) {
+		api.Use(r.m.Auth)
+		api.With(r.m.CacheControl(cachePrivate)).Get("/reports", r.mod.GetReports)
+		api.Post("/reports/{id}/resolve", r.mod.Resolve)
+	})
+}
+
+func (r *router) adminRouter(api *chi.Mux) {
+	api.Route("/admin", func(api chi.Router) {
+		api.Use(r.m.Auth)
+		api.With(r.m.CacheControl(cachePrivate)).Get("/audit-events", r.admin.GetAuditEvents)
+	})
+}
+
+// limitUpload caps the body of a form that carries a file of up to n bytes
+func (r *router) limitUpload(n int64) func(http.Handler) http.Handler {
+	return r.m.LimitBody(n + r.c.Uploads.Fields)
+}
@@ -1,21 +1,35 @@
 package router
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/admin"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/auth"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/media"
+	"github.com/Noblefel/ManorTalk/backend/internal/service/moderation"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/post"
 	"github.com/Noblefel/ManorTalk/backend/internal/service/user"
 )
 
 func TestNewRouter(t *testing.T) {
 	var c *config.AppConfig
+	var cr repository.CacheRepo
+	var ur repository.UserRepo
 	var as auth.AuthService
 	var us user.UserService
 	var ps post.PostService
-	router := NewRouter(c, as, us, ps)
+	var mds media.MediaService
+	var ms moderation.ModerationService
+	var ads admin.AdminService
+	router := NewRouter(c, cr, ur, nil, as, us, ps, mds, ms, ads)
 
 	typeString := reflect.TypeOf(router).String()
 	if typeString != "*router.router" {
@@ -24,11 +38,16 @@ func TestNewRouter(t *testing.T) {
 }
 
 func TestRouter_Routes(t *testing.T) {
-	var c *config.AppConfig
+	c := config.Default()
+	var cr repository.CacheRepo
+	var ur repository.UserRepo
 	var as auth.AuthService
 	var us user.UserService
 	var ps post.PostService
-	router := NewRouter(c, as, us, ps)
+	var mds media.MediaService
+	var ms moderation.ModerationService
+	var ads admin.AdminService
+	router := NewRouter(c, cr, ur, nil, as, us, ps, mds, ms, ads)
 
 	mux := router.Routes()
 
@@ -37,3 +56,99 @@ func TestRouter_Routes(t *testing.T) {
 		t.Error("router Routes() did not get the correct type, wanted *chi.Mux")
 	}
 }
+
+func TestRouter_CORS(t *testing.T) {
+	c := config.Default()
+	var cr repository.CacheRepo
+	var ur repository.UserRepo
+	var as auth.AuthService
+	var us user.UserService
+	var ps post.PostService
+	var mds media.MediaService
+	var ms moderation.ModerationService
+	var ads admin.AdminService
+	mux := NewRouter(c, cr, ur, nil, as, us, ps, mds, ms, ads).Routes()
+
+	r := httptest.NewRequest("OPTIONS", "/api/posts/example", nil)
+	r.Header.Set("Origin", "http://localhost:5173")
+	r.Header.Set("Access-Control-Request-Method", "PATCH")
+	r.Header.Set("Access-Control-Request-Headers", "if-match")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	if got := w.Header().Get("Access-Control-Allow-Headers"); !strings.EqualFold(got, "if-match") {
+		t.Errorf("preflight should allow If-Match, got %q", got)
+	}
+
+	r = httptest.NewRequest("GET", "/healthz", nil)
+	r.Header.Set("Origin", "http://localhost:5173")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+
+	exposed := w.Header().Get("Access-Control-Expose-Headers")
+	for _, h := range []string{"Etag", "Last-Modified"} {
+		if !strings.Contains(exposed, h) {
+			t.Errorf("%s should be exposed, got %q", h, exposed)
+		}
+	}
+}
+
+func TestRouter_Uploads(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name       string
+		baseURL    string
+		path       string
+		statusCode int
+	}{
+		{"relative", "/images", "/images/a.png", http.StatusOK},
+		{"absolute mounts on its path", "https://cdn.example.com/uploads", "/uploads/a.png", http.StatusOK},
+		{"empty doesn't catch every route", "", "/a.png", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := config.Default()
+			c.Storage.Dir = dir
+			c.Storage.BaseURL = tt.baseURL
+			var cr repository.CacheRepo
+			var ur repository.UserRepo
+			var as auth.AuthService
+			var us user.UserService
+			var ps post.PostService
+			var mds media.MediaService
+			var ms moderation.ModerationService
+			var ads admin.AdminService
+
+			var mux http.Handler
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("Routes() panicked: %v", r)
+					}
+				}()
+				mux = NewRouter(c, cr, ur, nil, as, us, ps, mds, ms, ads).Routes()
+			}()
+
+			r := httptest.NewRequest("GET", tt.path, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("GET %s want %d, got %d", tt.path, tt.statusCode, w.Code)
+			}
+
+			r = httptest.NewRequest("GET", "/healthz", nil)
+			w = httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("GET /healthz want 200, got %d", w.Code)
+			}
+		})
+	}
+}
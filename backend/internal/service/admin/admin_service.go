@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+)
+
+var (
+	ErrInvalidFilter = errors.New("Invalid filter, actor should be an id and dates should be YYYY-MM-DD or RFC3339")
+	ErrUnauthorized  = errors.New("You have no permission to do that")
+)
+
+type AdminService interface {
+	GetAuditEvents(ctx context.Context, q url.Values, authId int) ([]models.AuditEvent, *pagination.Meta, error)
+}
+
+type adminService struct {
+	c         *config.AppConfig
+	auditRepo repository.AuditRepo
+	userRepo  repository.UserRepo
+}
+
+func NewAdminService(c *config.AppConfig, ar repository.AuditRepo, ur repository.UserRepo) AdminService {
+	return &adminService{
+		c:         c,
+		auditRepo: ar,
+		userRepo:  ur,
+	}
+}
+
+// checkAdmin returns ErrUnauthorized unless the user is an admin
+func (s *adminService) checkAdmin(ctx context.Context, authId int) error {
+	user, err := s.userRepo.GetUser(ctx, models.UserFilters{Id: authId})
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return ErrUnauthorized
+		}
+
+		return fmt.Errorf("getting user by id: %w", err)
+	}
+
+	if user.Role != models.RoleAdmin {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
+// mockAdminService is a replica of the admin service to be used inside handler tests
+type mockAdminService struct {
+	auditRepo repository.AuditRepo
+	userRepo  repository.UserRepo
+}
+
+func NewMockAdminService() AdminService {
+	return &mockAdminService{
+		auditRepo: postgres.NewMockAuditRepo(),
+		userRepo:  postgres.NewMockUserRepo(),
+	}
+}
@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"context"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+)
+
+func TestNewAdminService(t *testing.T) {
+	var db *database.DB
+	var c *config.AppConfig
+	ar := postgres.NewAuditRepo(db)
+	ur := postgres.NewUserRepo(db)
+	service := NewAdminService(c, ar, ur)
+
+	typeString := reflect.TypeOf(service).String()
+
+	if typeString != "*admin.adminService" {
+		t.Error("NewAdminService() get incorrect type, wanted *admin.adminService")
+	}
+}
+
+func TestNewMockAdminService(t *testing.T) {
+	service := NewMockAdminService()
+
+	typeString := reflect.TypeOf(service).String()
+
+	if typeString != "*admin.mockAdminService" {
+		t.Error("NewMockAdminService() get incorrect type, wanted *admin.mockAdminService")
+	}
+}
+
+func newTestService() AdminService {
+	var tc config.AppConfig
+	ar := postgres.NewMockAuditRepo()
+	ur := postgres.NewMockUserRepo()
+
+	service := NewAdminService(&tc, ar, ur)
+
+	return service
+}
+
+var s = newTestService()
+
+func TestAdminService_GetAuditEvents(t *testing.T) {
+	var tests = []struct {
+		name    string
+		q       url.Values
+		authId  int
+		isError bool
+	}{
+		{"success", url.Values{"page": {"1"}}, repository.AdminKeyInt, false},
+		{"success with filters", url.Values{
+			"actor":  {"1"},
+			"action": {"auth.login"},
+			"from":   {"2024-01-01"},
+			"to":     {"2024-02-01T00:00:00Z"},
+		}, repository.AdminKeyInt, false},
+		{"zero limit", url.Values{"limit": {"0"}}, repository.AdminKeyInt, false},
+		{"not an admin", url.Values{}, 1, true},
+		{"moderator", url.Values{}, repository.ModeratorKeyInt, true},
+		{"no user", url.Values{}, repository.NotFoundKeyInt, true},
+		{"error getting user", url.Values{}, repository.UnexpectedKeyInt, true},
+		{"invalid actor", url.Values{"actor": {"x"}}, repository.AdminKeyInt, true},
+		{"invalid from", url.Values{"from": {"yesterday"}}, repository.AdminKeyInt, true},
+		{"invalid to", url.Values{"to": {"01-02-2024"}}, repository.AdminKeyInt, true},
+		{"error creating pagination meta", url.Values{"page": {"-1"}}, repository.AdminKeyInt, true},
+		{"error counting events", url.Values{"action": {repository.UnexpectedKey}}, repository.AdminKeyInt, true},
+		{"error getting events", url.Values{"total": {"1"}, "action": {repository.UnexpectedKey}}, repository.AdminKeyInt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.GetAuditEvents(context.Background(), tt.q, tt.authId)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+		})
+	}
+}
+
+// recordingAuditRepo keeps the filters the events were last asked with
+type recordingAuditRepo struct {
+	repository.AuditRepo
+	filters models.AuditEventsFilters
+}
+
+func (r *recordingAuditRepo) GetEvents(ctx context.Context, pgMeta *pagination.Meta, filters models.AuditEventsFilters) ([]models.AuditEvent, error) {
+	r.filters = filters
+	return r.AuditRepo.GetEvents(ctx, pgMeta, filters)
+}
+
+func TestAdminService_GetAuditEventsLimit(t *testing.T) {
+	var tests = []struct {
+		limit string
+		want  int
+	}{
+		{"", 50},
+		{"0", 50},
+		{"30", 30},
+		{"500", pagination.MaxLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.limit, func(t *testing.T) {
+			ar := &recordingAuditRepo{AuditRepo: postgres.NewMockAuditRepo()}
+			s := NewAdminService(&config.AppConfig{}, ar, postgres.NewMockUserRepo())
+
+			q := url.Values{"limit": {tt.limit}}
+			if _, _, err := s.GetAuditEvents(context.Background(), q, repository.AdminKeyInt); err != nil {
+				t.Fatalf("expecting no error, got %v", err)
+			}
+
+			if ar.filters.Limit != tt.want {
+				t.Errorf("want limit %d, got %d", tt.want, ar.filters.Limit)
+			}
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	var tests = []struct {
+		name string
+		s    string
+		end  bool
+		want time.Time
+	}{
+		{"empty", "", true, time.Time{}},
+		{"date", "2024-01-31", false, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{"date as the end", "2024-01-31", true, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"timestamp as the end", "2024-01-31T12:00:00Z", true, time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTime(tt.s, tt.end)
+			if err != nil {
+				t.Fatalf("expecting no error, got %v", err)
+			}
+
+			if !got.Equal(tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+	"github.com/gosimple/slug"
+)
+
+func (s *adminService) GetAuditEvents(ctx context.Context, q url.Values, authId int) ([]models.AuditEvent, *pagination.Meta, error) {
+	var events []models.AuditEvent
+	var err error
+
+	if err := s.checkAdmin(ctx, authId); err != nil {
+		return events, nil, err
+	}
+
+	limit := pagination.Limit(q, 50)
+
+	filters := models.AuditEventsFilters{
+		Action: q.Get("action"),
+		Limit:  limit,
+	}
+
+	if q.Get("actor") != "" {
+		filters.ActorId, err = strconv.Atoi(q.Get("actor"))
+		if err != nil {
+			return events, nil, ErrInvalidFilter
+		}
+	}
+
+	if filters.From, err = parseTime(q.Get("from"), false); err != nil {
+		return events, nil, ErrInvalidFilter
+	}
+
+	if filters.To, err = parseTime(q.Get("to"), true); err != nil {
+		return events, nil, ErrInvalidFilter
+	}
+
+	pgMeta, err := pagination.NewMeta(q, limit)
+	if err != nil {
+		return events, pgMeta, fmt.Errorf("creating pagination meta: %w", err)
+	}
+
+	if pgMeta.Total == 0 {
+		total, err := s.auditRepo.CountEvents(ctx, filters)
+		if err != nil && !errors.Is(sql.ErrNoRows, err) {
+			return events, nil, fmt.Errorf("counting audit events: %w", err)
+		}
+
+		pgMeta.SetNewTotal(total, limit)
+	}
+
+	events, err = s.auditRepo.GetEvents(ctx, pgMeta, filters)
+	if err != nil && !errors.Is(sql.ErrNoRows, err) {
+		return events, nil, fmt.Errorf("getting audit events: %w", err)
+	}
+
+	return events, pgMeta, nil
+}
+
+// parseTime accepts either a plain date or a full RFC3339 timestamp,
+// an empty string results in the zero time which leaves the range open.
+// The end of the range is exclusive, so a plain date given as the end
+// moves to the next day to include the whole of it.
+func parseTime(s string, end bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
+
+func (s *mockAdminService) GetAuditEvents(ctx context.Context, q url.Values, authId int) ([]models.AuditEvent, *pagination.Meta, error) {
+	events, pgMeta := []models.AuditEvent{}, &pagination.Meta{}
+
+	if q.Has(slug.Make(ErrUnauthorized.Error())) {
+		return events, nil, ErrUnauthorized
+	}
+
+	if q.Has(slug.Make(ErrInvalidFilter.Error())) {
+		return events, nil, ErrInvalidFilter
+	}
+
+	if q.Has(slug.Make("unexpected error")) {
+		return events, nil, errors.New("unexpected error")
+	}
+
+	return events, pgMeta, nil
+}
@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
@@ -15,29 +18,41 @@ var (
 	ErrInvalidCredentials = errors.New("Invalid credentials")
 	ErrNoUser             = errors.New("User not found")
 	ErrUnauthorized       = errors.New("Session invalid or expired, please login first")
+	ErrSuspended          = errors.New("Your account has been suspended")
 )
 
 type AuthService interface {
-	Register(payload models.UserRegisterInput) error
-	Login(payload models.UserLoginInput) (models.User, string, string, error)
-	Refresh(refreshToken string) (models.User, string, error)
-	Logout(refreshToken string) error
+	Register(ctx context.Context, payload models.UserRegisterInput, client audit.Client) error
+	Login(ctx context.Context, payload models.UserLoginInput, client audit.Client) (models.User, string, string, error)
+	Refresh(ctx context.Context, refreshToken string) (models.User, string, error)
+	Logout(ctx context.Context, refreshToken string, client audit.Client) error
 }
 
 type authService struct {
 	c         *config.AppConfig
 	cacheRepo repository.CacheRepo
 	userRepo  repository.UserRepo
+	audit     audit.Logger
 }
 
-func NewAuthService(c *config.AppConfig, cr repository.CacheRepo, ur repository.UserRepo) AuthService {
+func NewAuthService(c *config.AppConfig, cr repository.CacheRepo, ur repository.UserRepo, al audit.Logger) AuthService {
 	return &authService{
 		c:         c,
 		cacheRepo: cr,
 		userRepo:  ur,
+		audit:     al,
 	}
 }
 
+// suspensionError wraps ErrSuspended with when the suspension is going to end
+func suspensionError(u models.User) error {
+	if u.SuspendedUntil == nil {
+		return fmt.Errorf("%w permanently", ErrSuspended)
+	}
+
+	return fmt.Errorf("%w until %s", ErrSuspended, u.SuspendedUntil.Format("2 Jan 2006 15:04 MST"))
+}
+
 // mockAuthService is a replica of the auth service to be used inside handler tests
 type mockAuthService struct {
 	cacheRepo repository.CacheRepo
@@ -1,10 +1,12 @@
 package auth
 
 import (
+	"context"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
@@ -19,7 +21,7 @@ func TestNewAuthService(t *testing.T) {
 	var c *config.AppConfig
 	cr := redis.NewRepo(db)
 	ur := postgres.NewUserRepo(db)
-	service := NewAuthService(c, cr, ur)
+	service := NewAuthService(c, cr, ur, audit.NewMockLogger())
 
 	typeString := reflect.TypeOf(service).String()
 
@@ -49,7 +51,7 @@ func newTestService() AuthService {
 	cr := redis.NewMockRepo()
 	ur := postgres.NewMockUserRepo()
 
-	service := NewAuthService(&tc, cr, ur)
+	service := NewAuthService(&tc, cr, ur, audit.NewMockLogger())
 
 	return service
 }
@@ -76,7 +78,7 @@ func TestAuthService_Register(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := models.UserRegisterInput{Email: tt.email, Password: tt.password}
-			err := s.Register(p)
+			err := s.Register(context.Background(), p, audit.Client{})
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -100,13 +102,14 @@ func TestAuthService_Login(t *testing.T) {
 		{"no user", repository.NotFoundKey, "", true},
 		{"error getting user", repository.UnexpectedKey, "", true},
 		{"invalid credentials", "", "x", true},
+		{"suspended", repository.SuspendedKey, "password", true},
 		{"error setting refresh token", "get-invalid-user", "password", true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			p := models.UserLoginInput{Email: tt.email, Password: tt.password}
-			_, _, _, err := s.Login(p)
+			_, _, _, err := s.Login(context.Background(), p, audit.Client{})
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -153,6 +156,13 @@ func TestAuthService_Refresh(t *testing.T) {
 		Duration:  1 * time.Minute,
 	})
 
+	var refreshTokenUserSuspended, _ = token.Generate(token.Details{
+		UserId:    repository.SuspendedKeyInt,
+		UniqueId:  "uuid",
+		SecretKey: tc.RefreshTokenKey,
+		Duration:  1 * time.Minute,
+	})
+
 	var tests = []struct {
 		name         string
 		refreshToken string
@@ -163,11 +173,12 @@ func TestAuthService_Refresh(t *testing.T) {
 		{"error getting refresh token", refreshTokenInvalid2, true},
 		{"no user", refreshTokenUserNotFound, true},
 		{"error getting user", refreshTokenUserUnexpectedError, true},
+		{"suspended", refreshTokenUserSuspended, true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, err := s.Refresh(tt.refreshToken)
+			_, _, err := s.Refresh(context.Background(), tt.refreshToken)
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -220,7 +231,7 @@ func TestAuthService_Logout(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := s.Logout(tt.refreshToken)
+			err := s.Logout(context.Background(), tt.refreshToken, audit.Client{})
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -1,20 +1,27 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func (s *authService) Login(payload models.UserLoginInput) (models.User, string, string, error) {
-	user, err := s.userRepo.GetUser(models.UserFilters{Email: payload.Email})
+func (s *authService) Login(ctx context.Context, payload models.UserLoginInput, client audit.Client) (models.User, string, string, error) {
+	user, err := s.userRepo.GetUser(ctx, models.UserFilters{Email: payload.Email})
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
+			s.audit.Log(ctx, models.AuditEvent{
+				Action:   audit.ActionLoginFailed,
+				Metadata: map[string]interface{}{"email": payload.Email, "reason": "no user"},
+			}, client)
+
 			return user, "", "", ErrNoUser
 		}
 
@@ -29,9 +36,29 @@ func (s *authService) Login(payload models.UserLoginInput) (models.User, string,
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(payload.Password))
 	if err != nil {
+		s.audit.Log(ctx, models.AuditEvent{
+			ActorId:    user.Id,
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			TargetId:   user.Id,
+			Metadata:   map[string]interface{}{"reason": "invalid password"},
+		}, client)
+
 		return user, "", "", ErrInvalidCredentials
 	}
 
+	if user.IsSuspended() {
+		s.audit.Log(ctx, models.AuditEvent{
+			ActorId:    user.Id,
+			Action:     audit.ActionLoginFailed,
+			TargetType: audit.TargetUser,
+			TargetId:   user.Id,
+			Metadata:   map[string]interface{}{"reason": "suspended"},
+		}, client)
+
+		return models.User{}, "", "", suspensionError(user)
+	}
+
 	accessToken, err := token.Generate(accessTD)
 	if err != nil {
 		return user, "", "", fmt.Errorf("generating access token: %w", err)
@@ -49,16 +76,23 @@ func (s *authService) Login(payload models.UserLoginInput) (models.User, string,
 		return user, "", "", fmt.Errorf("generating refresh token: %w", err)
 	}
 
-	if err = s.cacheRepo.SetRefreshToken(refreshTD); err != nil {
+	if err = s.cacheRepo.SetRefreshToken(ctx, refreshTD); err != nil {
 		return user, "", "", fmt.Errorf("caching refresh token: %w", err)
 	}
 
+	s.audit.Log(ctx, models.AuditEvent{
+		ActorId:    user.Id,
+		Action:     audit.ActionLogin,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+	}, client)
+
 	user.Password = ""
 
 	return user, accessToken, refreshToken, nil
 }
 
-func (s *mockAuthService) Login(payload models.UserLoginInput) (models.User, string, string, error) {
+func (s *mockAuthService) Login(ctx context.Context, payload models.UserLoginInput, client audit.Client) (models.User, string, string, error) {
 	var user models.User
 
 	switch payload.Password {
@@ -66,6 +100,8 @@ func (s *mockAuthService) Login(payload models.UserLoginInput) (models.User, str
 		return user, "", "", ErrNoUser
 	case ErrInvalidCredentials.Error():
 		return user, "", "", ErrInvalidCredentials
+	case ErrSuspended.Error():
+		return user, "", "", ErrSuspended
 	case "unexpected error":
 		return user, "", "", errors.New("unexpected error")
 	default:
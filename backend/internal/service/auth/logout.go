@@ -1,34 +1,44 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
 )
 
-func (s *authService) Logout(refreshToken string) error {
+func (s *authService) Logout(ctx context.Context, refreshToken string, client audit.Client) error {
 	tokenDetails, err := token.Parse(s.c.RefreshTokenKey, refreshToken)
 	if err != nil {
 		return ErrUnauthorized
 	}
 
-	uuid, err := s.cacheRepo.GetRefreshToken(*tokenDetails)
+	uuid, err := s.cacheRepo.GetRefreshToken(ctx, *tokenDetails)
 	if err != nil || uuid != tokenDetails.UniqueId {
 		return ErrUnauthorized
 	}
 
-	err = s.cacheRepo.DelRefreshToken(*tokenDetails)
+	err = s.cacheRepo.DelRefreshToken(ctx, *tokenDetails)
 	if err != nil {
 		log.Println(err)
 		return fmt.Errorf("deleting refresh token: %w", err)
 	}
 
+	s.audit.Log(ctx, models.AuditEvent{
+		ActorId:    tokenDetails.UserId,
+		Action:     audit.ActionLogout,
+		TargetType: audit.TargetUser,
+		TargetId:   tokenDetails.UserId,
+	}, client)
+
 	return nil
 }
 
-func (s *mockAuthService) Logout(refreshToken string) error {
+func (s *mockAuthService) Logout(ctx context.Context, refreshToken string, client audit.Client) error {
 	switch refreshToken {
 	case ErrUnauthorized.Error():
 		return ErrUnauthorized
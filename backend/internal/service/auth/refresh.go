@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -9,7 +10,7 @@ import (
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
 )
 
-func (s *authService) Refresh(refreshToken string) (models.User, string, error) {
+func (s *authService) Refresh(ctx context.Context, refreshToken string) (models.User, string, error) {
 	var user models.User
 
 	tokenDetails, err := token.Parse(s.c.RefreshTokenKey, refreshToken)
@@ -17,12 +18,12 @@ func (s *authService) Refresh(refreshToken string) (models.User, string, error)
 		return user, "", ErrUnauthorized
 	}
 
-	uuid, err := s.cacheRepo.GetRefreshToken(*tokenDetails)
+	uuid, err := s.cacheRepo.GetRefreshToken(ctx, *tokenDetails)
 	if err != nil || uuid != tokenDetails.UniqueId {
 		return user, "", ErrUnauthorized
 	}
 
-	user, err = s.userRepo.GetUser(models.UserFilters{Id: tokenDetails.UserId})
+	user, err = s.userRepo.GetUser(ctx, models.UserFilters{Id: tokenDetails.UserId})
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			return user, "", ErrNoUser
@@ -31,6 +32,10 @@ func (s *authService) Refresh(refreshToken string) (models.User, string, error)
 		return user, "", fmt.Errorf("getting user by id: %w", err)
 	}
 
+	if user.IsSuspended() {
+		return models.User{}, "", suspensionError(user)
+	}
+
 	accessToken, err := token.Generate(token.Details{
 		SecretKey: s.c.AccessTokenKey,
 		UserId:    user.Id,
@@ -46,7 +51,7 @@ func (s *authService) Refresh(refreshToken string) (models.User, string, error)
 	return user, accessToken, nil
 }
 
-func (s *mockAuthService) Refresh(refreshToken string) (models.User, string, error) {
+func (s *mockAuthService) Refresh(ctx context.Context, refreshToken string) (models.User, string, error) {
 	var user models.User
 
 	switch refreshToken {
@@ -54,6 +59,8 @@ func (s *mockAuthService) Refresh(refreshToken string) (models.User, string, err
 		return user, "", ErrUnauthorized
 	case ErrNoUser.Error():
 		return user, "", ErrNoUser
+	case ErrSuspended.Error():
+		return user, "", ErrSuspended
 	case "unexpected error":
 		return user, "", errors.New("unexpected error")
 	default:
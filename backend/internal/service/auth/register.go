@@ -1,21 +1,23 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func (s *authService) Register(payload models.UserRegisterInput) error {
+func (s *authService) Register(ctx context.Context, payload models.UserRegisterInput, client audit.Client) error {
 	pw, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return fmt.Errorf("hashing password: %w", err)
 	}
 
-	_, err = s.userRepo.CreateUser(payload.Username, payload.Email, string(pw))
+	id, err := s.userRepo.CreateUser(ctx, payload.Username, payload.Email, string(pw))
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key") {
 			return ErrDuplicateEmail // or ErrDuplicateUsername
@@ -24,10 +26,17 @@ func (s *authService) Register(payload models.UserRegisterInput) error {
 		return fmt.Errorf("creating user: %w", err)
 	}
 
+	s.audit.Log(ctx, models.AuditEvent{
+		ActorId:    id,
+		Action:     audit.ActionRegister,
+		TargetType: audit.TargetUser,
+		TargetId:   id,
+	}, client)
+
 	return nil
 }
 
-func (s *mockAuthService) Register(payload models.UserRegisterInput) error {
+func (s *mockAuthService) Register(ctx context.Context, payload models.UserRegisterInput, client audit.Client) error {
 	switch payload.Password {
 	case ErrDuplicateEmail.Error():
 		return ErrDuplicateEmail
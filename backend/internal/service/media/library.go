@@ -0,0 +1,57 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
+)
+
+// GetLibrary lists the media uploaded by the user, with the posts using them
+func (s *mediaService) GetLibrary(ctx context.Context, authId int) ([]models.Media, error) {
+	media, err := s.mediaRepo.GetMediaByUser(ctx, authId)
+	if err != nil {
+		return media, fmt.Errorf("getting media by user: %w", err)
+	}
+
+	for i := range media {
+		media[i].URL = s.store.URL(key(media[i].Name))
+	}
+
+	return media, nil
+}
+
+// CollectGarbage removes the media that no post references anymore, once
+// they are older than the grace period
+func (s *mediaService) CollectGarbage(ctx context.Context) (int, error) {
+	media, err := s.mediaRepo.DeleteUnusedMedia(ctx, time.Now().Add(-s.c.MediaGracePeriod))
+	if err != nil {
+		return 0, fmt.Errorf("deleting unused media: %w", err)
+	}
+
+	for _, m := range media {
+		if err := img.Remove(ctx, s.store, key(m.Name)); err != nil {
+			log.Println("removing image: ", err)
+		}
+	}
+
+	return len(media), nil
+}
+
+func (s *mockMediaService) GetLibrary(ctx context.Context, authId int) ([]models.Media, error) {
+	switch authId {
+	case repository.UnexpectedKeyInt:
+		return nil, errors.New("unexpected error")
+	default:
+		return []models.Media{}, nil
+	}
+}
+
+func (s *mockMediaService) CollectGarbage(ctx context.Context) (int, error) {
+	return 0, nil
+}
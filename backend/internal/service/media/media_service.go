@@ -0,0 +1,54 @@
+package media
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+)
+
+var (
+	ErrImageTooLarge = errors.New("Image file is too large")
+	ErrImageSize     = errors.New("Image dimensions are too large (40 megapixels max)")
+	ErrImageInvalid  = errors.New("Invalid type, image should be jpg/jpeg/png/gif/webp, avif isn't supported")
+)
+
+type MediaService interface {
+	Upload(ctx context.Context, payload models.MediaUploadInput, authId int) (models.Media, error)
+	GetLibrary(ctx context.Context, authId int) ([]models.Media, error)
+	CollectGarbage(ctx context.Context) (int, error)
+}
+
+type mediaService struct {
+	c         *config.AppConfig
+	mediaRepo repository.MediaRepo
+	store     storage.Store
+}
+
+func NewMediaService(c *config.AppConfig, mr repository.MediaRepo, st storage.Store) MediaService {
+	return &mediaService{
+		c:         c,
+		mediaRepo: mr,
+		store:     st,
+	}
+}
+
+// key is where the media is kept in the store
+func key(name string) string {
+	return "media/" + name
+}
+
+// mockMediaService is a replica of the media service to be used inside handler tests
+type mockMediaService struct {
+	mediaRepo repository.MediaRepo
+}
+
+func NewMockMediaService() MediaService {
+	return &mockMediaService{
+		mediaRepo: postgres.NewMockMediaRepo(),
+	}
+}
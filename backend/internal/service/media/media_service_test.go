@@ -0,0 +1,154 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+)
+
+func TestNewMediaService(t *testing.T) {
+	var db *database.DB
+	var c *config.AppConfig
+	mr := postgres.NewMediaRepo(db)
+	service := NewMediaService(c, mr, storage.NewMockStore())
+
+	typeString := reflect.TypeOf(service).String()
+
+	if typeString != "*media.mediaService" {
+		t.Error("NewMediaService() get incorrect type, wanted *media.mediaService")
+	}
+}
+
+func TestNewMockMediaService(t *testing.T) {
+	service := NewMockMediaService()
+
+	typeString := reflect.TypeOf(service).String()
+
+	if typeString != "*media.mockMediaService" {
+		t.Error("NewMockMediaService() get incorrect type, wanted *media.mockMediaService")
+	}
+}
+
+func newTestService() MediaService {
+	tc := config.AppConfig{MediaGracePeriod: time.Hour}
+	tc.Uploads.Media = 2 * 1024 * 1024
+	mr := postgres.NewMockMediaRepo()
+
+	service := NewMediaService(&tc, mr, storage.NewMockStore())
+
+	return service
+}
+
+var s = newTestService()
+
+func TestMediaService_Upload(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)))
+
+	var tests = []struct {
+		name    string
+		file    io.ReadSeeker
+		authId  int
+		isError bool
+	}{
+		{"success", bytes.NewReader(buf.Bytes()), 1, false},
+		{"image invalid type", bytes.NewReader(make([]byte, 1)), 1, true},
+		{"image too large", bytes.NewReader(make([]byte, 2*1024*1024+2)), 1, true},
+		{"error verifying image", &bytes.Reader{}, 1, true},
+		{"error creating media", bytes.NewReader(buf.Bytes()), repository.UnexpectedKeyInt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media, err := s.Upload(context.Background(), models.MediaUploadInput{File: tt.file}, tt.authId)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+
+			if err == nil && media.URL != "/images/media/"+media.Name {
+				t.Errorf("unexpected url %q", media.URL)
+			}
+
+			if err == nil && media.Size != int64(buf.Len()) {
+				t.Errorf("want size %d, got %d", buf.Len(), media.Size)
+			}
+		})
+	}
+}
+
+func TestMediaService_UploadRollback(t *testing.T) {
+	tc := config.AppConfig{}
+	tc.Uploads.Media = 2 * 1024 * 1024
+	st := storage.NewMockStore()
+	s := NewMediaService(&tc, postgres.NewMockMediaRepo(), st)
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)))
+
+	payload := models.MediaUploadInput{File: bytes.NewReader(buf.Bytes())}
+	if _, err := s.Upload(context.Background(), payload, repository.UnexpectedKeyInt); err == nil {
+		t.Fatal("expecting error")
+	}
+
+	if objects, _ := st.List(context.Background(), "media/"); len(objects) != 0 {
+		t.Errorf("want the image removed, got %d files", len(objects))
+	}
+}
+
+func TestMediaService_GetLibrary(t *testing.T) {
+	var tests = []struct {
+		name    string
+		authId  int
+		isError bool
+	}{
+		{"success", 1, false},
+		{"error getting media", repository.UnexpectedKeyInt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			media, err := s.GetLibrary(context.Background(), tt.authId)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+
+			if err == nil && media[0].URL != "/images/media/example.png" {
+				t.Errorf("unexpected url %q", media[0].URL)
+			}
+		})
+	}
+}
+
+func TestMediaService_CollectGarbage(t *testing.T) {
+	// Failing to remove a file is only logged
+	n, err := s.CollectGarbage(context.Background())
+	if err != nil {
+		t.Errorf("expecting no error, got %v", err)
+	}
+
+	if n != 2 {
+		t.Errorf("want 2 removed, got %d", n)
+	}
+}
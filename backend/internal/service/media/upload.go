@@ -0,0 +1,92 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
+	"github.com/google/uuid"
+)
+
+// Upload stores an image to be embedded in post content. It only counts as
+// used once a post references its URL, see CollectGarbage.
+func (s *mediaService) Upload(ctx context.Context, payload models.MediaUploadInput, authId int) (models.Media, error) {
+	var media models.Media
+
+	ext, err := img.Verify(payload.File, s.c.Uploads.Media)
+	if err != nil {
+		switch err {
+		case img.ErrTooLarge:
+			return media, fmt.Errorf("%w (%s max)", ErrImageTooLarge, img.FormatSize(s.c.Uploads.Media))
+		case img.ErrDimensions:
+			return media, ErrImageSize
+		case img.ErrType:
+			return media, ErrImageInvalid
+		default:
+			return media, fmt.Errorf("verifying image: %w", err)
+		}
+	}
+
+	size, err := payload.File.Seek(0, io.SeekEnd)
+	if err != nil {
+		return media, fmt.Errorf("getting image size: %w", err)
+	}
+
+	if _, err := payload.File.Seek(0, io.SeekStart); err != nil {
+		return media, fmt.Errorf("getting image size: %w", err)
+	}
+
+	media.UserId = authId
+	media.Name = fmt.Sprintf("%s-%d", uuid.New(), authId) + ext
+	media.ContentType = mime.TypeByExtension(ext)
+	media.Size = size
+
+	// Uploads are only kept once the media row is written
+	tx := storage.Begin(s.store)
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Println("unable to roll back images: ", err)
+		}
+	}()
+
+	if err := img.Save(ctx, tx, payload.File, key(media.Name)); err != nil {
+		return media, fmt.Errorf("saving image: %w", err)
+	}
+
+	created, err := s.mediaRepo.CreateMedia(ctx, media)
+	if err != nil {
+		return media, fmt.Errorf("creating media: %w", err)
+	}
+
+	tx.Commit()
+
+	created.URL = s.store.URL(key(created.Name))
+	created.Posts = []models.MediaUsage{}
+
+	return created, nil
+}
+
+func (s *mockMediaService) Upload(ctx context.Context, payload models.MediaUploadInput, authId int) (models.Media, error) {
+	var media models.Media
+
+	b, _ := io.ReadAll(payload.File)
+
+	switch string(b) {
+	case ErrImageTooLarge.Error():
+		return media, ErrImageTooLarge
+	case ErrImageSize.Error():
+		return media, ErrImageSize
+	case ErrImageInvalid.Error():
+		return media, ErrImageInvalid
+	case "unexpected error":
+		return media, errors.New("unexpected error")
+	default:
+		return media, nil
+	}
+}
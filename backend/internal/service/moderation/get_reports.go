@@ -0,0 +1,76 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+	"github.com/gosimple/slug"
+)
+
+func (s *moderationService) GetReports(ctx context.Context, q url.Values, authId int) ([]models.Report, *pagination.Meta, error) {
+	var reports []models.Report
+
+	if _, err := s.checkModerator(ctx, authId); err != nil {
+		return reports, nil, err
+	}
+
+	limit := pagination.Limit(q, 20)
+
+	filters := models.ReportsFilters{
+		Status: q.Get("status"),
+		Reason: q.Get("reason"),
+		Limit:  limit,
+	}
+
+	switch filters.Status {
+	case "":
+		filters.Status = models.ReportPending
+	case models.ReportPending, models.ReportDismissed, models.ReportResolved:
+	default:
+		return reports, nil, ErrInvalidStatus
+	}
+
+	pgMeta, err := pagination.NewMeta(q, limit)
+	if err != nil {
+		return reports, pgMeta, fmt.Errorf("creating pagination meta: %w", err)
+	}
+
+	if pgMeta.Total == 0 {
+		total, err := s.reportRepo.CountReports(ctx, filters)
+		if err != nil && !errors.Is(sql.ErrNoRows, err) {
+			return reports, nil, fmt.Errorf("counting reports: %w", err)
+		}
+
+		pgMeta.SetNewTotal(total, limit)
+	}
+
+	reports, err = s.reportRepo.GetReports(ctx, pgMeta, filters)
+	if err != nil && !errors.Is(sql.ErrNoRows, err) {
+		return reports, nil, fmt.Errorf("getting reports: %w", err)
+	}
+
+	return reports, pgMeta, nil
+}
+
+func (s *mockModerationService) GetReports(ctx context.Context, q url.Values, authId int) ([]models.Report, *pagination.Meta, error) {
+	reports, pgMeta := []models.Report{}, &pagination.Meta{}
+
+	if q.Has(slug.Make(ErrUnauthorized.Error())) {
+		return reports, nil, ErrUnauthorized
+	}
+
+	if q.Has(slug.Make(ErrInvalidStatus.Error())) {
+		return reports, nil, ErrInvalidStatus
+	}
+
+	if q.Has(slug.Make("unexpected error")) {
+		return reports, nil, errors.New("unexpected error")
+	}
+
+	return reports, pgMeta, nil
+}
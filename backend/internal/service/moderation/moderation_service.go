@@ -0,0 +1,133 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
+)
+
+var (
+	ErrDuplicateReport = errors.New("You have already reported this post")
+	ErrOwnPost         = errors.New("You cannot report your own post")
+	ErrNoPost          = errors.New("Post not found")
+	ErrNoReport        = errors.New("Report not found")
+	ErrResolved        = errors.New("Report has already been resolved")
+	ErrInvalidStatus   = errors.New("Status should be pending/dismissed/resolved")
+	ErrUnauthorized    = errors.New("You have no permission to do that")
+)
+
+type ModerationService interface {
+	Report(ctx context.Context, payload models.ReportInput, slug string, authId int) error
+	GetReports(ctx context.Context, q url.Values, authId int) ([]models.Report, *pagination.Meta, error)
+	Resolve(ctx context.Context, payload models.ResolveReportInput, id, authId int, client audit.Client) error
+}
+
+type moderationService struct {
+	c          *config.AppConfig
+	cacheRepo  repository.CacheRepo
+	reportRepo repository.ReportRepo
+	postRepo   repository.PostRepo
+	userRepo   repository.UserRepo
+	txManager  repository.TxManager
+	audit      audit.Logger
+}
+
+func NewModerationService(
+	c *config.AppConfig,
+	cr repository.CacheRepo,
+	rr repository.ReportRepo,
+	pr repository.PostRepo,
+	ur repository.UserRepo,
+	tm repository.TxManager,
+	al audit.Logger,
+) ModerationService {
+	return &moderationService{
+		c:          c,
+		cacheRepo:  cr,
+		reportRepo: rr,
+		postRepo:   pr,
+		userRepo:   ur,
+		txManager:  tm,
+		audit:      al,
+	}
+}
+
+// checkModerator returns ErrUnauthorized unless the user is a moderator or an
+// admin, the user is returned for the checks depending on the role
+func (s *moderationService) checkModerator(ctx context.Context, authId int) (models.User, error) {
+	user, err := s.userRepo.GetUser(ctx, models.UserFilters{Id: authId})
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return user, ErrUnauthorized
+		}
+
+		return user, fmt.Errorf("getting user by id: %w", err)
+	}
+
+	if !user.IsModerator() {
+		return user, ErrUnauthorized
+	}
+
+	return user, nil
+}
+
+// uncache drops the cached post, which is only logged on failure as the
+// cache expires by itself anyway
+func (s *moderationService) uncache(ctx context.Context, slug string) {
+	ctx = context.WithoutCancel(ctx)
+
+	if err := s.cacheRepo.DelPost(ctx, slug); err != nil {
+		log.Println("unable to uncache post: ", err)
+	}
+}
+
+// revoke makes the auth middleware reject the user's live tokens and deletes
+// their refresh token. The suspension is saved by then, so failures are only
+// logged, the auth middleware reads it from the database once its cached
+// answer expires.
+func (s *moderationService) revoke(ctx context.Context, userId int, until *time.Time) {
+	ctx = context.WithoutCancel(ctx)
+
+	var d time.Duration
+	if until != nil {
+		d = time.Until(*until)
+	}
+
+	if err := s.cacheRepo.SetSuspension(ctx, userId, d); err != nil {
+		log.Println("unable to cache suspension: ", err)
+	}
+
+	if err := s.cacheRepo.DelRefreshToken(ctx, token.Details{UserId: userId}); err != nil {
+		log.Println("unable to delete refresh token: ", err)
+	}
+}
+
+// mockModerationService is a replica of the moderation service to be used inside handler tests
+type mockModerationService struct {
+	cacheRepo  repository.CacheRepo
+	reportRepo repository.ReportRepo
+	postRepo   repository.PostRepo
+	userRepo   repository.UserRepo
+}
+
+func NewMockModerationService() ModerationService {
+	return &mockModerationService{
+		cacheRepo:  redis.NewMockRepo(),
+		reportRepo: postgres.NewMockReportRepo(),
+		postRepo:   postgres.NewMockPostRepo(),
+		userRepo:   postgres.NewMockUserRepo(),
+	}
+}
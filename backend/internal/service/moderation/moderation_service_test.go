@@ -0,0 +1,211 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/config"
+	"github.com/Noblefel/ManorTalk/backend/internal/database"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
+)
+
+func TestNewModerationService(t *testing.T) {
+	var db *database.DB
+	var c *config.AppConfig
+	cr := redis.NewRepo(db)
+	rr := postgres.NewReportRepo(db)
+	pr := postgres.NewPostRepo(db)
+	ur := postgres.NewUserRepo(db)
+	service := NewModerationService(c, cr, rr, pr, ur, postgres.NewTxManager(db), audit.NewMockLogger())
+
+	typeString := reflect.TypeOf(service).String()
+
+	if typeString != "*moderation.moderationService" {
+		t.Error("NewModerationService() get incorrect type, wanted *moderation.moderationService")
+	}
+}
+
+func TestNewMockModerationService(t *testing.T) {
+	service := NewMockModerationService()
+
+	typeString := reflect.TypeOf(service).String()
+
+	if typeString != "*moderation.mockModerationService" {
+		t.Error("NewMockModerationService() get incorrect type, wanted *moderation.mockModerationService")
+	}
+}
+
+func newTestService() ModerationService {
+	var tc config.AppConfig
+	cr := redis.NewMockRepo()
+	rr := postgres.NewMockReportRepo()
+	pr := postgres.NewMockPostRepo()
+	ur := postgres.NewMockUserRepo()
+	tm := postgres.NewMockTxManager(repository.Repos{Post: pr, User: ur, Report: rr})
+
+	service := NewModerationService(&tc, cr, rr, pr, ur, tm, audit.NewMockLogger())
+
+	return service
+}
+
+var s = newTestService()
+
+func TestModerationService_Report(t *testing.T) {
+	var tests = []struct {
+		name    string
+		reason  string
+		slug    string
+		authId  int
+		isError bool
+	}{
+		{"success", "spam", "sample", 1, false},
+		{"no post", "spam", repository.NotFoundKey, 1, true},
+		{"error getting post", "spam", repository.UnexpectedKey, 1, true},
+		{"own post", "spam", "sample", 0, true},
+		{"duplicate report", repository.DuplicateKey, "sample", 1, true},
+		{"error creating report", repository.UnexpectedKey, "sample", 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := models.ReportInput{Reason: tt.reason}
+			err := s.Report(context.Background(), payload, tt.slug, tt.authId)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+		})
+	}
+}
+
+func TestModerationService_GetReports(t *testing.T) {
+	var tests = []struct {
+		name    string
+		q       url.Values
+		authId  int
+		isError bool
+	}{
+		{"success", url.Values{"page": {"1"}}, repository.AdminKeyInt, false},
+		{"success with status", url.Values{"status": {"resolved"}}, repository.AdminKeyInt, false},
+		{"success as moderator", url.Values{"page": {"1"}}, repository.ModeratorKeyInt, false},
+		{"zero limit", url.Values{"limit": {"0"}}, repository.AdminKeyInt, false},
+		{"not a moderator", url.Values{}, 1, true},
+		{"no user", url.Values{}, repository.NotFoundKeyInt, true},
+		{"error getting user", url.Values{}, repository.UnexpectedKeyInt, true},
+		{"invalid status", url.Values{"status": {"x"}}, repository.AdminKeyInt, true},
+		{"error creating pagination meta", url.Values{"page": {"-1"}}, repository.AdminKeyInt, true},
+		{"error counting reports", url.Values{"page": {"1"}, "reason": {repository.UnexpectedKey}}, repository.AdminKeyInt, true},
+		{"error getting reports", url.Values{"total": {"1"}, "reason": {repository.UnexpectedKey}}, repository.AdminKeyInt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := s.GetReports(context.Background(), tt.q, tt.authId)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+		})
+	}
+}
+
+func TestModerationService_ResolveCacheError(t *testing.T) {
+	var tc config.AppConfig
+	var actions []string
+	cr := failingCacheRepo{redis.NewMockRepo()}
+	rr := postgres.NewMockReportRepo()
+	pr := postgres.NewMockPostRepo()
+	ur := postgres.NewMockUserRepo()
+	tm := postgres.NewMockTxManager(repository.Repos{Post: pr, User: ur, Report: rr})
+	s := NewModerationService(&tc, cr, rr, pr, ur, tm, recordingLogger{&actions})
+
+	payload := models.ResolveReportInput{Action: models.ActionSuspend}
+	if err := s.Resolve(context.Background(), payload, 1, repository.AdminKeyInt, audit.Client{}); err != nil {
+		t.Errorf("expecting no error once the suspension is saved, got %v", err)
+	}
+
+	want := []string{audit.ActionSuspend, audit.ActionResolveReport}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("want %v audited, got %v", want, actions)
+	}
+}
+
+// failingCacheRepo fails to cache a suspension and to revoke refresh tokens
+type failingCacheRepo struct {
+	repository.CacheRepo
+}
+
+func (r failingCacheRepo) SetSuspension(ctx context.Context, userId int, d time.Duration) error {
+	return errors.New("some error")
+}
+
+func (r failingCacheRepo) DelRefreshToken(ctx context.Context, td token.Details) error {
+	return errors.New("some error")
+}
+
+// recordingLogger keeps the actions of the logged audit events
+type recordingLogger struct {
+	actions *[]string
+}
+
+func (l recordingLogger) Log(ctx context.Context, e models.AuditEvent, c audit.Client) {
+	*l.actions = append(*l.actions, e.Action)
+}
+
+func TestModerationService_Resolve(t *testing.T) {
+	var tests = []struct {
+		name    string
+		action  string
+		id      int
+		authId  int
+		isError bool
+	}{
+		{"success dismiss", models.ActionDismiss, 1, repository.AdminKeyInt, false},
+		{"success hide", models.ActionHide, 1, repository.AdminKeyInt, false},
+		{"success delete", models.ActionDelete, 1, repository.AdminKeyInt, false},
+		{"success suspend", models.ActionSuspend, 1, repository.AdminKeyInt, false},
+		{"success as moderator", models.ActionHide, 1, repository.ModeratorKeyInt, false},
+		{"success suspend as moderator", models.ActionSuspend, 1, repository.ModeratorKeyInt, false},
+		{"not a moderator", models.ActionDismiss, 1, 1, true},
+		{"suspend an admin", models.ActionSuspend, repository.AdminKeyInt, repository.ModeratorKeyInt, true},
+		{"suspend a moderator", models.ActionSuspend, repository.ModeratorKeyInt, repository.ModeratorKeyInt, true},
+		{"suspend an admin as admin", models.ActionSuspend, repository.AdminKeyInt, repository.AdminKeyInt, true},
+		{"suspend a moderator as admin", models.ActionSuspend, repository.ModeratorKeyInt, repository.AdminKeyInt, false},
+		{"hide a moderator's post", models.ActionHide, repository.ModeratorKeyInt, repository.ModeratorKeyInt, false},
+		{"no report", models.ActionDismiss, repository.NotFoundKeyInt, repository.AdminKeyInt, true},
+		{"error getting report", models.ActionDismiss, repository.UnexpectedKeyInt, repository.AdminKeyInt, true},
+		{"already resolved", models.ActionDismiss, repository.IncorrectKeyInt, repository.AdminKeyInt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := models.ResolveReportInput{Action: tt.action}
+			err := s.Resolve(context.Background(), payload, tt.id, tt.authId, audit.Client{})
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+		})
+	}
+}
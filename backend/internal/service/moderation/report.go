@@ -0,0 +1,62 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+)
+
+func (s *moderationService) Report(ctx context.Context, payload models.ReportInput, slug string, authId int) error {
+	post, err := s.postRepo.GetPostBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return ErrNoPost
+		}
+
+		return fmt.Errorf("getting post by slug: %w", err)
+	}
+
+	if post.HiddenAt != nil {
+		return ErrNoPost
+	}
+
+	if post.UserId == authId {
+		return ErrOwnPost
+	}
+
+	err = s.reportRepo.CreateReport(ctx, models.Report{
+		PostId:     post.Id,
+		ReporterId: authId,
+		Reason:     payload.Reason,
+		Note:       payload.Note,
+	})
+
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return ErrDuplicateReport
+		}
+
+		return fmt.Errorf("creating report: %w", err)
+	}
+
+	return nil
+}
+
+func (s *mockModerationService) Report(ctx context.Context, payload models.ReportInput, slug string, authId int) error {
+	switch slug {
+	case ErrNoPost.Error():
+		return ErrNoPost
+	case ErrOwnPost.Error():
+		return ErrOwnPost
+	case ErrDuplicateReport.Error():
+		return ErrDuplicateReport
+	case "unexpected error":
+		return errors.New("unexpected error")
+	default:
+		return nil
+	}
+}
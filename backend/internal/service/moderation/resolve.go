@@ -0,0 +1,160 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+)
+
+// Resolve applies the moderator's decision on the reported post and
+// closes the report, along with any other pending report of the same post.
+func (s *moderationService) Resolve(ctx context.Context, payload models.ResolveReportInput, id, authId int, client audit.Client) error {
+	moderator, err := s.checkModerator(ctx, authId)
+	if err != nil {
+		return err
+	}
+
+	report, err := s.reportRepo.GetReportById(ctx, id)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return ErrNoReport
+		}
+
+		return fmt.Errorf("getting report by id: %w", err)
+	}
+
+	if report.Status != models.ReportPending {
+		return ErrResolved
+	}
+
+	// The post may already be gone, in which case only dismissing makes sense
+	if report.PostId == 0 && payload.Action != models.ActionDismiss {
+		return ErrNoPost
+	}
+
+	// The same rules as userService.Suspend, only admins suspend the staff
+	// and nobody suspends an admin
+	if payload.Action == models.ActionSuspend {
+		author, err := s.userRepo.GetUser(ctx, models.UserFilters{Id: report.Post.UserId})
+		if err != nil {
+			if errors.Is(sql.ErrNoRows, err) {
+				return ErrNoPost
+			}
+
+			return fmt.Errorf("getting user by id: %w", err)
+		}
+
+		if author.Role == models.RoleAdmin || (author.IsModerator() && moderator.Role != models.RoleAdmin) {
+			return ErrUnauthorized
+		}
+	}
+
+	report.Status = models.ReportResolved
+	report.Action = payload.Action
+	report.ModeratorId = authId
+
+	if payload.Action == models.ActionDismiss {
+		report.Status = models.ReportDismissed
+	}
+
+	suspension := models.SuspendInput{
+		Reason: "Reported for " + report.Reason,
+		Days:   payload.Days,
+	}
+	until := suspension.Until()
+
+	// The cache and the audit log are only touched once the decision is
+	// committed as a whole
+	err = s.txManager.WithinTx(ctx, func(repos repository.Repos) error {
+		switch payload.Action {
+		case models.ActionHide:
+			if err := repos.Post.HidePost(ctx, report.PostId); err != nil {
+				return fmt.Errorf("hiding post: %w", err)
+			}
+		case models.ActionDelete:
+			// Hidden as well, so the author can't bring it back from the trash
+			if err := repos.Post.HidePost(ctx, report.PostId); err != nil {
+				return fmt.Errorf("hiding post: %w", err)
+			}
+
+			if err := repos.Post.DeletePost(ctx, report.PostId); err != nil {
+				return fmt.Errorf("deleting post: %w", err)
+			}
+		case models.ActionSuspend:
+			if err := repos.User.SuspendUser(ctx, report.Post.UserId, until, suspension.Reason); err != nil {
+				return fmt.Errorf("suspending user: %w", err)
+			}
+		}
+
+		if err := repos.Report.ResolveReports(ctx, report); err != nil {
+			return fmt.Errorf("resolving reports: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	switch payload.Action {
+	case models.ActionHide, models.ActionDelete:
+		s.uncache(ctx, report.Post.Slug)
+	case models.ActionSuspend:
+		// The author's cached profile catches up with the suspension once it expires
+		s.revoke(ctx, report.Post.UserId, until)
+
+		s.audit.Log(ctx, models.AuditEvent{
+			ActorId:    authId,
+			Action:     audit.ActionSuspend,
+			TargetType: audit.TargetUser,
+			TargetId:   report.Post.UserId,
+			Metadata:   map[string]interface{}{"reason": suspension.Reason, "days": payload.Days},
+		}, client)
+	}
+
+	s.audit.Log(ctx, models.AuditEvent{
+		ActorId:    authId,
+		Action:     audit.ActionResolveReport,
+		TargetType: audit.TargetReport,
+		TargetId:   report.Id,
+		Metadata: map[string]interface{}{
+			"action":  payload.Action,
+			"post_id": report.PostId,
+			"reason":  report.Reason,
+		},
+	}, client)
+
+	if payload.Action == models.ActionDelete {
+		s.audit.Log(ctx, models.AuditEvent{
+			ActorId:    authId,
+			Action:     audit.ActionDeletePost,
+			TargetType: audit.TargetPost,
+			TargetId:   report.PostId,
+			Metadata:   map[string]interface{}{"title": report.Post.Title, "slug": report.Post.Slug},
+		}, client)
+	}
+
+	return nil
+}
+
+func (s *mockModerationService) Resolve(ctx context.Context, payload models.ResolveReportInput, id, authId int, client audit.Client) error {
+	if authId == repository.NotFoundKeyInt {
+		return ErrUnauthorized
+	}
+
+	switch id {
+	case repository.NotFoundKeyInt:
+		return ErrNoReport
+	case repository.IncorrectKeyInt:
+		return ErrResolved
+	case repository.UnexpectedKeyInt:
+		return errors.New("unexpected error")
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package post
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// backfillBatch is how many posts are read from the database at a time
+const backfillBatch = 100
+
+// Backfill stores the rendered content, excerpt, word count, reading time and
+// table of contents of the posts written before they were stored, and
+// returns how many it stored. Posts edited in the meantime are skipped, the
+// edit stored them already.
+func (s *postService) Backfill(ctx context.Context) (int, error) {
+	var n, afterId int
+
+	for {
+		posts, err := s.postRepo.GetUnrenderedPosts(ctx, afterId, backfillBatch)
+		if err != nil {
+			return n, fmt.Errorf("getting unrendered posts: %w", err)
+		}
+
+		for _, post := range posts {
+			afterId = post.Id
+
+			if err := render(&post); err != nil {
+				log.Printf("rendering post %d: %v", post.Id, err)
+				continue
+			}
+
+			stored, err := s.postRepo.SetRendered(ctx, post)
+			if err != nil {
+				return n, fmt.Errorf("storing rendered post: %w", err)
+			}
+
+			if stored {
+				n++
+			}
+		}
+
+		if len(posts) < backfillBatch {
+			return n, nil
+		}
+	}
+}
+
+func (s *mockPostService) Backfill(ctx context.Context) (int, error) {
+	return 0, nil
+}
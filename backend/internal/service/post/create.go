@@ -1,22 +1,25 @@
 package post
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"path/filepath"
+	"log"
 	"strings"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
 	"github.com/google/uuid"
 	"github.com/gosimple/slug"
 )
 
-func (s *postService) Create(payload models.PostCreateInput, authId int) (models.Post, error) {
+func (s *postService) Create(ctx context.Context, payload models.PostCreateInput, authId int) (models.Post, error) {
 	var post models.Post
 
-	category, err := s.postRepo.GetCategoryById(payload.CategoryId)
+	category, err := s.postRepo.GetCategoryById(ctx, payload.CategoryId)
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			return post, ErrNoCategory
@@ -25,12 +28,22 @@ func (s *postService) Create(payload models.PostCreateInput, authId int) (models
 		return post, fmt.Errorf("getting category by id: %w", err)
 	}
 
+	// Uploads are only kept once the row referencing them is written
+	tx := storage.Begin(s.store)
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Println("unable to roll back images: ", err)
+		}
+	}()
+
 	if payload.Image != nil {
-		ext, err := img.Verify(payload.Image)
+		ext, err := img.Verify(payload.Image, s.c.Uploads.Post)
 		if err != nil {
 			switch err {
 			case img.ErrTooLarge:
-				return post, ErrImageTooLarge
+				return post, fmt.Errorf("%w (%s max)", ErrImageTooLarge, img.FormatSize(s.c.Uploads.Post))
+			case img.ErrDimensions:
+				return post, ErrImageSize
 			case img.ErrType:
 				return post, ErrImageInvalid
 			default:
@@ -39,8 +52,9 @@ func (s *postService) Create(payload models.PostCreateInput, authId int) (models
 		}
 		name := fmt.Sprintf("%s-%d", uuid.New(), authId) + ext
 		post.Image = name
+		post.ImageVariants = s.c.Images.Post.String()
 
-		err = img.Save(payload.Image, filepath.Join("images", "post", post.Image))
+		err = img.Save(ctx, tx, payload.Image, "post/"+post.Image, s.c.Images.Post...)
 		if err != nil {
 			return post, fmt.Errorf("saving image: %w", err)
 		}
@@ -53,20 +67,44 @@ func (s *postService) Create(payload models.PostCreateInput, authId int) (models
 	post.Content = payload.Content
 	post.CategoryId = payload.CategoryId
 
-	post, err = s.postRepo.CreatePost(post)
-	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			return post, ErrDuplicateTitle
+	if err := render(&post); err != nil {
+		return post, fmt.Errorf("rendering content: %w", err)
+	}
+
+	if err := s.checkSlug(ctx, post.Slug, 0); err != nil {
+		return post, err
+	}
+
+	err = s.txManager.WithinTx(ctx, func(repos repository.Repos) error {
+		created, err := repos.Post.CreatePost(ctx, post)
+		if err != nil {
+			if strings.Contains(err.Error(), "duplicate key") {
+				return ErrDuplicateTitle
+			}
+
+			return fmt.Errorf("creating post: %w", err)
+		}
+
+		if err := repos.Post.SetPostMedia(ctx, created.Id, mediaNames(created.Content)); err != nil {
+			return fmt.Errorf("setting post media: %w", err)
 		}
 
-		return post, fmt.Errorf("creating post: %w", err)
+		post = created
+		return nil
+	})
+	if err != nil {
+		return post, err
 	}
+
+	tx.Commit()
+
 	post.Category = category
+	s.withSrcset(&post)
 
 	return post, nil
 }
 
-func (s *mockPostService) Create(payload models.PostCreateInput, authId int) (models.Post, error) {
+func (s *mockPostService) Create(ctx context.Context, payload models.PostCreateInput, authId int) (models.Post, error) {
 	var post models.Post
 	switch payload.Title {
 	case ErrNoCategory.Error():
@@ -75,6 +113,8 @@ func (s *mockPostService) Create(payload models.PostCreateInput, authId int) (mo
 		return post, ErrDuplicateTitle
 	case ErrImageTooLarge.Error():
 		return post, ErrImageTooLarge
+	case ErrImageSize.Error():
+		return post, ErrImageSize
 	case ErrImageInvalid.Error():
 		return post, ErrImageInvalid
 	case "unexpected error":
@@ -1,16 +1,17 @@
 package post
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
 )
 
-func (s *postService) Delete(slug string, authId int) error {
-	post, err := s.postRepo.GetPostBySlug(slug)
+func (s *postService) Delete(ctx context.Context, slug string, authId int, client audit.Client) error {
+	post, err := s.postRepo.GetPostBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			return ErrNoPost
@@ -23,22 +24,25 @@ func (s *postService) Delete(slug string, authId int) error {
 		return ErrUnauthorized
 	}
 
-	err = s.postRepo.DeletePost(post.Id)
+	err = s.postRepo.DeletePost(ctx, post.Id)
 	if err != nil {
 		return fmt.Errorf("deleting post: %w", err)
 	}
 
-	if post.Image != "" {
-		err := os.Remove(filepath.Join("images", "post", post.Image))
-		if err != nil {
-			log.Println("removing image: ", err)
-		}
-	}
+	s.uncache(ctx, post.Slug)
+
+	s.audit.Log(ctx, models.AuditEvent{
+		ActorId:    authId,
+		Action:     audit.ActionDeletePost,
+		TargetType: audit.TargetPost,
+		TargetId:   post.Id,
+		Metadata:   map[string]interface{}{"title": post.Title, "slug": post.Slug},
+	}, client)
 
 	return nil
 }
 
-func (s *mockPostService) Delete(slug string, authId int) error {
+func (s *mockPostService) Delete(ctx context.Context, slug string, authId int, client audit.Client) error {
 	switch slug {
 	case ErrNoPost.Error():
 		return ErrNoPost
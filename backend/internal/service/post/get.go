@@ -1,31 +1,99 @@
 package post
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 )
 
-func (s *postService) Get(slug string) (models.Post, error) {
-	post, err := s.postRepo.GetPostBySlug(slug)
+func (s *postService) Get(ctx context.Context, slug string) (models.Post, error) {
+	v, err := s.share(ctx, "post-"+slug, func(ctx context.Context) (interface{}, error) {
+		return s.getCached(ctx, slug)
+	})
+
+	post, _ := v.(models.Post)
+	return post, err
+}
+
+// getCached reads the post from the cache, or from the database to be cached
+// when it isn't. Only posts that can be served are cached.
+func (s *postService) getCached(ctx context.Context, slug string) (models.Post, error) {
+	if s.c.Cache.Post > 0 {
+		post, err := s.cacheRepo.GetPost(ctx, slug)
+		if err == nil {
+			return post, nil
+		}
+
+		if !errors.Is(err, repository.ErrCacheMiss) {
+			log.Println("getting cached post: ", err)
+		}
+	}
+
+	post, err := s.postRepo.GetPostBySlug(ctx, slug)
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
-			return post, ErrNoPost
+			return s.redirect(ctx, slug)
 		}
 
 		return post, fmt.Errorf("getting post by slug: %w", err)
 	}
 
+	// Posts hidden by a moderator are kept for the record, but not served
+	if post.HiddenAt != nil {
+		return models.Post{}, ErrNoPost
+	}
+
+	// Posts written before the rendered fields were stored have no table of contents
+	if post.TOC == nil && post.Content != "" {
+		if err := render(&post); err != nil {
+			log.Println("rendering content: ", err)
+		}
+	}
+
+	// The srcset is cached along, the recorded variants aren't
+	s.withSrcset(&post)
+
+	if s.c.Cache.Post > 0 {
+		if err := s.cacheRepo.SetPost(ctx, post, s.c.Cache.Post); err != nil {
+			log.Println("caching post: ", err)
+		}
+	}
+
 	return post, nil
 }
 
-func (s *mockPostService) Get(slug string) (models.Post, error) {
+// redirect looks up a former slug of a renamed post. Only the canonical slug
+// is returned along with ErrMoved, for the client to follow.
+func (s *postService) redirect(ctx context.Context, slug string) (models.Post, error) {
+	var post models.Post
+
+	redirect, err := s.postRepo.GetSlugRedirect(ctx, slug)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return post, ErrNoPost
+		}
+
+		return post, fmt.Errorf("getting slug redirect: %w", err)
+	}
+
+	post.Slug = redirect.Canonical
+
+	return post, ErrMoved
+}
+
+func (s *mockPostService) Get(ctx context.Context, slug string) (models.Post, error) {
 	var post models.Post
 	switch slug {
 	case ErrNoPost.Error():
 		return post, ErrNoPost
+	case ErrMoved.Error():
+		post.Slug = "example"
+		return post, ErrMoved
 	case "unexpected error":
 		return post, errors.New("unexpected error")
 	default:
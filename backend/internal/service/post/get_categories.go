@@ -1,22 +1,51 @@
 package post
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 )
 
-func (s *postService) GetCategories() ([]models.Category, error) {
-	categories, err := s.postRepo.GetCategories()
+func (s *postService) GetCategories(ctx context.Context) ([]models.Category, error) {
+	v, err := s.share(ctx, "categories", func(ctx context.Context) (interface{}, error) {
+		return s.getCategoriesCached(ctx)
+	})
+
+	categories, _ := v.([]models.Category)
+	return categories, err
+}
+
+func (s *postService) getCategoriesCached(ctx context.Context) ([]models.Category, error) {
+	if s.c.Cache.Categories > 0 {
+		categories, err := s.cacheRepo.GetCategories(ctx)
+		if err == nil {
+			return categories, nil
+		}
+
+		if !errors.Is(err, repository.ErrCacheMiss) {
+			log.Println("getting cached categories: ", err)
+		}
+	}
+
+	categories, err := s.postRepo.GetCategories(ctx)
 	if err != nil && !errors.Is(sql.ErrNoRows, err) {
 		return categories, fmt.Errorf("getting categories: %w", err)
 	}
 
+	if s.c.Cache.Categories > 0 {
+		if err := s.cacheRepo.SetCategories(ctx, categories, s.c.Cache.Categories); err != nil {
+			log.Println("caching categories: ", err)
+		}
+	}
+
 	return categories, nil
 }
 
-func (s *mockPostService) GetCategories() ([]models.Category, error) {
+func (s *mockPostService) GetCategories(ctx context.Context) ([]models.Category, error) {
 	return []models.Category{}, nil
 }
@@ -1,18 +1,20 @@
 package post
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
 	"github.com/gosimple/slug"
 )
 
-func (s *postService) GetMany(q url.Values) ([]models.Post, *pagination.Meta, error) {
+func (s *postService) GetMany(ctx context.Context, q url.Values) ([]models.Post, *pagination.Meta, error) {
 	var posts []models.Post
 	var pgMeta *pagination.Meta
 	var err error
@@ -24,6 +26,16 @@ func (s *postService) GetMany(q url.Values) ([]models.Post, *pagination.Meta, er
 		limit = 10
 	}
 
+	fields, err := parseList(q, "fields", models.PostListFields, models.PostListDefault)
+	if err != nil {
+		return posts, nil, err
+	}
+
+	include, err := parseList(q, "include", models.PostIncludes, models.PostIncludes)
+	if err != nil {
+		return posts, nil, err
+	}
+
 	filters := models.PostsFilters{
 		Order:    q.Get("order"),
 		Category: q.Get("category"),
@@ -31,10 +43,12 @@ func (s *postService) GetMany(q url.Values) ([]models.Post, *pagination.Meta, er
 		Cursor:   cursor,
 		UserId:   uId,
 		Limit:    limit,
+		Fields:   fields,
+		Include:  include,
 	}
 
 	if filters.Category != "" {
-		c, err := s.postRepo.GetCategoryBySlug(q.Get("category"))
+		c, err := s.postRepo.GetCategoryBySlug(ctx, q.Get("category"))
 		if err != nil {
 			if errors.Is(sql.ErrNoRows, err) {
 				return posts, nil, ErrNoCategory
@@ -55,7 +69,7 @@ func (s *postService) GetMany(q url.Values) ([]models.Post, *pagination.Meta, er
 		// Client could optionally attach "total" parameter to the url.
 		// This skip the below statement to reduce further bottleneck
 		if pgMeta.Total == 0 {
-			total, err := s.postRepo.CountPosts(filters)
+			total, err := s.postRepo.CountPosts(ctx, filters)
 			if err != nil && !errors.Is(sql.ErrNoRows, err) {
 				return posts, nil, fmt.Errorf("counting posts: %w", err)
 			}
@@ -64,21 +78,71 @@ func (s *postService) GetMany(q url.Values) ([]models.Post, *pagination.Meta, er
 		}
 	}
 
-	posts, err = s.postRepo.GetPosts(pgMeta, filters)
+	posts, err = s.postRepo.GetPosts(ctx, pgMeta, filters)
 	if err != nil && !errors.Is(sql.ErrNoRows, err) {
 		return posts, nil, fmt.Errorf("getting posts: %w", err)
 	}
 
+	keys := make([]string, 0, len(fields)+len(include)+1)
+	keys = append(append(keys, fields...), include...)
+	for _, f := range fields {
+		if f == "image" {
+			keys = append(keys, "image_srcset")
+		}
+	}
+
+	for i := range posts {
+		renderMissing(&posts[i])
+		s.withSrcset(&posts[i])
+		posts[i].Select(keys)
+	}
+
 	return posts, pgMeta, nil
 }
 
-func (s *mockPostService) GetMany(q url.Values) ([]models.Post, *pagination.Meta, error) {
+// parseList reads a comma separated query parameter, checking every entry
+// against the allowed ones. The default is used when the parameter is absent.
+func parseList(q url.Values, key string, allowed, def []string) ([]string, error) {
+	if !q.Has(key) {
+		return def, nil
+	}
+
+	valid := make(map[string]bool, len(allowed))
+	for _, a := range allowed {
+		valid[a] = true
+	}
+
+	list := []string{}
+	seen := make(map[string]bool)
+
+	for _, v := range strings.Split(q.Get(key), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[v] {
+			continue
+		}
+
+		if !valid[v] {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidField, v)
+		}
+
+		seen[v] = true
+		list = append(list, v)
+	}
+
+	return list, nil
+}
+
+func (s *mockPostService) GetMany(ctx context.Context, q url.Values) ([]models.Post, *pagination.Meta, error) {
 	posts, pgMeta := []models.Post{}, &pagination.Meta{}
 
 	if q.Has(slug.Make(ErrNoCategory.Error())) {
 		return posts, nil, ErrNoCategory
 	}
 
+	if q.Has(slug.Make(ErrInvalidField.Error())) {
+		return posts, nil, ErrInvalidField
+	}
+
 	if q.Has(slug.Make("unexpected error")) {
 		return posts, nil, errors.New("unexpected error")
 	}
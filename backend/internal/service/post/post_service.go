@@ -1,46 +1,234 @@
 package post
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"log"
 	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/markdown"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/pagination"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
 	ErrDuplicateTitle = errors.New("Title has already been used")
 	ErrNoCategory     = errors.New("Category not found")
 	ErrNoPost         = errors.New("Post not found")
+	ErrMoved          = errors.New("Post has moved")
+	ErrInvalidField   = errors.New("Invalid field")
 	ErrUnauthorized   = errors.New("You have no permission to do that")
-	ErrImageTooLarge  = errors.New("Image is too large (2MB max)")
-	ErrImageInvalid   = errors.New("Invalid type, image should be jpg/jpeg/png")
+	ErrImageTooLarge  = errors.New("Image file is too large")
+	ErrImageSize      = errors.New("Image dimensions are too large (40 megapixels max)")
+	ErrImageInvalid   = errors.New("Invalid type, image should be jpg/jpeg/png/gif/webp, avif isn't supported")
+	ErrStale          = errors.New("Post has been changed since you loaded it")
 )
 
+// sharedReadTimeout bounds a read shared by concurrent callers
+const sharedReadTimeout = 10 * time.Second
+
+// StaleError is ErrStale along with the post's current version
+type StaleError struct {
+	Version int
+}
+
+func (e *StaleError) Error() string { return ErrStale.Error() }
+func (e *StaleError) Unwrap() error { return ErrStale }
+
 type PostService interface {
-	Create(payload models.PostCreateInput, authId int) (models.Post, error)
-	Get(slug string) (models.Post, error)
-	GetMany(q url.Values) ([]models.Post, *pagination.Meta, error)
-	Update(payload models.PostUpdateInput, urlSlug string, authId int) error
-	Delete(slug string, authId int) error
-	GetCategories() ([]models.Category, error)
+	Create(ctx context.Context, payload models.PostCreateInput, authId int) (models.Post, error)
+	Get(ctx context.Context, slug string) (models.Post, error)
+	GetMany(ctx context.Context, q url.Values) ([]models.Post, *pagination.Meta, error)
+	Update(ctx context.Context, payload models.PostUpdateInput, urlSlug string, authId int) error
+	Delete(ctx context.Context, slug string, authId int, client audit.Client) error
+	GetTrash(ctx context.Context, authId int) ([]models.Post, error)
+	Restore(ctx context.Context, slug string, authId int, client audit.Client) error
+	PurgeTrash(ctx context.Context) (int, error)
+	Backfill(ctx context.Context) (int, error)
+	GetCategories(ctx context.Context) ([]models.Category, error)
 }
 
 type postService struct {
 	c         *config.AppConfig
 	cacheRepo repository.CacheRepo
 	postRepo  repository.PostRepo
+	txManager repository.TxManager
+	store     storage.Store
+	audit     audit.Logger
+	// group lets concurrent misses of a cached read share one query
+	group singleflight.Group
 }
 
-func NewPostService(c *config.AppConfig, cr repository.CacheRepo, pr repository.PostRepo) PostService {
+func NewPostService(c *config.AppConfig, cr repository.CacheRepo, pr repository.PostRepo, tm repository.TxManager, st storage.Store, al audit.Logger) PostService {
 	return &postService{
 		c:         c,
 		cacheRepo: cr,
 		postRepo:  pr,
+		txManager: tm,
+		store:     st,
+		audit:     al,
+	}
+}
+
+// share runs fn once for the concurrent callers with the same key. It runs
+// detached from the first caller's context, so that caller leaving doesn't
+// fail it for the rest, but bounded by sharedReadTimeout. Each caller still
+// returns as soon as its own context is done.
+func (s *postService) share(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ch := s.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedReadTimeout)
+		defer cancel()
+
+		return fn(ctx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.Val, r.Err
+	}
+}
+
+// checkSlug makes sure the slug is not still held in the history of another
+// post, so its old links keep pointing to the right place.
+func (s *postService) checkSlug(ctx context.Context, slug string, postId int) error {
+	redirect, err := s.postRepo.GetSlugRedirect(ctx, slug)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return nil
+		}
+
+		return fmt.Errorf("getting slug redirect: %w", err)
+	}
+
+	if redirect.PostId != postId {
+		return ErrDuplicateTitle
+	}
+
+	return nil
+}
+
+// uncache drops the cached post, which is only logged on failure as the
+// cache expires by itself anyway
+func (s *postService) uncache(ctx context.Context, slug string) {
+	// The write has already gone through, so the entry has to go either way
+	ctx = context.WithoutCancel(ctx)
+
+	if err := s.cacheRepo.DelPost(ctx, slug); err != nil {
+		log.Println("unable to uncache post: ", err)
+	}
+}
+
+// mediaPattern matches the names of uploaded media in the URLs of the content,
+// whatever host or storage they are served from
+var mediaPattern = regexp.MustCompile(`media/([\w-]+\.(?:png|jpe?g|gif|webp))\b`)
+
+// mediaNames lists the media the content references, each only once
+func mediaNames(content string) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+
+	for _, m := range mediaPattern.FindAllStringSubmatch(content, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+
+	return names
+}
+
+const (
+	excerptLength  = 160
+	wordsPerMinute = 200
+)
+
+// render derives the HTML, word count, reading time and, when none was
+// given, the excerpt from the post's Markdown content
+func render(post *models.Post) error {
+	html, headings, err := markdown.Render(post.Content)
+	if err != nil {
+		return err
+	}
+
+	text, err := markdown.PlainText(post.Content)
+	if err != nil {
+		return err
+	}
+
+	post.ContentHTML = html
+	post.TOC = make([]models.TOCEntry, len(headings))
+	for i, h := range headings {
+		post.TOC[i] = models.TOCEntry{Level: h.Level, Text: h.Text, Anchor: h.Anchor}
+	}
+	post.WordCount = len(strings.Fields(text))
+	post.ReadingTime = readingTime(post.WordCount)
+
+	if post.Excerpt == "" {
+		post.Excerpt = excerpt(text, excerptLength)
+	}
+
+	return nil
+}
+
+// renderMissing fills in the derived fields of posts written before they were stored
+func renderMissing(post *models.Post) {
+	if post.Content == "" || (post.ContentHTML != "" && post.WordCount != 0) {
+		return
+	}
+
+	if err := render(post); err != nil {
+		log.Println("rendering content: ", err)
+	}
+}
+
+// excerpt cuts the text down to max characters, on a word boundary if possible
+func excerpt(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+
+	cut := string(runes[:max-1])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
+
+// readingTime estimates the minutes needed to read the given number of words
+func readingTime(words int) int {
+	if words == 0 {
+		return 0
+	}
+
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// withSrcset fills in the URLs of the variants recorded with the post's
+// image, and with its author's avatar
+func (s *postService) withSrcset(post *models.Post) {
+	if post.Image != "" {
+		post.ImageSrcset = img.Srcset(s.store.URL("post/"+post.Image), img.ParseVariants(post.ImageVariants))
+	}
+
+	if post.User.Avatar != "" {
+		post.User.AvatarSrcset = img.Srcset(s.store.URL("avatar/"+post.User.Avatar), img.ParseVariants(post.User.AvatarVariants))
 	}
 }
 
@@ -2,17 +2,27 @@ package post
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
 	"io"
 	"net/url"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
 )
 
 func TestNewPostService(t *testing.T) {
@@ -20,7 +30,7 @@ func TestNewPostService(t *testing.T) {
 	var c *config.AppConfig
 	cr := redis.NewRepo(db)
 	pr := postgres.NewPostRepo(db)
-	service := NewPostService(c, cr, pr)
+	service := NewPostService(c, cr, pr, postgres.NewTxManager(db), storage.NewMockStore(), audit.NewMockLogger())
 
 	typeString := reflect.TypeOf(service).String()
 
@@ -41,10 +51,12 @@ func TestNewMockPostService(t *testing.T) {
 
 func newTestService() PostService {
 	var tc config.AppConfig
+	tc.Uploads.Post = 2 * 1024 * 1024
 	cr := redis.NewMockRepo()
-	ur := postgres.NewMockPostRepo()
+	pr := postgres.NewMockPostRepo()
+	tm := postgres.NewMockTxManager(repository.Repos{Post: pr})
 
-	service := NewPostService(&tc, cr, ur)
+	service := NewPostService(&tc, cr, pr, tm, storage.NewMockStore(), audit.NewMockLogger())
 
 	return service
 }
@@ -66,6 +78,8 @@ func TestPostService_Create(t *testing.T) {
 		{"image too large", "", 0, bytes.NewReader(make([]byte, 2*1024*1024+2)), true},
 		{"error verifying image", "", 0, &bytes.Reader{}, true},
 		{"duplicate title", repository.DuplicateKey, 1, nil, true},
+		{"slug held in history", repository.MovedKey, 1, nil, true},
+		{"error getting slug redirect", repository.IncorrectKey, 1, nil, true},
 		{"error creating post", repository.UnexpectedKey, 1, nil, true},
 	}
 
@@ -76,7 +90,7 @@ func TestPostService_Create(t *testing.T) {
 				CategoryId: tt.categoryId,
 				Image:      tt.image,
 			}
-			_, err := s.Create(payload, 1)
+			_, err := s.Create(context.Background(), payload, 1)
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -89,6 +103,83 @@ func TestPostService_Create(t *testing.T) {
 	}
 }
 
+func TestPostService_RollbackImage(t *testing.T) {
+	var tc config.AppConfig
+	tc.Uploads.Post = 2 * 1024 * 1024
+	st := storage.NewMockStore()
+	pr := postgres.NewMockPostRepo()
+	tm := postgres.NewMockTxManager(repository.Repos{Post: pr})
+	s := NewPostService(&tc, redis.NewMockRepo(), pr, tm, st, audit.NewMockLogger())
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)))
+
+	payload := models.PostCreateInput{
+		Title:      repository.DuplicateKey,
+		CategoryId: 1,
+		Image:      bytes.NewReader(buf.Bytes()),
+	}
+
+	if _, err := s.Create(context.Background(), payload, 1); err == nil {
+		t.Fatal("expecting error")
+	}
+
+	if objects, _ := st.List(context.Background(), "post/"); len(objects) != 0 {
+		t.Errorf("want the image removed, got %d files", len(objects))
+	}
+
+	// Setting the media is part of the same transaction as the post
+	payload.Title = "title"
+	payload.Content = "![](/images/media/" + repository.UnexpectedKey + ".png)"
+	payload.Image = bytes.NewReader(buf.Bytes())
+
+	if _, err := s.Create(context.Background(), payload, 1); err == nil {
+		t.Fatal("expecting error")
+	}
+
+	if objects, _ := st.List(context.Background(), "post/"); len(objects) != 0 {
+		t.Errorf("want the image removed, got %d files", len(objects))
+	}
+
+	payload.Content = ""
+	payload.Image = bytes.NewReader(buf.Bytes())
+
+	if _, err := s.Create(context.Background(), payload, 1); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if objects, _ := st.List(context.Background(), "post/"); len(objects) != 1 {
+		t.Errorf("want the image kept, got %d files", len(objects))
+	}
+}
+
+func TestPostService_SetPostMedia(t *testing.T) {
+	content := "![](/images/media/" + repository.UnexpectedKey + ".png)"
+
+	_, err := s.Create(context.Background(), models.PostCreateInput{CategoryId: 1, Content: content}, 1)
+	if err == nil {
+		t.Error("expecting error creating post")
+	}
+
+	err = s.Update(context.Background(), models.PostUpdateInput{Content: content}, "", 0)
+	if err == nil {
+		t.Error("expecting error updating post")
+	}
+}
+
+func TestMediaNames(t *testing.T) {
+	content := `![a](/images/media/abc-1.png) and ![b](https://cdn.example.com/media/def-2.webp)
+
+![a again](/images/media/abc-1.png), [not media](/images/post/ghi-3.png), media/readme.txt`
+
+	got := mediaNames(content)
+	want := []string{"abc-1.png", "def-2.webp"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
 func TestPostService_Get(t *testing.T) {
 	var tests = []struct {
 		name    string
@@ -97,12 +188,14 @@ func TestPostService_Get(t *testing.T) {
 	}{
 		{"success", "example", false},
 		{"no post", repository.NotFoundKey, true},
+		{"moved", repository.MovedKey, true},
 		{"error getting post", repository.UnexpectedKey, true},
+		{"error getting slug redirect", repository.IncorrectKey, true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := s.Get(tt.slug)
+			_, err := s.Get(context.Background(), tt.slug)
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -115,6 +208,111 @@ func TestPostService_Get(t *testing.T) {
 	}
 }
 
+// countingPostRepo counts the reads that reach the database, and holds them
+// long enough for concurrent ones to overlap. A read whose context is done by
+// then fails.
+type countingPostRepo struct {
+	repository.PostRepo
+	posts, categories atomic.Int32
+}
+
+func (r *countingPostRepo) GetPostBySlug(ctx context.Context, slug string) (models.Post, error) {
+	r.posts.Add(1)
+	time.Sleep(10 * time.Millisecond)
+
+	if err := ctx.Err(); err != nil {
+		return models.Post{}, err
+	}
+
+	post, err := r.PostRepo.GetPostBySlug(ctx, slug)
+	post.Slug = slug
+	return post, err
+}
+
+func (r *countingPostRepo) GetCategories(ctx context.Context) ([]models.Category, error) {
+	r.categories.Add(1)
+	time.Sleep(10 * time.Millisecond)
+
+	return []models.Category{{Name: "Example", Slug: "example"}}, nil
+}
+
+func TestPostService_GetCached(t *testing.T) {
+	var tc config.AppConfig
+	tc.Cache.Post = time.Minute
+	tc.Cache.Categories = time.Minute
+	pr := &countingPostRepo{PostRepo: postgres.NewMockPostRepo()}
+	tm := postgres.NewMockTxManager(repository.Repos{Post: pr})
+	s := NewPostService(&tc, redis.NewMockRepo(), pr, tm, storage.NewMockStore(), audit.NewMockLogger())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Get(context.Background(), "example")
+			s.GetCategories(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if n := pr.posts.Load(); n != 1 {
+		t.Errorf("want concurrent misses to share 1 query, got %d", n)
+	}
+
+	if n := pr.categories.Load(); n != 1 {
+		t.Errorf("want concurrent misses to share 1 categories query, got %d", n)
+	}
+
+	s.Get(context.Background(), "example")
+	if n := pr.posts.Load(); n != 1 {
+		t.Errorf("want the post served from the cache, got %d queries", n)
+	}
+
+	// Updating reads the post once, then drops it from the cache
+	if err := s.Update(context.Background(), models.PostUpdateInput{Title: "example"}, "example", 0); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	s.Get(context.Background(), "example")
+	if n := pr.posts.Load(); n != 3 {
+		t.Errorf("want the post read again after an update, got %d queries", n)
+	}
+
+	if err := s.Delete(context.Background(), "example", 0, audit.Client{}); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	s.Get(context.Background(), "example")
+	if n := pr.posts.Load(); n != 5 {
+		t.Errorf("want the post read again after a delete, got %d queries", n)
+	}
+}
+
+func TestPostService_GetCanceled(t *testing.T) {
+	var tc config.AppConfig
+	pr := &countingPostRepo{PostRepo: postgres.NewMockPostRepo()}
+	tm := postgres.NewMockTxManager(repository.Repos{Post: pr})
+	s := NewPostService(&tc, redis.NewMockRepo(), pr, tm, storage.NewMockStore(), audit.NewMockLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The caller that left returns right away, without failing the shared
+	// read for the caller that joined it
+	if _, err := s.Get(ctx, "example"); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+
+	post, err := s.Get(context.Background(), "example")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if post.Slug != "example" {
+		t.Errorf("unexpected post %q", post.Slug)
+	}
+}
+
 func TestPostService_GetMany(t *testing.T) {
 	var tests = []struct {
 		name    string
@@ -122,6 +320,9 @@ func TestPostService_GetMany(t *testing.T) {
 		isError bool
 	}{
 		{"success", url.Values{"page": {"1"}, "total": {"10"}}, false},
+		{"success with fields", url.Values{"fields": {"id, title,content"}, "include": {""}}, false},
+		{"invalid field", url.Values{"fields": {"title,password"}}, true},
+		{"invalid include", url.Values{"include": {"user,comments"}}, true},
 		{"no category", url.Values{"category": {repository.NotFoundKey}}, true},
 		{"error getting category", url.Values{"category": {repository.UnexpectedKey}}, true},
 		{"error creating pagination meta", url.Values{"page": {"-1"}}, true},
@@ -131,7 +332,7 @@ func TestPostService_GetMany(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, _, err := s.GetMany(tt.q)
+			_, _, err := s.GetMany(context.Background(), tt.q)
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -164,7 +365,10 @@ func TestPostService_Update(t *testing.T) {
 		{"image too large", "", 0, bytes.NewReader(make([]byte, 2*1024*1024+2)), "", 0, true},
 		{"error verifying image", "", 0, &bytes.Reader{}, "", 0, true},
 		{"duplicate title", repository.DuplicateKey, 0, nil, "", 0, true},
+		{"slug held in history", repository.MovedKey, 0, nil, "", 0, true},
+		{"error getting slug redirect", repository.IncorrectKey, 0, nil, "", 0, true},
 		{"error updating post", repository.UnexpectedKey, 0, nil, "", 0, true},
+		{"error saving slug history", "new title", 0, nil, "get-invalid-post", 0, true},
 	}
 
 	for _, tt := range tests {
@@ -174,7 +378,7 @@ func TestPostService_Update(t *testing.T) {
 				CategoryId: tt.categoryId,
 				Image:      tt.image,
 			}
-			err := s.Update(payload, tt.urlSlug, tt.authId)
+			err := s.Update(context.Background(), payload, tt.urlSlug, tt.authId)
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -187,6 +391,34 @@ func TestPostService_Update(t *testing.T) {
 	}
 }
 
+func TestPostService_UpdateStale(t *testing.T) {
+	var tests = []struct {
+		name    string
+		title   string
+		version int
+		current int
+	}{
+		{"read an older version", "", 1, 0},
+		{"changed in the meantime", repository.StaleKey, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := models.PostUpdateInput{Title: tt.title, Version: tt.version}
+			err := s.Update(context.Background(), payload, "", 0)
+
+			var staleErr *StaleError
+			if !errors.As(err, &staleErr) || !errors.Is(err, ErrStale) {
+				t.Fatalf("want StaleError, got %v", err)
+			}
+
+			if staleErr.Version != tt.current {
+				t.Errorf("want current version %d, got %d", tt.current, staleErr.Version)
+			}
+		})
+	}
+}
+
 func TestPostService_Delete(t *testing.T) {
 	var tests = []struct {
 		name    string
@@ -203,7 +435,7 @@ func TestPostService_Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := s.Delete(tt.slug, tt.authId)
+			err := s.Delete(context.Background(), tt.slug, tt.authId, audit.Client{})
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got %v", err)
@@ -215,3 +447,250 @@ func TestPostService_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestPostService_GetTrash(t *testing.T) {
+	var tests = []struct {
+		name    string
+		authId  int
+		isError bool
+	}{
+		{"success", 1, false},
+		{"error getting trashed posts", repository.UnexpectedKeyInt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.GetTrash(context.Background(), tt.authId)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+		})
+	}
+}
+
+func TestPostService_Restore(t *testing.T) {
+	var tests = []struct {
+		name    string
+		slug    string
+		authId  int
+		isError bool
+	}{
+		{"success", "sample", 0, false},
+		{"post not found", repository.NotFoundKey, 0, true},
+		{"error getting post", repository.UnexpectedKey, 0, true},
+		{"unauthorized", "", -1, true},
+		{"error restoring post", "get-invalid-post", 0, true},
+		{"hidden by a moderator", repository.HiddenKey, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Restore(context.Background(), tt.slug, tt.authId, audit.Client{})
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+		})
+	}
+}
+
+func TestPostService_PurgeTrash(t *testing.T) {
+	_, err := s.PurgeTrash(context.Background())
+	if err != nil {
+		t.Errorf("expecting no error, got %v", err)
+	}
+}
+
+// backfillPostRepo serves its posts as unrendered and keeps the ones stored.
+// A post at version 0 stands for one edited since it was read.
+type backfillPostRepo struct {
+	repository.PostRepo
+	posts  []models.Post
+	stored []models.Post
+}
+
+func (r *backfillPostRepo) GetUnrenderedPosts(ctx context.Context, afterId, limit int) ([]models.Post, error) {
+	posts := []models.Post{}
+	for _, p := range r.posts {
+		if p.Id > afterId && len(posts) < limit {
+			posts = append(posts, p)
+		}
+	}
+	return posts, nil
+}
+
+func (r *backfillPostRepo) SetRendered(ctx context.Context, p models.Post) (bool, error) {
+	if ok, err := r.PostRepo.SetRendered(ctx, p); !ok || err != nil || p.Version == 0 {
+		return false, err
+	}
+
+	r.stored = append(r.stored, p)
+	return true, nil
+}
+
+func TestPostService_Backfill(t *testing.T) {
+	pr := &backfillPostRepo{PostRepo: postgres.NewMockPostRepo()}
+	for i := 1; i <= backfillBatch+1; i++ {
+		pr.posts = append(pr.posts, models.Post{Id: i, Content: "## Example\n\nSome content", Version: 1})
+	}
+	pr.posts[1].Version = 0
+
+	var tc config.AppConfig
+	s := NewPostService(&tc, redis.NewMockRepo(), pr, postgres.NewMockTxManager(repository.Repos{Post: pr}), storage.NewMockStore(), audit.NewMockLogger())
+
+	n, err := s.Backfill(context.Background())
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if n != backfillBatch || len(pr.stored) != backfillBatch {
+		t.Fatalf("want %d posts stored past the first batch and without the edited one, got %d", backfillBatch, n)
+	}
+
+	post := pr.stored[0]
+	if post.ContentHTML == "" || post.WordCount != 3 || post.ReadingTime != 1 || len(post.TOC) != 1 || post.Excerpt == "" {
+		t.Errorf("expecting the rendered fields, got %+v", post)
+	}
+
+	pr.posts = []models.Post{{Id: 1, Content: "x", Excerpt: repository.UnexpectedKey, Version: 1}}
+	if _, err := s.Backfill(context.Background()); err == nil {
+		t.Error("expecting error storing the post")
+	}
+}
+
+func TestRender(t *testing.T) {
+	post := models.Post{Content: "## Heading\n\nThe **quick** brown fox jumps over the lazy dog."}
+
+	if err := render(&post); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if post.ContentHTML == "" {
+		t.Error("expecting content html")
+	}
+
+	if post.WordCount != 10 {
+		t.Errorf("want 10 words, got %d", post.WordCount)
+	}
+
+	if post.ReadingTime != 1 {
+		t.Errorf("want 1 minute, got %d", post.ReadingTime)
+	}
+
+	want := []models.TOCEntry{{Level: 2, Text: "Heading", Anchor: "heading"}}
+	if !reflect.DeepEqual(post.TOC, want) {
+		t.Errorf("want %v, got %v", want, post.TOC)
+	}
+
+	if post.Excerpt != "Heading The quick brown fox jumps over the lazy dog." {
+		t.Errorf("unexpected excerpt %q", post.Excerpt)
+	}
+
+	post.Excerpt = "Given"
+	render(&post)
+
+	if post.Excerpt != "Given" {
+		t.Errorf("want the given excerpt to be kept, got %q", post.Excerpt)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	var tests = []struct {
+		name string
+		text string
+		max  int
+		want string
+	}{
+		{"short text", "hello world", 20, "hello world"},
+		{"cut on word boundary", "hello wonderful world", 15, "hello…"},
+		{"trailing punctuation", "hello, wonderful world", 15, "hello…"},
+		{"no spaces", "abcdefghij", 5, "abcd…"},
+		{"multibyte", "héllo wörld ünïcode", 13, "héllo wörld…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := excerpt(tt.text, tt.max); got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	var tests = []struct {
+		words int
+		want  int
+	}{
+		{0, 0},
+		{1, 1},
+		{200, 1},
+		{201, 2},
+		{1000, 5},
+	}
+
+	for _, tt := range tests {
+		if got := readingTime(tt.words); got != tt.want {
+			t.Errorf("readingTime(%d) want %d, got %d", tt.words, tt.want, got)
+		}
+	}
+}
+
+func TestParseList(t *testing.T) {
+	allowed := []string{"a", "b", "c"}
+	def := []string{"a"}
+
+	var tests = []struct {
+		name    string
+		q       url.Values
+		want    []string
+		isError bool
+	}{
+		{"default when absent", url.Values{}, def, false},
+		{"empty when blank", url.Values{"f": {""}}, []string{}, false},
+		{"trimmed and de-duplicated", url.Values{"f": {" b,c,b "}}, []string{"b", "c"}, false},
+		{"not allowed", url.Values{"f": {"a,d"}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseList(tt.q, "f", allowed, def)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+
+			if !tt.isError && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPost_Select(t *testing.T) {
+	post := models.Post{Id: 1, Title: "title", Content: "content"}
+	post.Select([]string{"id", "title", "category"})
+
+	b, err := json.Marshal(post)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	want := `{"category":{"name":"","slug":""},"id":1,"title":"title"}`
+	if string(b) != want {
+		t.Errorf("want %s, got %s", want, b)
+	}
+}
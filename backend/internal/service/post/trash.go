@@ -0,0 +1,108 @@
+package post
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
+	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
+)
+
+func (s *postService) GetTrash(ctx context.Context, authId int) ([]models.Post, error) {
+	posts, err := s.postRepo.GetTrashedPosts(ctx, authId)
+	if err != nil {
+		return posts, fmt.Errorf("getting trashed posts: %w", err)
+	}
+
+	return posts, nil
+}
+
+func (s *postService) Restore(ctx context.Context, slug string, authId int, client audit.Client) error {
+	post, err := s.postRepo.GetTrashedPostBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return ErrNoPost
+		}
+
+		return fmt.Errorf("getting trashed post by slug: %w", err)
+	}
+
+	// Deleted by a moderator, it stays out of reach of the author until purged
+	if post.HiddenAt != nil {
+		return ErrNoPost
+	}
+
+	if authId != post.UserId {
+		return ErrUnauthorized
+	}
+
+	err = s.postRepo.RestorePost(ctx, post.Id)
+	if err != nil {
+		return fmt.Errorf("restoring post: %w", err)
+	}
+
+	s.audit.Log(ctx, models.AuditEvent{
+		ActorId:    authId,
+		Action:     audit.ActionRestorePost,
+		TargetType: audit.TargetPost,
+		TargetId:   post.Id,
+		Metadata:   map[string]interface{}{"title": post.Title, "slug": post.Slug},
+	}, client)
+
+	return nil
+}
+
+// PurgeTrash permanently removes posts that have been in the trash for longer
+// than the retention period, along with their images
+func (s *postService) PurgeTrash(ctx context.Context) (int, error) {
+	posts, err := s.postRepo.PurgePosts(ctx, time.Now().Add(-s.c.TrashRetention))
+	if err != nil {
+		return 0, fmt.Errorf("purging posts: %w", err)
+	}
+
+	for _, post := range posts {
+		if post.Image == "" {
+			continue
+		}
+
+		variants := append(img.ParseVariants(post.ImageVariants), s.c.Images.Post...)
+		err := img.Remove(ctx, s.store, "post/"+post.Image, variants...)
+		if err != nil {
+			log.Println("removing image: ", err)
+		}
+	}
+
+	return len(posts), nil
+}
+
+func (s *mockPostService) GetTrash(ctx context.Context, authId int) ([]models.Post, error) {
+	switch authId {
+	case repository.UnexpectedKeyInt:
+		return nil, errors.New("unexpected error")
+	default:
+		return []models.Post{}, nil
+	}
+}
+
+func (s *mockPostService) Restore(ctx context.Context, slug string, authId int, client audit.Client) error {
+	switch slug {
+	case ErrNoPost.Error():
+		return ErrNoPost
+	case ErrUnauthorized.Error():
+		return ErrUnauthorized
+	case "unexpected error":
+		return errors.New("unexpected error")
+	default:
+		return nil
+	}
+}
+
+func (s *mockPostService) PurgeTrash(ctx context.Context) (int, error) {
+	return 0, nil
+}
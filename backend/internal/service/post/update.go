@@ -1,22 +1,23 @@
 package post
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
 	"github.com/google/uuid"
 	"github.com/gosimple/slug"
 )
 
-func (s *postService) Update(payload models.PostUpdateInput, urlSlug string, authId int) error {
-	post, err := s.postRepo.GetPostBySlug(urlSlug)
+func (s *postService) Update(ctx context.Context, payload models.PostUpdateInput, urlSlug string, authId int) error {
+	post, err := s.postRepo.GetPostBySlug(ctx, urlSlug)
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			return ErrNoPost
@@ -29,8 +30,12 @@ func (s *postService) Update(payload models.PostUpdateInput, urlSlug string, aut
 		return ErrUnauthorized
 	}
 
+	if payload.Version != post.Version {
+		return &StaleError{Version: post.Version}
+	}
+
 	if payload.CategoryId != post.CategoryId {
-		_, err = s.postRepo.GetCategoryById(payload.CategoryId)
+		_, err = s.postRepo.GetCategoryById(ctx, payload.CategoryId)
 		if err != nil {
 			if errors.Is(sql.ErrNoRows, err) {
 				return ErrNoCategory
@@ -40,14 +45,24 @@ func (s *postService) Update(payload models.PostUpdateInput, urlSlug string, aut
 		}
 	}
 
-	var oldImage string
+	var oldImage, oldVariants string
+
+	// Uploads are only kept once the row referencing them is written
+	tx := storage.Begin(s.store)
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Println("unable to roll back images: ", err)
+		}
+	}()
 
 	if payload.Image != nil {
-		ext, err := img.Verify(payload.Image)
+		ext, err := img.Verify(payload.Image, s.c.Uploads.Post)
 		if err != nil {
 			switch err {
 			case img.ErrTooLarge:
-				return ErrImageTooLarge
+				return fmt.Errorf("%w (%s max)", ErrImageTooLarge, img.FormatSize(s.c.Uploads.Post))
+			case img.ErrDimensions:
+				return ErrImageSize
 			case img.ErrType:
 				return ErrImageInvalid
 			default:
@@ -57,29 +72,74 @@ func (s *postService) Update(payload models.PostUpdateInput, urlSlug string, aut
 
 		name := fmt.Sprintf("%s-%d", uuid.New(), authId) + ext
 		oldImage, post.Image = post.Image, name
+		oldVariants, post.ImageVariants = post.ImageVariants, s.c.Images.Post.String()
 
-		err = img.Save(payload.Image, filepath.Join("images", "post", post.Image))
+		err = img.Save(ctx, tx, payload.Image, "post/"+post.Image, s.c.Images.Post...)
 		if err != nil {
 			return fmt.Errorf("saving image: %w", err)
 		}
 	}
 
+	oldSlug := post.Slug
+
 	post.Title = payload.Title
 	post.Slug = slug.Make(payload.Title)
 	post.Excerpt = payload.Excerpt
 	post.Content = payload.Content
 	post.CategoryId = payload.CategoryId
 
-	if err := s.postRepo.UpdatePost(post); err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			return ErrDuplicateTitle
+	if err := render(&post); err != nil {
+		return fmt.Errorf("rendering content: %w", err)
+	}
+
+	if post.Slug != oldSlug {
+		if err := s.checkSlug(ctx, post.Slug, post.Id); err != nil {
+			return err
 		}
+	}
 
-		return fmt.Errorf("updating post: %w", err)
+	// The version is checked again in case the post changed in the meantime
+	err = s.txManager.WithinTx(ctx, func(repos repository.Repos) error {
+		if version, err := repos.Post.UpdatePost(ctx, post); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrStale):
+				return &StaleError{Version: version}
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNoPost
+			case strings.Contains(err.Error(), "duplicate key"):
+				return ErrDuplicateTitle
+			default:
+				return fmt.Errorf("updating post: %w", err)
+			}
+		}
+
+		if err := repos.Post.SetPostMedia(ctx, post.Id, mediaNames(post.Content)); err != nil {
+			return fmt.Errorf("setting post media: %w", err)
+		}
+
+		if post.Slug != oldSlug {
+			err := repos.Post.SaveSlugHistory(ctx, post.Id, oldSlug, post.Slug)
+			if err != nil {
+				return fmt.Errorf("saving slug history: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	tx.Commit()
+	s.uncache(ctx, oldSlug)
+
 	if oldImage != "" {
-		if err := os.Remove(filepath.Join("images", "post", oldImage)); err != nil {
+		// The post is saved already, the old files go even if the client left
+		ctx := context.WithoutCancel(ctx)
+
+		// The configured variants cover an image whose variants weren't recorded
+		variants := append(img.ParseVariants(oldVariants), s.c.Images.Post...)
+		if err := img.Remove(ctx, s.store, "post/"+oldImage, variants...); err != nil {
 			log.Println("unable to delete image: ", err)
 		}
 	}
@@ -87,7 +147,7 @@ func (s *postService) Update(payload models.PostUpdateInput, urlSlug string, aut
 	return nil
 }
 
-func (s *mockPostService) Update(payload models.PostUpdateInput, urlSlug string, authId int) error {
+func (s *mockPostService) Update(ctx context.Context, payload models.PostUpdateInput, urlSlug string, authId int) error {
 	switch urlSlug {
 	case ErrNoPost.Error():
 		return ErrNoPost
@@ -99,8 +159,12 @@ func (s *mockPostService) Update(payload models.PostUpdateInput, urlSlug string,
 		return ErrDuplicateTitle
 	case ErrImageTooLarge.Error():
 		return ErrImageTooLarge
+	case ErrImageSize.Error():
+		return ErrImageSize
 	case ErrImageInvalid.Error():
 		return ErrImageInvalid
+	case ErrStale.Error():
+		return &StaleError{Version: 2}
 	case "unexpected error":
 		return errors.New("unexpected error")
 	default:
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -9,8 +10,8 @@ import (
 	"github.com/gosimple/slug"
 )
 
-func (s *userService) CheckUsername(username string) error {
-	_, err := s.userRepo.GetUser(models.UserFilters{Username: username})
+func (s *userService) CheckUsername(ctx context.Context, username string) error {
+	_, err := s.userRepo.GetUser(ctx, models.UserFilters{Username: username})
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			return nil
@@ -22,7 +23,7 @@ func (s *userService) CheckUsername(username string) error {
 	return ErrDuplicateUsername
 }
 
-func (s *mockUserService) CheckUsername(username string) error {
+func (s *mockUserService) CheckUsername(ctx context.Context, username string) error {
 	switch username {
 	case slug.Make(ErrDuplicateUsername.Error()):
 		return ErrDuplicateUsername
@@ -1,15 +1,41 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"log"
 
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
 )
 
-func (s *userService) Get(username string) (models.User, error) {
-	user, err := s.userRepo.GetUser(models.UserFilters{Username: username})
+func (s *userService) Get(ctx context.Context, username string) (models.User, error) {
+	v, err := s.share(ctx, "profile-"+username, func(ctx context.Context) (interface{}, error) {
+		return s.getCached(ctx, username)
+	})
+
+	user, _ := v.(models.User)
+	return user, err
+}
+
+// getCached reads the profile from the cache, or from the database to be
+// cached when it isn't. The posts count is left to go stale until it expires.
+func (s *userService) getCached(ctx context.Context, username string) (models.User, error) {
+	if s.c.Cache.Profile > 0 {
+		user, err := s.cacheRepo.GetProfile(ctx, username)
+		if err == nil {
+			return user, nil
+		}
+
+		if !errors.Is(err, repository.ErrCacheMiss) {
+			log.Println("getting cached profile: ", err)
+		}
+	}
+
+	user, err := s.userRepo.GetUser(ctx, models.UserFilters{Username: username})
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			return user, ErrNoUser
@@ -20,11 +46,23 @@ func (s *userService) Get(username string) (models.User, error) {
 
 	user.Email = ""
 	user.Password = ""
+	user.SuspensionReason = ""
+
+	// The srcset is cached along, the recorded variants aren't
+	if user.Avatar != "" {
+		user.AvatarSrcset = img.Srcset(s.store.URL("avatar/"+user.Avatar), img.ParseVariants(user.AvatarVariants))
+	}
+
+	if s.c.Cache.Profile > 0 {
+		if err := s.cacheRepo.SetProfile(ctx, user, s.c.Cache.Profile); err != nil {
+			log.Println("caching profile: ", err)
+		}
+	}
 
 	return user, nil
 }
 
-func (s *mockUserService) Get(username string) (models.User, error) {
+func (s *mockUserService) Get(ctx context.Context, username string) (models.User, error) {
 	var user models.User
 	switch username {
 	case ErrNoUser.Error():
@@ -1,21 +1,23 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
 	"strings"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
 	"github.com/Noblefel/ManorTalk/backend/internal/utils/img"
 	"github.com/google/uuid"
 )
 
-func (s *userService) UpdateProfile(payload models.UpdateProfileInput, username string, authId int) (string, error) {
-	user, err := s.userRepo.GetUser(models.UserFilters{Username: username})
+func (s *userService) UpdateProfile(ctx context.Context, payload models.UpdateProfileInput, username string, authId int, client audit.Client) (string, error) {
+	user, err := s.userRepo.GetUser(ctx, models.UserFilters{Username: username})
 	if err != nil {
 		if errors.Is(sql.ErrNoRows, err) {
 			return "", ErrNoUser
@@ -28,18 +30,34 @@ func (s *userService) UpdateProfile(payload models.UpdateProfileInput, username
 		return "", ErrUnauthorized
 	}
 
+	if payload.Version != user.Version {
+		return "", &StaleError{Version: user.Version}
+	}
+
+	oldUsername := user.Username
+
 	user.Name = payload.Name
 	user.Username = payload.Username
 	user.Bio = payload.Bio
 
-	var oldImage string
+	var oldImage, oldVariants string
+
+	// Uploads are only kept once the row referencing them is written
+	tx := storage.Begin(s.store)
+	defer func() {
+		if err := tx.Rollback(ctx); err != nil {
+			log.Println("unable to roll back images: ", err)
+		}
+	}()
 
 	if payload.Avatar != nil {
-		ext, err := img.Verify(payload.Avatar)
+		ext, err := img.Verify(payload.Avatar, s.c.Uploads.Avatar)
 		if err != nil {
 			switch err {
 			case img.ErrTooLarge:
-				return "", ErrAvatarTooLarge
+				return "", fmt.Errorf("%w (%s max)", ErrAvatarTooLarge, img.FormatSize(s.c.Uploads.Avatar))
+			case img.ErrDimensions:
+				return "", ErrAvatarSize
 			case img.ErrType:
 				return "", ErrAvatarInvalid
 			default:
@@ -49,32 +67,73 @@ func (s *userService) UpdateProfile(payload models.UpdateProfileInput, username
 
 		name := fmt.Sprintf("%s-%d", uuid.New(), authId) + ext
 		oldImage, user.Avatar = user.Avatar, name
+		oldVariants, user.AvatarVariants = user.AvatarVariants, s.c.Images.Avatar.String()
 
-		err = img.Save(payload.Avatar, filepath.Join("images", "avatar", user.Avatar))
+		err = img.Save(ctx, tx, payload.Avatar, "avatar/"+user.Avatar, s.c.Images.Avatar...)
 		if err != nil {
 			return "", fmt.Errorf("saving image: %w", err)
 		}
 	}
 
-	err = s.userRepo.UpdateUser(user)
-	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			return "", ErrDuplicateUsername
+	// The version is checked again in case the user changed in the meantime
+	err = s.txManager.WithinTx(ctx, func(repos repository.Repos) error {
+		if version, err := repos.User.UpdateUser(ctx, user); err != nil {
+			switch {
+			case errors.Is(err, repository.ErrStale):
+				return &StaleError{Version: version}
+			case errors.Is(err, sql.ErrNoRows):
+				return ErrNoUser
+			case strings.Contains(err.Error(), "duplicate key"):
+				return ErrDuplicateUsername
+			default:
+				return fmt.Errorf("updating user: %w", err)
+			}
 		}
 
-		return "", fmt.Errorf("updating user: %w", err)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	tx.Commit()
+	s.uncache(ctx, oldUsername)
+
+	// Cached posts carry the author's name and avatar, they're only dropped
+	// once the change is committed so they can't be cached again stale
+	if err := s.cacheRepo.DelPostsByUser(ctx, user.Id); err != nil {
+		log.Println("unable to uncache posts: ", err)
 	}
 
 	if oldImage != "" {
-		if err := os.Remove(filepath.Join("images", "avatar", oldImage)); err != nil {
+		// The profile is saved already, the old files go even if the client left
+		ctx := context.WithoutCancel(ctx)
+
+		// The configured variants cover an avatar whose variants weren't recorded
+		variants := append(img.ParseVariants(oldVariants), s.c.Images.Avatar...)
+		if err := img.Remove(ctx, s.store, "avatar/"+oldImage, variants...); err != nil {
 			log.Println("unable to delete image: ", err)
 		}
 	}
 
+	metadata := map[string]interface{}{"avatar_changed": payload.Avatar != nil}
+	if oldUsername != user.Username {
+		metadata["old_username"] = oldUsername
+		metadata["new_username"] = user.Username
+	}
+
+	s.audit.Log(ctx, models.AuditEvent{
+		ActorId:    authId,
+		Action:     audit.ActionUpdateProfile,
+		TargetType: audit.TargetUser,
+		TargetId:   user.Id,
+		Metadata:   metadata,
+	}, client)
+
 	return user.Avatar, nil
 }
 
-func (s *mockUserService) UpdateProfile(payload models.UpdateProfileInput, username string, authId int) (string, error) {
+func (s *mockUserService) UpdateProfile(ctx context.Context, payload models.UpdateProfileInput, username string, authId int, client audit.Client) (string, error) {
 	switch username {
 	case ErrNoUser.Error():
 		return "", ErrNoUser
@@ -82,10 +141,14 @@ func (s *mockUserService) UpdateProfile(payload models.UpdateProfileInput, usern
 		return "", ErrUnauthorized
 	case ErrAvatarTooLarge.Error():
 		return "", ErrAvatarTooLarge
+	case ErrAvatarSize.Error():
+		return "", ErrAvatarSize
 	case ErrAvatarInvalid.Error():
 		return "", ErrAvatarInvalid
 	case ErrDuplicateUsername.Error():
 		return "", ErrDuplicateUsername
+	case ErrStale.Error():
+		return "", &StaleError{Version: 2}
 	case "unexpected error":
 		return "", errors.New("unexpected error")
 	default:
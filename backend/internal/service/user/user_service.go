@@ -1,40 +1,142 @@
 package user
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"log"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
+	"golang.org/x/sync/singleflight"
 )
 
 var (
 	ErrDuplicateUsername = errors.New("Username already taken")
 	ErrNoUser            = errors.New("User not found")
 	ErrUnauthorized      = errors.New("You have no permission to do that")
-	ErrAvatarTooLarge    = errors.New("Avatar image is too large (2MB max)")
-	ErrAvatarInvalid     = errors.New("Invalid type, avatar should be jpg/jpeg/png")
+	ErrAvatarTooLarge    = errors.New("Avatar image file is too large")
+	ErrAvatarSize        = errors.New("Avatar image dimensions are too large (40 megapixels max)")
+	ErrAvatarInvalid     = errors.New("Invalid type, avatar should be jpg/jpeg/png/gif/webp, avif isn't supported")
+	ErrNotSuspended      = errors.New("User is not suspended")
+	ErrStale             = errors.New("Profile has been changed since you loaded it")
 )
 
+// sharedReadTimeout bounds a read shared by concurrent callers
+const sharedReadTimeout = 10 * time.Second
+
+// StaleError is ErrStale along with the user's current version
+type StaleError struct {
+	Version int
+}
+
+func (e *StaleError) Error() string { return ErrStale.Error() }
+func (e *StaleError) Unwrap() error { return ErrStale }
+
 type UserService interface {
-	CheckUsername(username string) error
-	Get(username string) (models.User, error)
-	UpdateProfile(payload models.UpdateProfileInput, username string, authId int) (string, error)
+	CheckUsername(ctx context.Context, username string) error
+	Get(ctx context.Context, username string) (models.User, error)
+	UpdateProfile(ctx context.Context, payload models.UpdateProfileInput, username string, authId int, client audit.Client) (string, error)
+	Suspend(ctx context.Context, payload models.SuspendInput, username string, authId int, client audit.Client) error
+	Unsuspend(ctx context.Context, username string, authId int, client audit.Client) error
 }
 
 type userService struct {
 	c         *config.AppConfig
 	cacheRepo repository.CacheRepo
 	userRepo  repository.UserRepo
+	txManager repository.TxManager
+	store     storage.Store
+	audit     audit.Logger
+	// group lets concurrent misses of a cached profile share one query
+	group singleflight.Group
 }
 
-func NewUserService(c *config.AppConfig, cr repository.CacheRepo, ur repository.UserRepo) UserService {
+func NewUserService(c *config.AppConfig, cr repository.CacheRepo, ur repository.UserRepo, tm repository.TxManager, st storage.Store, al audit.Logger) UserService {
 	return &userService{
 		c:         c,
 		cacheRepo: cr,
 		userRepo:  ur,
+		txManager: tm,
+		store:     st,
+		audit:     al,
+	}
+}
+
+// share runs fn once for the concurrent callers with the same key. It runs
+// detached from the first caller's context, so that caller leaving doesn't
+// fail it for the rest, but bounded by sharedReadTimeout. Each caller still
+// returns as soon as its own context is done.
+func (s *userService) share(ctx context.Context, key string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ch := s.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sharedReadTimeout)
+		defer cancel()
+
+		return fn(ctx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.Val, r.Err
+	}
+}
+
+// checkAdmin returns ErrUnauthorized unless the user is an admin
+func (s *userService) checkAdmin(ctx context.Context, authId int) error {
+	user, err := s.userRepo.GetUser(ctx, models.UserFilters{Id: authId})
+	if err != nil {
+		if errors.Is(sql.ErrNoRows, err) {
+			return ErrUnauthorized
+		}
+
+		return fmt.Errorf("getting user by id: %w", err)
+	}
+
+	if user.Role != models.RoleAdmin {
+		return ErrUnauthorized
+	}
+
+	return nil
+}
+
+// uncache drops the cached profile, which is only logged on failure as the
+// cache expires by itself anyway
+func (s *userService) uncache(ctx context.Context, username string) {
+	ctx = context.WithoutCancel(ctx)
+
+	if err := s.cacheRepo.DelProfile(ctx, username); err != nil {
+		log.Println("unable to uncache profile: ", err)
+	}
+}
+
+// revoke makes the auth middleware reject the user's live tokens and deletes
+// their refresh token. The suspension is saved by then, so failures are only
+// logged, the auth middleware reads it from the database once its cached
+// answer expires.
+func (s *userService) revoke(ctx context.Context, userId int, until *time.Time) {
+	ctx = context.WithoutCancel(ctx)
+
+	var d time.Duration
+	if until != nil {
+		d = time.Until(*until)
+	}
+
+	if err := s.cacheRepo.SetSuspension(ctx, userId, d); err != nil {
+		log.Println("unable to cache suspension: ", err)
+	}
+
+	if err := s.cacheRepo.DelRefreshToken(ctx, token.Details{UserId: userId}); err != nil {
+		log.Println("unable to delete refresh token: ", err)
 	}
 }
 
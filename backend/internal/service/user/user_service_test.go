@@ -2,15 +2,23 @@ package user
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"reflect"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Noblefel/ManorTalk/backend/internal/audit"
 	"github.com/Noblefel/ManorTalk/backend/internal/config"
 	"github.com/Noblefel/ManorTalk/backend/internal/database"
 	"github.com/Noblefel/ManorTalk/backend/internal/models"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/postgres"
 	"github.com/Noblefel/ManorTalk/backend/internal/repository/redis"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"github.com/Noblefel/ManorTalk/backend/internal/utils/token"
 )
 
 func TestNewUserService(t *testing.T) {
@@ -18,7 +26,7 @@ func TestNewUserService(t *testing.T) {
 	var c *config.AppConfig
 	cr := redis.NewRepo(db)
 	ur := postgres.NewUserRepo(db)
-	service := NewUserService(c, cr, ur)
+	service := NewUserService(c, cr, ur, postgres.NewTxManager(db), storage.NewMockStore(), audit.NewMockLogger())
 
 	typeString := reflect.TypeOf(service).String()
 	if typeString != "*user.userService" {
@@ -38,10 +46,11 @@ func TestNewMockUserService(t *testing.T) {
 
 func newTestService() UserService {
 	var tc config.AppConfig
+	tc.Uploads.Avatar = 2 * 1024 * 1024
 	cr := redis.NewMockRepo()
 	ur := postgres.NewMockUserRepo()
 
-	service := NewUserService(&tc, cr, ur)
+	service := NewUserService(&tc, cr, ur, postgres.NewMockTxManager(repository.Repos{User: ur}), storage.NewMockStore(), audit.NewMockLogger())
 
 	return service
 }
@@ -61,7 +70,7 @@ func TestUserService_CheckUsername(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := s.CheckUsername(tt.username)
+			err := s.CheckUsername(context.Background(), tt.username)
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got: %v", err)
@@ -87,7 +96,7 @@ func TestUserService_Get(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := s.Get(tt.username)
+			_, err := s.Get(context.Background(), tt.username)
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got: %v", err)
@@ -100,6 +109,103 @@ func TestUserService_Get(t *testing.T) {
 	}
 }
 
+// countingUserRepo counts the profile reads that reach the database, and
+// holds them long enough for concurrent ones to overlap. A read whose context
+// is done by then fails.
+type countingUserRepo struct {
+	repository.UserRepo
+	calls atomic.Int32
+}
+
+func (r *countingUserRepo) GetUser(ctx context.Context, filters models.UserFilters) (models.User, error) {
+	if filters.Username != "" {
+		r.calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+
+		if err := ctx.Err(); err != nil {
+			return models.User{}, err
+		}
+	}
+
+	user, err := r.UserRepo.GetUser(ctx, filters)
+	user.Username = filters.Username
+	return user, err
+}
+
+func TestUserService_GetCanceled(t *testing.T) {
+	var tc config.AppConfig
+	ur := &countingUserRepo{UserRepo: postgres.NewMockUserRepo()}
+	s := NewUserService(&tc, redis.NewMockRepo(), ur, postgres.NewMockTxManager(repository.Repos{User: ur}), storage.NewMockStore(), audit.NewMockLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.Get(ctx, "example"); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+
+	user, err := s.Get(context.Background(), "example")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if user.Username != "example" {
+		t.Errorf("unexpected user %q", user.Username)
+	}
+}
+
+func TestUserService_GetCached(t *testing.T) {
+	var tc config.AppConfig
+	tc.Cache.Profile = time.Minute
+	cr := redis.NewMockRepo()
+	ur := &countingUserRepo{UserRepo: postgres.NewMockUserRepo()}
+	s := NewUserService(&tc, cr, ur, postgres.NewMockTxManager(repository.Repos{User: ur}), storage.NewMockStore(), audit.NewMockLogger())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Get(context.Background(), "example")
+		}()
+	}
+	wg.Wait()
+
+	if n := ur.calls.Load(); n != 1 {
+		t.Errorf("want concurrent misses to share 1 query, got %d", n)
+	}
+
+	user, err := s.Get(context.Background(), "example")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if n := ur.calls.Load(); n != 1 {
+		t.Errorf("want the profile served from the cache, got %d queries", n)
+	}
+
+	if user.Password != "" || user.Email != "" {
+		t.Error("expecting private fields left out of the cached profile")
+	}
+
+	// Posts of the author are cached along with their name and avatar
+	cr.SetPost(context.Background(), models.Post{Slug: "post", UserId: 0}, time.Minute)
+
+	payload := models.UpdateProfileInput{Username: "example"}
+	if _, err := s.UpdateProfile(context.Background(), payload, "example", 0, audit.Client{}); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	s.Get(context.Background(), "example")
+	if n := ur.calls.Load(); n != 3 {
+		t.Errorf("want the profile read again after an update, got %d queries", n)
+	}
+
+	if _, err := cr.GetPost(context.Background(), "post"); err != repository.ErrCacheMiss {
+		t.Errorf("want the author's posts uncached, got %v", err)
+	}
+}
+
 func TestUserService_UpdateProfile(t *testing.T) {
 	var tests = []struct {
 		name     string
@@ -158,7 +264,179 @@ func TestUserService_UpdateProfile(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := s.UpdateProfile(tt.payload, tt.username, tt.authId)
+			_, err := s.UpdateProfile(context.Background(), tt.payload, tt.username, tt.authId, audit.Client{})
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got: %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Errorf("expecting error")
+			}
+		})
+	}
+}
+
+func TestUserService_UpdateProfileStale(t *testing.T) {
+	var tests = []struct {
+		name    string
+		nameArg string
+		version int
+		current int
+	}{
+		{"read an older version", "", 1, 0},
+		{"changed in the meantime", repository.StaleKey, 0, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := models.UpdateProfileInput{Name: tt.nameArg, Version: tt.version}
+			_, err := s.UpdateProfile(context.Background(), payload, "", 0, audit.Client{})
+
+			var staleErr *StaleError
+			if !errors.As(err, &staleErr) || !errors.Is(err, ErrStale) {
+				t.Fatalf("want StaleError, got %v", err)
+			}
+
+			if staleErr.Version != tt.current {
+				t.Errorf("want current version %d, got %d", tt.current, staleErr.Version)
+			}
+		})
+	}
+}
+
+// failingTxManager fails every transaction, as a failed commit would
+type failingTxManager struct{}
+
+func (failingTxManager) WithinTx(ctx context.Context, fn func(r repository.Repos) error) error {
+	return errors.New("some error")
+}
+
+// uncachingCacheRepo counts the times the cached posts of a user are dropped
+type uncachingCacheRepo struct {
+	repository.CacheRepo
+	dels *int
+}
+
+func (r uncachingCacheRepo) DelPostsByUser(ctx context.Context, userId int) error {
+	*r.dels++
+	return r.CacheRepo.DelPostsByUser(ctx, userId)
+}
+
+func TestUserService_UpdateProfileTx(t *testing.T) {
+	var tc config.AppConfig
+	var dels int
+	cr := uncachingCacheRepo{redis.NewMockRepo(), &dels}
+	ur := postgres.NewMockUserRepo()
+
+	s := NewUserService(&tc, cr, ur, failingTxManager{}, storage.NewMockStore(), audit.NewMockLogger())
+	if _, err := s.UpdateProfile(context.Background(), models.UpdateProfileInput{}, "", 0, audit.Client{}); err == nil {
+		t.Error("expecting error")
+	}
+
+	if dels != 0 {
+		t.Error("expecting the cached posts kept when the transaction fails")
+	}
+
+	s = NewUserService(&tc, cr, ur, postgres.NewMockTxManager(repository.Repos{User: ur}), storage.NewMockStore(), audit.NewMockLogger())
+	if _, err := s.UpdateProfile(context.Background(), models.UpdateProfileInput{}, "", 0, audit.Client{}); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if dels != 1 {
+		t.Errorf("want the cached posts dropped once committed, got %d", dels)
+	}
+}
+
+func TestUserService_Suspend(t *testing.T) {
+	var tests = []struct {
+		name     string
+		payload  models.SuspendInput
+		username string
+		authId   int
+		isError  bool
+	}{
+		{"success", models.SuspendInput{Reason: "spam", Days: 7}, "test", repository.AdminKeyInt, false},
+		{"success permanently", models.SuspendInput{Reason: "spam"}, "test", repository.AdminKeyInt, false},
+		{"not an admin", models.SuspendInput{}, "test", 1, true},
+		{"moderator", models.SuspendInput{}, "test", repository.ModeratorKeyInt, true},
+		{"error getting admin", models.SuspendInput{}, "test", repository.UnexpectedKeyInt, true},
+		{"user not found", models.SuspendInput{}, repository.NotFoundKey, repository.AdminKeyInt, true},
+		{"error getting user", models.SuspendInput{}, repository.UnexpectedKey, repository.AdminKeyInt, true},
+		{"error suspending user", models.SuspendInput{}, "get-invalid-user", repository.AdminKeyInt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Suspend(context.Background(), tt.payload, tt.username, tt.authId, audit.Client{})
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got: %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Errorf("expecting error")
+			}
+		})
+	}
+}
+
+func TestUserService_SuspendCacheError(t *testing.T) {
+	var tc config.AppConfig
+	var actions []string
+	cr := failingCacheRepo{redis.NewMockRepo()}
+	ur := postgres.NewMockUserRepo()
+	s := NewUserService(&tc, cr, ur, postgres.NewMockTxManager(repository.Repos{User: ur}), storage.NewMockStore(), recordingLogger{&actions})
+
+	err := s.Suspend(context.Background(), models.SuspendInput{Reason: "spam"}, "test", repository.AdminKeyInt, audit.Client{})
+	if err != nil {
+		t.Errorf("expecting no error once the suspension is saved, got %v", err)
+	}
+
+	if !reflect.DeepEqual(actions, []string{audit.ActionSuspend}) {
+		t.Errorf("want the suspension audited, got %v", actions)
+	}
+}
+
+// failingCacheRepo fails to cache a suspension and to revoke refresh tokens
+type failingCacheRepo struct {
+	repository.CacheRepo
+}
+
+func (r failingCacheRepo) SetSuspension(ctx context.Context, userId int, d time.Duration) error {
+	return errors.New("some error")
+}
+
+func (r failingCacheRepo) DelRefreshToken(ctx context.Context, td token.Details) error {
+	return errors.New("some error")
+}
+
+// recordingLogger keeps the actions of the logged audit events
+type recordingLogger struct {
+	actions *[]string
+}
+
+func (l recordingLogger) Log(ctx context.Context, e models.AuditEvent, c audit.Client) {
+	*l.actions = append(*l.actions, e.Action)
+}
+
+func TestUserService_Unsuspend(t *testing.T) {
+	var tests = []struct {
+		name     string
+		username string
+		authId   int
+		isError  bool
+	}{
+		{"success", repository.SuspendedKey, repository.AdminKeyInt, false},
+		{"not an admin", repository.SuspendedKey, 1, true},
+		{"user not found", repository.NotFoundKey, repository.AdminKeyInt, true},
+		{"error getting user", repository.UnexpectedKey, repository.AdminKeyInt, true},
+		{"not suspended", "test", repository.AdminKeyInt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Unsuspend(context.Background(), tt.username, tt.authId, audit.Client{})
 
 			if err != nil && !tt.isError {
 				t.Errorf("expecting no error, got: %v", err)
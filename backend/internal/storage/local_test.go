@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	st := NewLocal(dir, "/images/")
+
+	if err := st.Put(context.Background(), "post/a.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "post", "a.txt")); err != nil {
+		t.Errorf("expecting file inside the directory, got %v", err)
+	}
+
+	r, err := st.Get(context.Background(), "post/a.txt")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+	b, _ := io.ReadAll(r)
+	r.Close()
+
+	if string(b) != "hello" {
+		t.Errorf("want hello, got %q", b)
+	}
+
+	if url := st.URL("post/a.txt"); url != "/images/post/a.txt" {
+		t.Errorf("unexpected url %q", url)
+	}
+
+	if err := st.Delete(context.Background(), "post/a.txt"); err != nil {
+		t.Errorf("expecting no error, got %v", err)
+	}
+
+	if _, err := st.Get(context.Background(), "post/a.txt"); err != ErrNotExist {
+		t.Errorf("want ErrNotExist, got %v", err)
+	}
+
+	if err := st.Delete(context.Background(), "post/a.txt"); err != ErrNotExist {
+		t.Errorf("want ErrNotExist, got %v", err)
+	}
+}
+
+func TestLocal_List(t *testing.T) {
+	dir := t.TempDir()
+	st := NewLocal(dir, "")
+
+	objects, err := st.List(context.Background(), "post/")
+	if err != nil {
+		t.Fatalf("expecting no error on an empty directory, got %v", err)
+	}
+
+	if len(objects) != 0 {
+		t.Errorf("want no objects, got %d", len(objects))
+	}
+
+	st.Put(context.Background(), "post/a.txt", strings.NewReader("hello"), 5, "")
+	st.Put(context.Background(), "post/b.txt", strings.NewReader("hi"), 2, "")
+	st.Put(context.Background(), "avatar/c.txt", strings.NewReader("x"), 1, "")
+
+	objects, err = st.List(context.Background(), "post/")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if len(objects) != 2 {
+		t.Fatalf("want 2 objects, got %d", len(objects))
+	}
+
+	if objects[0].Key != "post/a.txt" || objects[0].Size != 5 || objects[0].ModTime.IsZero() {
+		t.Errorf("unexpected object %+v", objects[0])
+	}
+
+	st = NewLocal(filepath.Join(dir, "missing"), "")
+	if _, err := st.List(context.Background(), ""); err != nil {
+		t.Errorf("expecting no error on a missing directory, got %v", err)
+	}
+}
+
+func TestLocal_KeyEscape(t *testing.T) {
+	dir := t.TempDir()
+	st := NewLocal(filepath.Join(dir, "images"), "")
+
+	if err := st.Put(context.Background(), "../escaped.txt", strings.NewReader("x"), 1, ""); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); err == nil {
+		t.Error("expecting the key to stay inside the directory")
+	}
+}
+
+func TestLocal_Canceled(t *testing.T) {
+	st := NewLocal(t.TempDir(), "")
+	st.Put(context.Background(), "post/a.txt", strings.NewReader("x"), 1, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := st.Put(ctx, "post/b.txt", strings.NewReader("x"), 1, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("expecting put to be canceled, got %v", err)
+	}
+
+	if _, err := st.List(ctx, "post/"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expecting list to be canceled, got %v", err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	var tests = []struct {
+		name    string
+		c       Config
+		isError bool
+	}{
+		{"local by default", Config{}, false},
+		{"s3", Config{Driver: "s3", S3: S3Config{Endpoint: "localhost:9000"}}, false},
+		{"unknown driver", Config{Driver: "ftp"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.c)
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+		})
+	}
+}
+
+func TestConfig_ServePath(t *testing.T) {
+	var tests = []struct {
+		baseURL string
+		want    string
+		isError bool
+	}{
+		{"/images", "/images", false},
+		{"/images/", "/images", false},
+		{"https://cdn.example.com/images", "/images", false},
+		{"", "", true},
+		{"/", "", true},
+		{"images", "", true},
+		{"https://cdn.example.com", "", true},
+		{"http://[::1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.baseURL, func(t *testing.T) {
+			got, err := Config{BaseURL: tt.baseURL}.ServePath()
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+
+			if got != tt.want {
+				t.Errorf("want %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
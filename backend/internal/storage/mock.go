@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+)
+
+// mockStore keeps files in memory, failing on keys that hold
+// repository.UnexpectedKey
+type mockStore struct {
+	mu    sync.Mutex
+	files map[string]Object
+	data  map[string][]byte
+}
+
+func NewMockStore() Store {
+	return &mockStore{
+		files: make(map[string]Object),
+		data:  make(map[string][]byte),
+	}
+}
+
+func (s *mockStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if strings.Contains(key, repository.UnexpectedKey) {
+		return errors.New("some error")
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.files[key] = Object{Key: key, Size: int64(len(b)), ModTime: time.Now()}
+	s.data[key] = b
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *mockStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.data[key]
+	if !ok {
+		return nil, ErrNotExist
+	}
+
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *mockStore) Delete(ctx context.Context, key string) error {
+	if strings.Contains(key, repository.UnexpectedKey) {
+		return errors.New("some error")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.files[key]; !ok {
+		return ErrNotExist
+	}
+
+	delete(s.files, key)
+	delete(s.data, key)
+	return nil
+}
+
+func (s *mockStore) URL(key string) string {
+	return "/images/" + key
+}
+
+func (s *mockStore) List(ctx context.Context, prefix string) ([]Object, error) {
+	if strings.Contains(prefix, repository.UnexpectedKey) {
+		return nil, errors.New("some error")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	objects := []Object{}
+	for key, obj := range s.files {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, obj)
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type s3 struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+// NewS3 stores files in a bucket of any S3 compatible service. Objects are
+// expected to be publicly readable under the configured public URL.
+func NewS3(c S3Config) (Store, error) {
+	endpoint := c.Endpoint
+	secure := c.UseSSL
+
+	// Accept a full URL as well as a bare host
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		endpoint = u.Host
+		secure = u.Scheme == "https"
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(c.AccessKey, c.SecretKey, ""),
+		Secure:       secure,
+		Region:       c.Region,
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3{
+		client:    client,
+		bucket:    c.Bucket,
+		publicURL: strings.TrimSuffix(c.PublicURL, "/"),
+	}, nil
+}
+
+func (s *s3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+
+	return err
+}
+
+func (s *s3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// GetObject is lazy, so the object is only found missing once stat-ed
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotExist
+		}
+
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (s *s3) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+func (s *s3) URL(key string) string {
+	return s.publicURL + "/" + key
+}
+
+func (s *s3) List(ctx context.Context, prefix string) ([]Object, error) {
+	objects := []Object{}
+
+	opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true}
+	for obj := range s.client.ListObjects(ctx, s.bucket, opts) {
+		if obj.Err != nil {
+			return objects, obj.Err
+		}
+
+		objects = append(objects, Object{Key: obj.Key, Size: obj.Size, ModTime: obj.LastModified})
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,186 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a bare stand-in for an S3 compatible service, keeping objects
+// of path-style requests in memory
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") == "" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readBody(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.objects[r.URL.Path] = body
+		w.Header().Set("ETag", `"etag"`)
+	case http.MethodGet, http.MethodHead:
+		if r.URL.Query().Get("list-type") == "2" {
+			f.list(w, r)
+			return
+		}
+
+		b, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+		if r.Method == http.MethodGet {
+			w.Write(b)
+		}
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// list answers ListObjectsV2 with every object of the bucket under the prefix
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	bucket := "/" + strings.Trim(r.URL.Path, "/") + "/"
+	prefix := bucket + r.URL.Query().Get("prefix")
+
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(`<ListBucketResult><IsTruncated>false</IsTruncated>`)
+	for _, key := range keys {
+		fmt.Fprintf(&b,
+			`<Contents><Key>%s</Key><LastModified>2006-01-02T15:04:05.000Z</LastModified><Size>%d</Size><ETag>"etag"</ETag></Contents>`,
+			strings.TrimPrefix(key, bucket), len(f.objects[key]),
+		)
+	}
+	b.WriteString(`</ListBucketResult>`)
+
+	w.Header().Set("Content-Type", "application/xml")
+	io.WriteString(w, b.String())
+}
+
+// readBody decodes aws-chunked uploads, sent when signing the payload in chunks
+func readBody(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var out bytes.Buffer
+	br := bufio.NewReader(r.Body)
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.ParseInt(strings.Split(strings.TrimSpace(line), ";")[0], 16, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+
+		br.Discard(2)
+	}
+}
+
+func TestS3(t *testing.T) {
+	server := httptest.NewServer(&fakeS3{objects: make(map[string][]byte)})
+	defer server.Close()
+
+	st, err := NewS3(S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		Bucket:    "uploads",
+		AccessKey: "key",
+		SecretKey: "secret",
+		PublicURL: "https://cdn.example.com/uploads/",
+	})
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if err := st.Put(context.Background(), "post/a.txt", strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	r, err := st.Get(context.Background(), "post/a.txt")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+	b, _ := io.ReadAll(r)
+	r.Close()
+
+	if string(b) != "hello" {
+		t.Errorf("want hello, got %q", b)
+	}
+
+	if url := st.URL("post/a.txt"); url != "https://cdn.example.com/uploads/post/a.txt" {
+		t.Errorf("unexpected url %q", url)
+	}
+
+	st.Put(context.Background(), "avatar/b.txt", strings.NewReader("hi"), 2, "text/plain")
+
+	objects, err := st.List(context.Background(), "post/")
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	if len(objects) != 1 || objects[0].Key != "post/a.txt" || objects[0].Size != 5 {
+		t.Errorf("unexpected objects %+v", objects)
+	}
+
+	if err := st.Delete(context.Background(), "post/a.txt"); err != nil {
+		t.Errorf("expecting no error, got %v", err)
+	}
+
+	if _, err := st.Get(context.Background(), "post/a.txt"); err != ErrNotExist {
+		t.Errorf("want ErrNotExist, got %v", err)
+	}
+}
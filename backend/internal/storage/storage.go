@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrNotExist = errors.New("object does not exist")
+
+// Store keeps uploaded files under slash separated keys, e.g. "post/abc.png"
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+	// List returns every object whose key starts with the prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Config picks where uploads are kept, either "local" or "s3"
+type Config struct {
+	Driver  string   `yaml:"driver" env:"STORAGE_DRIVER"`
+	Dir     string   `yaml:"dir" env:"STORAGE_DIR"`
+	BaseURL string   `yaml:"base_url" env:"STORAGE_BASE_URL"`
+	S3      S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint" env:"S3_ENDPOINT"`
+	Region    string `yaml:"region" env:"S3_REGION"`
+	Bucket    string `yaml:"bucket" env:"S3_BUCKET"`
+	AccessKey string `yaml:"access_key" env:"S3_ACCESS_KEY" secret:"true"`
+	SecretKey string `yaml:"secret_key" env:"S3_SECRET_KEY" secret:"true"`
+	PublicURL string `yaml:"public_url" env:"S3_PUBLIC_URL"`
+	UseSSL    bool   `yaml:"use_ssl" env:"S3_USE_SSL"`
+}
+
+// ServePath is where the local driver's files are served from, the path of
+// BaseURL so it can also point at a CDN in front of the api. It fails when
+// that path would be empty or the root, which would shadow every route.
+func (c Config) ServePath() (string, error) {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a url", c.BaseURL)
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("%q should have a path such as /images", c.BaseURL)
+	}
+
+	return path, nil
+}
+
+// New returns the store picked by the configured driver
+func New(c Config) (Store, error) {
+	switch c.Driver {
+	case "", "local":
+		return NewLocal(c.Dir, c.BaseURL), nil
+	case "s3":
+		return NewS3(c.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", c.Driver)
+	}
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Tx is a Store that remembers what it puts, so the writes of a request can
+// be undone when the database work they belong to fails. Rollback is meant to
+// be deferred right after Begin, it does nothing once the Tx is committed.
+type Tx struct {
+	Store
+
+	mu        sync.Mutex
+	keys      []string
+	committed bool
+}
+
+func Begin(st Store) *Tx {
+	return &Tx{Store: st}
+}
+
+func (tx *Tx) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if err := tx.Store.Put(ctx, key, r, size, contentType); err != nil {
+		return err
+	}
+
+	tx.mu.Lock()
+	tx.keys = append(tx.keys, key)
+	tx.mu.Unlock()
+
+	return nil
+}
+
+// Commit keeps the files put so far
+func (tx *Tx) Commit() {
+	tx.mu.Lock()
+	tx.committed = true
+	tx.mu.Unlock()
+}
+
+// Rollback deletes the files put since Begin, unless the Tx was committed.
+// It still runs once ctx is canceled, which is often why it's rolled back.
+func (tx *Tx) Rollback(ctx context.Context) error {
+	ctx = context.WithoutCancel(ctx)
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.committed {
+		return nil
+	}
+
+	var errs []error
+	for _, key := range tx.keys {
+		if err := tx.Store.Delete(ctx, key); err != nil && !errors.Is(err, ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	tx.keys = nil
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+)
+
+func TestTx_Rollback(t *testing.T) {
+	st := NewMockStore()
+	st.Put(context.Background(), "post/kept.txt", strings.NewReader("x"), 1, "")
+
+	tx := Begin(st)
+	tx.Put(context.Background(), "post/a.txt", strings.NewReader("x"), 1, "")
+	tx.Put(context.Background(), "post/b.txt", strings.NewReader("x"), 1, "")
+
+	if err := tx.Put(context.Background(), "post/"+repository.UnexpectedKey, strings.NewReader("x"), 1, ""); err == nil {
+		t.Error("expecting error")
+	}
+
+	if err := tx.Rollback(context.Background()); err != nil {
+		t.Errorf("expecting no error, got %v", err)
+	}
+
+	for _, key := range []string{"post/a.txt", "post/b.txt"} {
+		if _, err := st.Get(context.Background(), key); err != ErrNotExist {
+			t.Errorf("want %s removed, got %v", key, err)
+		}
+	}
+
+	if _, err := st.Get(context.Background(), "post/kept.txt"); err != nil {
+		t.Errorf("want files put outside the tx kept, got %v", err)
+	}
+
+	if err := tx.Rollback(context.Background()); err != nil {
+		t.Errorf("expecting rolling back twice to be harmless, got %v", err)
+	}
+}
+
+func TestTx_Commit(t *testing.T) {
+	st := NewMockStore()
+
+	tx := Begin(st)
+	tx.Put(context.Background(), "post/a.txt", strings.NewReader("x"), 1, "")
+	tx.Commit()
+
+	if err := tx.Rollback(context.Background()); err != nil {
+		t.Errorf("expecting no error, got %v", err)
+	}
+
+	if _, err := st.Get(context.Background(), "post/a.txt"); err != nil {
+		t.Errorf("want committed file kept, got %v", err)
+	}
+}
+
+func TestTx_RollbackCanceled(t *testing.T) {
+	st := NewMockStore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx := Begin(st)
+	tx.Put(ctx, "post/a.txt", strings.NewReader("x"), 1, "")
+	cancel()
+
+	if err := tx.Rollback(ctx); err != nil {
+		t.Errorf("expecting no error, got %v", err)
+	}
+
+	if _, err := st.Get(context.Background(), "post/a.txt"); err != ErrNotExist {
+		t.Errorf("want the file removed after the request was canceled, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package img
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"golang.org/x/image/draw"
+)
+
+// framePalette is used for the resized frames of animations, which can't
+// keep the palettes of the original frames
+var framePalette = append(color.Palette{color.Transparent}, palette.WebSafe...)
+
+// countFrames walks the blocks of a GIF without decoding them, so the size of
+// an animation is known before its frames get decoded
+func countFrames(r *bufio.Reader) int {
+	header := make([]byte, 13)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0
+	}
+
+	if header[10]&0x80 != 0 {
+		r.Discard(3 << (header[10]&0x07 + 1))
+	}
+
+	frames := 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return frames
+		}
+
+		switch b {
+		case 0x21: // extension, skipping its label
+			r.Discard(1)
+			skipSubBlocks(r)
+		case 0x2C: // image descriptor
+			frames++
+
+			desc := make([]byte, 9)
+			if _, err := io.ReadFull(r, desc); err != nil {
+				return frames
+			}
+
+			if desc[8]&0x80 != 0 {
+				r.Discard(3 << (desc[8]&0x07 + 1))
+			}
+
+			// Skip the LZW minimum code size before the pixel data
+			r.Discard(1)
+			skipSubBlocks(r)
+		default: // trailer or garbage
+			return frames
+		}
+	}
+}
+
+func skipSubBlocks(r *bufio.Reader) {
+	for {
+		size, err := r.ReadByte()
+		if err != nil || size == 0 {
+			return
+		}
+
+		if _, err := r.Discard(int(size)); err != nil {
+			return
+		}
+	}
+}
+
+// saveAnimation stores an animated GIF as is, and its variants as animated
+// GIF and WebP
+func saveAnimation(ctx context.Context, st storage.Store, g *gif.GIF, key string, variants []Variant) error {
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return err
+	}
+
+	if err := st.Put(ctx, key, &buf, int64(buf.Len()), "image/gif"); err != nil {
+		return err
+	}
+
+	gifs := make([]*gif.GIF, len(variants))
+	webps := make([]*nativewebp.Animation, len(variants))
+	for i := range variants {
+		gifs[i] = &gif.GIF{LoopCount: g.LoopCount}
+		webps[i] = &nativewebp.Animation{LoopCount: webpLoopCount(g.LoopCount)}
+	}
+
+	composite(g, func(i int, frame image.Image) {
+		for j, v := range variants {
+			resized := paletted(resize(frame, v))
+
+			// Each resized frame covers the whole canvas, so it gets cleared
+			// rather than drawn over
+			gifs[j].Image = append(gifs[j].Image, resized)
+			gifs[j].Delay = append(gifs[j].Delay, g.Delay[i])
+			gifs[j].Disposal = append(gifs[j].Disposal, gif.DisposalBackground)
+
+			webps[j].Images = append(webps[j].Images, resized)
+			webps[j].Durations = append(webps[j].Durations, uint(g.Delay[i]*10))
+			webps[j].Disposals = append(webps[j].Disposals, 1)
+		}
+	})
+
+	for i, v := range variants {
+		buf.Reset()
+		if err := gif.EncodeAll(&buf, gifs[i]); err != nil {
+			return err
+		}
+
+		if err := st.Put(ctx, VariantKey(key, v, ".gif"), &buf, int64(buf.Len()), "image/gif"); err != nil {
+			return err
+		}
+
+		buf.Reset()
+		if err := nativewebp.EncodeAll(&buf, webps[i], nil); err != nil {
+			return err
+		}
+
+		if err := st.Put(ctx, VariantKey(key, v, ".webp"), &buf, int64(buf.Len()), "image/webp"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// composite draws each frame onto the canvas following the disposal methods,
+// and calls fn with the complete picture. The canvas is reused between calls.
+func composite(g *gif.GIF, fn func(i int, frame image.Image)) {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	for _, frame := range g.Image {
+		bounds = bounds.Union(frame.Bounds())
+	}
+
+	canvas := image.NewRGBA(bounds)
+	previous := image.NewRGBA(bounds)
+
+	for i, frame := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		if disposal == gif.DisposalPrevious {
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		fn(i, canvas)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			copy(canvas.Pix, previous.Pix)
+		}
+	}
+}
+
+func paletted(img image.Image) *image.Paletted {
+	dst := image.NewPaletted(img.Bounds(), framePalette)
+	draw.FloydSteinberg.Draw(dst, dst.Bounds(), img, img.Bounds().Min)
+	return dst
+}
+
+// webpLoopCount converts the number of GIF repeats to WebP plays, where both
+// use 0 for looping forever
+func webpLoopCount(n int) uint16 {
+	switch {
+	case n == 0:
+		return 0
+	case n < 0:
+		return 1
+	default:
+		return uint16(n + 1)
+	}
+}
@@ -1,85 +1,421 @@
 package img
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"image"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"io"
+	"mime"
 	"net/http"
-	"os"
+	"path"
+	"strconv"
 	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
+	"golang.org/x/image/draw"
 )
 
 var ErrTooLarge = errors.New("too large")
 var ErrType = errors.New("invalid type")
-var max = 2 * 1024 * 1024
+var ErrDimensions = errors.New("dimensions too large")
+
+// Limits against decompression bombs, checked from the headers before any
+// pixel gets decoded. The pixel count of an animation covers all its frames.
+var maxSide = 10000
+var maxPixels = 40_000_000
 
-// Verify will check the file's size and return the extension
-func Verify(r io.ReadSeeker) (string, error) {
-	buff := make([]byte, max+1)
-	n, err := r.Read(buff)
+// Verify will check the file's size, type and dimensions and return the
+// extension. Only the headers are read, the pixels are left to Save.
+func Verify(r io.ReadSeeker, max int64) (string, error) {
+	size, err := r.Seek(0, io.SeekEnd)
 	if err != nil {
 		return "", err
 	}
 
-	if n > max {
+	if size > max {
 		return "", ErrTooLarge
 	}
 
-	_, err = r.Seek(0, 0)
-	if err != nil {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
 		return "", err
 	}
 
-	fileType, err := checkType(buff)
+	// Enough for http.DetectContentType, which never looks further
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	fileType, err := checkType(head[:n])
 	if err != nil {
 		return "", err
 	}
 
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	if _, err := inspect(r); err != nil {
+		return "", err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
 	ext := "." + strings.Split(fileType, "/")[1]
 	return ext, nil
 }
 
-// checkType will check if the file type is PNG or JPG
+// FormatSize writes a size limit for messages, e.g. "2MB" or "512KB"
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return strconv.FormatInt(n>>20, 10) + "MB"
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return strconv.FormatInt(n>>10, 10) + "KB"
+	default:
+		return strconv.FormatInt(n, 10) + "B"
+	}
+}
+
+// checkType will check if the file type is PNG, JPG, GIF or WebP. AVIF is
+// left out, decoding it takes an AV1 decoder the standard library and
+// x/image don't have.
 func checkType(buff []byte) (string, error) {
 	fileType := http.DetectContentType(buff)
 	switch fileType {
-	case "image/png", "image/jpg", "image/jpeg":
+	case "image/png", "image/jpg", "image/jpeg", "image/gif", "image/webp":
 		return fileType, nil
 	default:
 		return "", ErrType
 	}
 }
 
-// Save will store the image locally
-func Save(r io.ReadSeeker, path string) error {
-	out, err := os.Create(path)
+// inspect reads the image headers and returns the format, rejecting images
+// that can't be decoded or are too large to be decoded safely
+func inspect(r io.ReadSeeker) (string, error) {
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return "", ErrType
+	}
+
+	if cfg.Width > maxSide || cfg.Height > maxSide {
+		return "", ErrDimensions
+	}
+
+	frames := 1
+	if format == "gif" {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+
+		frames = countFrames(bufio.NewReader(r))
+	}
+
+	if cfg.Width*cfg.Height*frames > maxPixels {
+		return "", ErrDimensions
+	}
+
+	return format, nil
+}
+
+// Variant is a resized copy of an uploaded image. A variant with a height is
+// cropped to fill it, otherwise it only gets scaled down to the width.
+type Variant struct {
+	Width  int
+	Height int
+}
+
+// String writes the variant as its width, followed by x and the height when
+// it's cropped, e.g. "400" or "64x64"
+func (v Variant) String() string {
+	if v.Height == 0 {
+		return strconv.Itoa(v.Width)
+	}
+
+	return strconv.Itoa(v.Width) + "x" + strconv.Itoa(v.Height)
+}
+
+// Variants is a comma separated list in the config, e.g. "64x64,256x256"
+type Variants []Variant
+
+func (vs Variants) String() string {
+	parts := make([]string, len(vs))
+	for i, v := range vs {
+		parts[i] = v.String()
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (vs Variants) MarshalText() ([]byte, error) {
+	return []byte(vs.String()), nil
+}
+
+func (vs *Variants) UnmarshalText(b []byte) error {
+	parsed := Variants{}
+
+	for _, part := range strings.Split(string(b), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		w, h, cropped := strings.Cut(part, "x")
+
+		var v Variant
+		var err error
+
+		if v.Width, err = strconv.Atoi(w); err != nil {
+			return fmt.Errorf("%q is not a width such as 400 or 64x64", part)
+		}
+
+		if cropped {
+			if v.Height, err = strconv.Atoi(h); err != nil {
+				return fmt.Errorf("%q is not a width such as 400 or 64x64", part)
+			}
+		}
+
+		parsed = append(parsed, v)
+	}
+
+	*vs = parsed
+	return nil
+}
+
+// ParseVariants reads the variants recorded with an image. A malformed record
+// counts as none, so no srcset points at files that may not exist.
+func ParseVariants(text string) Variants {
+	var vs Variants
+	if err := vs.UnmarshalText([]byte(text)); err != nil {
+		return nil
+	}
+
+	return vs
+}
+
+// Save decodes the image and puts it in the store, along with each of its
+// variants in both the original format and WebP. Everything is re-encoded
+// from the pixels, which leaves out any metadata such as EXIF and GPS tags.
+func Save(ctx context.Context, st storage.Store, r io.ReadSeeker, key string, variants ...Variant) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	format, err := inspect(bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
-	defer out.Close()
 
-	if strings.HasSuffix(path, "png") {
-		img, err := png.Decode(r)
+	if format != formatOf(path.Ext(key)) {
+		return ErrType
+	}
+
+	var img image.Image
+
+	switch format {
+	case "gif":
+		g, err := gif.DecodeAll(bytes.NewReader(data))
 		if err != nil {
 			return err
 		}
 
-		err = png.Encode(out, img)
+		if len(g.Image) > 1 {
+			return saveAnimation(ctx, st, g, key, variants)
+		}
+
+		img = g.Image[0]
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
 		if err != nil {
 			return err
 		}
-	} else {
-		img, err := jpeg.Decode(r)
+
+		img = orient(img, orientation(data))
+	default:
+		img, _, err = image.Decode(bytes.NewReader(data))
 		if err != nil {
 			return err
 		}
+	}
 
-		err = jpeg.Encode(out, img, nil)
-		if err != nil {
+	if err := put(ctx, st, img, key); err != nil {
+		return err
+	}
+
+	for _, v := range variants {
+		resized := resize(img, v)
+
+		if err := put(ctx, st, resized, VariantKey(key, v, path.Ext(key))); err != nil {
+			return err
+		}
+
+		if format == "webp" {
+			continue
+		}
+
+		if err := put(ctx, st, resized, VariantKey(key, v, ".webp")); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// Remove deletes the image along with its variants. Missing files are ignored,
+// as images uploaded before a variant was configured don't have it.
+func Remove(ctx context.Context, st storage.Store, key string, variants ...Variant) error {
+	var errs []error
+	for _, k := range Keys(key, variants...) {
+		if err := st.Delete(ctx, k); err != nil && !errors.Is(err, storage.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Keys lists every key Save may write for the image, the original first.
+// Not all of them exist, e.g. a WebP original has no separate WebP variants.
+func Keys(key string, variants ...Variant) []string {
+	keys := []string{key}
+	for _, v := range variants {
+		keys = append(keys, VariantKey(key, v, path.Ext(key)))
+		if path.Ext(key) != ".webp" {
+			keys = append(keys, VariantKey(key, v, ".webp"))
+		}
+	}
+
+	return keys
+}
+
+// VariantKey names the variant after the original, e.g. "abc-400w.webp"
+func VariantKey(key string, v Variant, ext string) string {
+	base := strings.TrimSuffix(key, path.Ext(key))
+	return base + "-" + strconv.Itoa(v.Width) + "w" + ext
+}
+
+// OriginalKey reverses VariantKey, giving the key of the original in the same
+// format along with the variant's width. Whether the variant was cropped
+// can't be told from its key.
+func OriginalKey(key string) (string, int, bool) {
+	ext := path.Ext(key)
+	base := strings.TrimSuffix(key, ext)
+
+	i := strings.LastIndex(base, "-")
+	if i < 0 || !strings.HasSuffix(base, "w") {
+		return "", 0, false
+	}
+
+	w, err := strconv.Atoi(base[i+1 : len(base)-1])
+	if err != nil || w < 1 {
+		return "", 0, false
+	}
+
+	return base[:i] + ext, w, true
+}
+
+// Srcset lists the URLs of the variants by format and width descriptor,
+// ready to be joined into a srcset attribute. Only pass the variants recorded
+// with the image, the configured ones may not have been generated for it.
+func Srcset(url string, variants []Variant) map[string]map[string]string {
+	if url == "" || len(variants) == 0 {
+		return nil
+	}
+
+	ext := path.Ext(url)
+	format := formatOf(ext)
+
+	set := map[string]map[string]string{
+		format: {},
+		"webp": {},
+	}
+
+	for _, v := range variants {
+		w := strconv.Itoa(v.Width) + "w"
+		set[format][w] = VariantKey(url, v, ext)
+		set["webp"][w] = VariantKey(url, v, ".webp")
+	}
+
+	return set
+}
+
+// formatOf names the image format of the extension, as image.Decode does
+func formatOf(ext string) string {
+	format := strings.TrimPrefix(ext, ".")
+	if format == "jpg" {
+		format = "jpeg"
+	}
+
+	return format
+}
+
+func put(ctx context.Context, st storage.Store, img image.Image, key string) error {
+	var buf bytes.Buffer
+
+	if err := encodeTo(&buf, img, path.Ext(key)); err != nil {
+		return err
+	}
+
+	return st.Put(ctx, key, &buf, int64(buf.Len()), mime.TypeByExtension(path.Ext(key)))
+}
+
+func encodeTo(out io.Writer, img image.Image, ext string) error {
+	switch ext {
+	case ".png":
+		return png.Encode(out, img)
+	case ".gif":
+		return gif.Encode(out, img, nil)
+	case ".webp":
+		return nativewebp.Encode(out, img, nil)
+	default:
+		return jpeg.Encode(out, img, nil)
+	}
+}
+
+// resize scales the image to the variant's width, then crops the center to
+// its height if one is set. Images are never scaled up.
+func resize(src image.Image, v Variant) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	crop := b
+	if v.Height > 0 {
+		// Cut the source down to the variant's aspect ratio first
+		if w*v.Height > h*v.Width {
+			cw := h * v.Width / v.Height
+			crop = image.Rect(b.Min.X+(w-cw)/2, b.Min.Y, b.Min.X+(w-cw)/2+cw, b.Max.Y)
+		} else {
+			ch := w * v.Height / v.Width
+			crop = image.Rect(b.Min.X, b.Min.Y+(h-ch)/2, b.Max.X, b.Min.Y+(h-ch)/2+ch)
+		}
+	}
+
+	dw, dh := v.Width, v.Height
+	if dh == 0 {
+		dh = crop.Dy() * v.Width / crop.Dx()
+	}
+
+	if dw >= crop.Dx() {
+		dw, dh = crop.Dx(), crop.Dy()
+	}
+
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+
+	return dst
+}
@@ -2,13 +2,27 @@ package img
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"os"
+	"path"
+	"reflect"
 	"testing"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/Noblefel/ManorTalk/backend/internal/repository"
+	"github.com/Noblefel/ManorTalk/backend/internal/storage"
 )
 
+const maxSize = 2 * 1024 * 1024
+
 func TestVerify(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		f, _ := os.CreateTemp("", "test.png")
@@ -16,7 +30,7 @@ func TestVerify(t *testing.T) {
 		png.Encode(f, image.Rect(0, 0, 1, 1))
 		f.Seek(0, 0)
 
-		s, err := Verify(f)
+		s, err := Verify(f, maxSize)
 		if err != nil {
 			t.Errorf("expecting no error, got %v", err)
 		}
@@ -28,17 +42,17 @@ func TestVerify(t *testing.T) {
 
 	t.Run("fail reading", func(t *testing.T) {
 		var b bytes.Reader
-		_, err := Verify(&b)
+		_, err := Verify(&b, maxSize)
 		if err == nil {
 			t.Error("expecting error")
 		}
 	})
 
 	t.Run("fail too large", func(t *testing.T) {
-		b := make([]byte, max+1)
+		b := make([]byte, maxSize+1)
 		r := bytes.NewReader(b)
 
-		_, err := Verify(r)
+		_, err := Verify(r, maxSize)
 		if err == nil {
 			t.Error("expecting error")
 		}
@@ -48,11 +62,55 @@ func TestVerify(t *testing.T) {
 		b := make([]byte, 1)
 		r := bytes.NewReader(b)
 
-		_, err := Verify(r)
+		_, err := Verify(r, maxSize)
 		if err == nil {
 			t.Error("expecting error")
 		}
 	})
+
+	t.Run("fail undecodable", func(t *testing.T) {
+		// A PNG signature followed by garbage
+		b := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+
+		_, err := Verify(bytes.NewReader(b), maxSize)
+		if err != ErrType {
+			t.Errorf("want %v, got %v", ErrType, err)
+		}
+	})
+}
+
+func TestVerify_Dimensions(t *testing.T) {
+	var tests = []struct {
+		name   string
+		width  int
+		height int
+		frames int
+		err    error
+	}{
+		{"success", 100, 100, 1, nil},
+		{"success animated", 100, 100, 3, nil},
+		{"too wide", maxSide + 1, 1, 1, ErrDimensions},
+		{"too many pixels", 8000, 8000, 1, ErrDimensions},
+		{"too many frames", 2000, 2000, 11, ErrDimensions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Only the header claims the size, as a decompression bomb would
+			b := encodeGIF(10, 10, tt.frames)
+			binary.LittleEndian.PutUint16(b[6:], uint16(tt.width))
+			binary.LittleEndian.PutUint16(b[8:], uint16(tt.height))
+
+			ext, err := Verify(bytes.NewReader(b), maxSize)
+			if err != tt.err {
+				t.Errorf("want %v, got %v", tt.err, err)
+			}
+
+			if err == nil && ext != ".gif" {
+				t.Errorf("want .gif, got %s", ext)
+			}
+		})
+	}
 }
 
 func TestCheckType(t *testing.T) {
@@ -62,7 +120,7 @@ func TestCheckType(t *testing.T) {
 		png.Encode(f, image.Rect(0, 0, 1, 1))
 		f.Seek(0, 0)
 
-		buff := make([]byte, max+1)
+		buff := make([]byte, 512)
 		f.Read(buff)
 
 		fileType, err := checkType(buff)
@@ -81,7 +139,7 @@ func TestCheckType(t *testing.T) {
 		jpeg.Encode(f, image.Rect(0, 0, 1, 1), nil)
 		f.Seek(0, 0)
 
-		buff := make([]byte, max+1)
+		buff := make([]byte, 512)
 		f.Read(buff)
 
 		fileType, err := checkType(buff)
@@ -94,6 +152,31 @@ func TestCheckType(t *testing.T) {
 		}
 	})
 
+	t.Run("return image gif", func(t *testing.T) {
+		fileType, err := checkType(encodeGIF(1, 1, 1))
+		if err != nil {
+			t.Errorf("expecting no error, got %v", err)
+		}
+
+		if fileType != "image/gif" {
+			t.Errorf("got %q, want image/gif", fileType)
+		}
+	})
+
+	t.Run("return image webp", func(t *testing.T) {
+		var buf bytes.Buffer
+		nativewebp.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1)), nil)
+
+		fileType, err := checkType(buf.Bytes())
+		if err != nil {
+			t.Errorf("expecting no error, got %v", err)
+		}
+
+		if fileType != "image/webp" {
+			t.Errorf("got %q, want image/webp", fileType)
+		}
+	})
+
 	t.Run("return invalid type", func(t *testing.T) {
 		_, err := checkType(nil)
 		if err == nil {
@@ -103,8 +186,15 @@ func TestCheckType(t *testing.T) {
 }
 
 func TestSave(t *testing.T) {
-	t.Run("fail no path name", func(t *testing.T) {
-		err := Save(nil, "")
+	st := storage.NewMockStore()
+
+	t.Run("fail putting", func(t *testing.T) {
+		f, _ := os.CreateTemp("", "test.png")
+		defer os.Remove(f.Name())
+		png.Encode(f, image.Rect(0, 0, 1, 1))
+		f.Seek(0, 0)
+
+		err := Save(context.Background(), st, f, repository.UnexpectedKey+".png")
 		if err == nil {
 			t.Error("expecting error")
 		}
@@ -116,7 +206,7 @@ func TestSave(t *testing.T) {
 		png.Encode(f, image.Rect(0, 0, 1, 1))
 		f.Seek(0, 0)
 
-		err := Save(f, "/test.png")
+		err := Save(context.Background(), st, f, "test.png")
 		if err != nil {
 			t.Errorf("expecting no error, got %v", err)
 		}
@@ -127,7 +217,7 @@ func TestSave(t *testing.T) {
 		defer os.Remove(f.Name())
 		jpeg.Encode(f, image.Rect(0, 0, 1, 1), nil)
 
-		err := Save(f, "/test.png")
+		err := Save(context.Background(), st, f, "test.png")
 		if err == nil {
 			t.Errorf("expecting error")
 		}
@@ -139,20 +229,439 @@ func TestSave(t *testing.T) {
 		jpeg.Encode(f, image.Rect(0, 0, 1, 1), nil)
 		f.Seek(0, 0)
 
-		err := Save(f, "/test.jpeg")
+		err := Save(context.Background(), st, f, "test.jpeg")
 		if err != nil {
 			t.Errorf("expecting no error, got %v", err)
 		}
 	})
 
+	t.Run("saving gif", func(t *testing.T) {
+		err := Save(context.Background(), st, bytes.NewReader(encodeGIF(2, 2, 1)), "test.gif", Variant{Width: 1})
+		if err != nil {
+			t.Errorf("expecting no error, got %v", err)
+		}
+	})
+
+	t.Run("saving webp", func(t *testing.T) {
+		var buf bytes.Buffer
+		nativewebp.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2)), nil)
+
+		err := Save(context.Background(), st, bytes.NewReader(buf.Bytes()), "test.webp", Variant{Width: 1})
+		if err != nil {
+			t.Errorf("expecting no error, got %v", err)
+		}
+	})
+
+	t.Run("fail mismatched format", func(t *testing.T) {
+		err := Save(context.Background(), st, bytes.NewReader(encodeGIF(1, 1, 1)), "test.png")
+		if err != ErrType {
+			t.Errorf("want %v, got %v", ErrType, err)
+		}
+	})
+
 	t.Run("fail decoding jpeg", func(t *testing.T) {
 		f, _ := os.CreateTemp("", "test.png")
 		defer os.Remove(f.Name())
 		png.Encode(f, image.Rect(0, 0, 1, 1))
 
-		err := Save(f, "/test.jpeg")
+		err := Save(context.Background(), st, f, "test.jpeg")
 		if err == nil {
 			t.Errorf("expecting error")
 		}
 	})
 }
+
+func TestSave_Variants(t *testing.T) {
+	st := storage.NewMockStore()
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 800, 400)))
+
+	key := "post/test.png"
+	variants := []Variant{{Width: 64, Height: 64}, {Width: 400}, {Width: 1200}}
+
+	if err := Save(context.Background(), st, bytes.NewReader(buf.Bytes()), key, variants...); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	var tests = []struct {
+		key    string
+		width  int
+		height int
+	}{
+		{VariantKey(key, variants[0], ".png"), 64, 64},
+		{VariantKey(key, variants[0], ".webp"), 64, 64},
+		{VariantKey(key, variants[1], ".png"), 400, 200},
+		{VariantKey(key, variants[2], ".png"), 800, 400},
+	}
+
+	for _, tt := range tests {
+		r, err := st.Get(context.Background(), tt.key)
+		if err != nil {
+			t.Fatalf("expecting variant %s, got %v", tt.key, err)
+		}
+
+		cfg, _, err := image.DecodeConfig(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("decoding %s: %v", tt.key, err)
+		}
+
+		if cfg.Width != tt.width || cfg.Height != tt.height {
+			t.Errorf("%s want %dx%d, got %dx%d", tt.key, tt.width, tt.height, cfg.Width, cfg.Height)
+		}
+	}
+
+	// The last variant was never stored, which should be fine
+	if err := Remove(context.Background(), st, key, append(variants, Variant{Width: 10})...); err != nil {
+		t.Errorf("expecting no error, got %v", err)
+	}
+
+	if _, err := st.Get(context.Background(), VariantKey(key, variants[1], ".webp")); err != storage.ErrNotExist {
+		t.Error("expecting variants to be removed")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	variants := []Variant{{Width: 64, Height: 64}, {Width: 400}}
+
+	var pngBuf, webpBuf bytes.Buffer
+	png.Encode(&pngBuf, image.NewRGBA(image.Rect(0, 0, 800, 400)))
+	nativewebp.Encode(&webpBuf, image.NewRGBA(image.Rect(0, 0, 800, 400)), nil)
+
+	var tests = []struct {
+		name string
+		key  string
+		data []byte
+	}{
+		{"png", "post/test.png", pngBuf.Bytes()},
+		{"webp", "post/test.webp", webpBuf.Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := storage.NewMockStore()
+
+			if err := Save(context.Background(), st, bytes.NewReader(tt.data), tt.key, variants...); err != nil {
+				t.Fatalf("expecting no error, got %v", err)
+			}
+
+			objects, _ := st.List(context.Background(), "post/")
+			keys := Keys(tt.key, variants...)
+
+			// Every file Save writes should be accounted for, and nothing else
+			if len(objects) != len(keys) {
+				t.Errorf("want %d keys, got %d", len(objects), len(keys))
+			}
+
+			for _, obj := range objects {
+				found := false
+				for _, k := range keys {
+					found = found || k == obj.Key
+				}
+
+				if !found {
+					t.Errorf("Keys() is missing %s", obj.Key)
+				}
+			}
+		})
+	}
+}
+
+func TestSave_Animation(t *testing.T) {
+	st := storage.NewMockStore()
+
+	key := "post/test.gif"
+	v := Variant{Width: 5}
+
+	if err := Save(context.Background(), st, bytes.NewReader(encodeGIF(10, 10, 3)), key, v); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	for _, k := range []string{key, VariantKey(key, v, ".gif")} {
+		r, err := st.Get(context.Background(), k)
+		if err != nil {
+			t.Fatalf("expecting %s, got %v", k, err)
+		}
+
+		g, err := gif.DecodeAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("decoding %s: %v", k, err)
+		}
+
+		if len(g.Image) != 3 {
+			t.Errorf("%s want 3 frames, got %d", k, len(g.Image))
+		}
+	}
+
+	r, err := st.Get(context.Background(), VariantKey(key, v, ".webp"))
+	if err != nil {
+		t.Fatalf("expecting webp variant, got %v", err)
+	}
+	defer r.Close()
+
+	b, _ := io.ReadAll(r)
+	if !bytes.Contains(b, []byte("ANIM")) {
+		t.Error("expecting webp variant to be animated")
+	}
+}
+
+func TestSave_Orientation(t *testing.T) {
+	st := storage.NewMockStore()
+
+	// Left half red, right half blue, stored as if the camera was turned
+	src := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			c := color.RGBA{0, 0, 255, 255}
+			if x < 8 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			src.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, src, &jpeg.Options{Quality: 100})
+
+	if err := Save(context.Background(), st, bytes.NewReader(withExif(buf.Bytes(), 6)), "test.jpeg"); err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	r, _ := st.Get(context.Background(), "test.jpeg")
+	b, _ := io.ReadAll(r)
+	r.Close()
+
+	if bytes.Contains(b, []byte("Exif")) {
+		t.Error("expecting metadata to be stripped")
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+
+	if img.Bounds().Dx() != 8 || img.Bounds().Dy() != 16 {
+		t.Fatalf("want 8x16, got %dx%d", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	// Rotated clockwise, the left half ends up on top
+	r1, _, b1, _ := img.At(4, 3).RGBA()
+	r2, _, b2, _ := img.At(4, 12).RGBA()
+	if r1 < b1 || b2 < r2 {
+		t.Error("expecting the image to be rotated clockwise")
+	}
+}
+
+func TestOrientation(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.Rect(0, 0, 1, 1), nil)
+	plain := buf.Bytes()
+
+	var tests = []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no exif", plain, 1},
+		{"rotated", withExif(plain, 6), 6},
+		{"mirrored", withExif(plain, 2), 2},
+		{"out of range", withExif(plain, 9), 1},
+		{"truncated", withExif(plain, 6)[:20], 1},
+		{"not a jpeg", []byte("GIF89a"), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orientation(tt.data); got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	src.Set(0, 0, color.White)
+
+	// Where the top left pixel ends up, for each orientation
+	var tests = []struct {
+		o    int
+		x, y int
+	}{
+		{1, 0, 0},
+		{2, 2, 0},
+		{3, 2, 1},
+		{4, 0, 1},
+		{5, 0, 0},
+		{6, 1, 0},
+		{7, 1, 2},
+		{8, 0, 2},
+	}
+
+	for _, tt := range tests {
+		dst := orient(src, tt.o)
+
+		if _, _, _, a := dst.At(tt.x, tt.y).RGBA(); a == 0 {
+			t.Errorf("orientation %d: expecting the pixel at %d,%d", tt.o, tt.x, tt.y)
+		}
+	}
+}
+
+func TestSrcset(t *testing.T) {
+	set := Srcset("/images/post/abc.jpg", []Variant{{Width: 400}})
+
+	if set["jpeg"]["400w"] != "/images/post/abc-400w.jpg" {
+		t.Errorf("unexpected jpeg url %q", set["jpeg"]["400w"])
+	}
+
+	if set["webp"]["400w"] != "/images/post/abc-400w.webp" {
+		t.Errorf("unexpected webp url %q", set["webp"]["400w"])
+	}
+
+	if Srcset("", []Variant{{Width: 400}}) != nil {
+		t.Error("expecting no srcset without an image")
+	}
+}
+
+func TestVariants(t *testing.T) {
+	var tests = []struct {
+		text    string
+		want    Variants
+		isError bool
+	}{
+		{"400, 1200", Variants{{Width: 400}, {Width: 1200}}, false},
+		{"64x64,256x256", Variants{{Width: 64, Height: 64}, {Width: 256, Height: 256}}, false},
+		{"", Variants{}, false},
+		{"wide", nil, true},
+		{"64xtall", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var vs Variants
+			err := vs.UnmarshalText([]byte(tt.text))
+
+			if err != nil && !tt.isError {
+				t.Errorf("expecting no error, got %v", err)
+			}
+
+			if err == nil && tt.isError {
+				t.Error("expecting error")
+			}
+
+			if !tt.isError && !reflect.DeepEqual(vs, tt.want) {
+				t.Errorf("want %v, got %v", tt.want, vs)
+			}
+		})
+	}
+
+	text, _ := Variants{{Width: 64, Height: 64}, {Width: 400}}.MarshalText()
+	if string(text) != "64x64,400" {
+		t.Errorf("unexpected text %q", text)
+	}
+}
+
+func TestOriginalKey(t *testing.T) {
+	var tests = []struct {
+		key   string
+		want  string
+		width int
+		ok    bool
+	}{
+		{"post/abc-1-400w.jpg", "post/abc-1.jpg", 400, true},
+		{"post/abc-1-400w.webp", "post/abc-1.webp", 400, true},
+		{"post/abc-1.jpg", "", 0, false},
+		{"post/abc-w.jpg", "", 0, false},
+		{"post/abc-0w.jpg", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, width, ok := OriginalKey(tt.key)
+
+			if got != tt.want || width != tt.width || ok != tt.ok {
+				t.Errorf("want %q %d %v, got %q %d %v", tt.want, tt.width, tt.ok, got, width, ok)
+			}
+
+			if ok && VariantKey(got, Variant{Width: width}, path.Ext(tt.key)) != tt.key {
+				t.Error("expecting VariantKey to give the key back")
+			}
+		})
+	}
+}
+
+func TestParseVariants(t *testing.T) {
+	if vs := ParseVariants("400,1200"); !reflect.DeepEqual(vs, Variants{{Width: 400}, {Width: 1200}}) {
+		t.Errorf("unexpected variants %v", vs)
+	}
+
+	if vs := ParseVariants("400,wide"); vs != nil {
+		t.Errorf("expecting no variants from a malformed record, got %v", vs)
+	}
+
+	if Srcset("/images/post/abc.jpg", ParseVariants("")) != nil {
+		t.Error("expecting no srcset without recorded variants")
+	}
+}
+
+// encodeGIF makes a GIF with the given number of frames
+func encodeGIF(width, height, frames int) []byte {
+	g := &gif.GIF{}
+	for i := 0; i < frames; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, width, height), palette.Plan9)
+		frame.SetColorIndex(i%width, 0, uint8(i+1))
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, 10)
+	}
+
+	var buf bytes.Buffer
+	gif.EncodeAll(&buf, g)
+	return buf.Bytes()
+}
+
+// withExif inserts an EXIF segment with the orientation and a GPS tag right
+// after the start of the JPEG
+func withExif(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 2)
+
+	// Orientation, as a single SHORT
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0)
+
+	// GPS IFD pointer, its content doesn't matter here
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x8825)
+	tiff = binary.BigEndian.AppendUint16(tiff, 4)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint32(tiff, 0)
+	tiff = binary.BigEndian.AppendUint32(tiff, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestFormatSize(t *testing.T) {
+	var tests = []struct {
+		n    int64
+		want string
+	}{
+		{2 << 20, "2MB"},
+		{512 << 10, "512KB"},
+		{1500, "1500B"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSize(tt.n); got != tt.want {
+			t.Errorf("want %s, got %s", tt.want, got)
+		}
+	}
+}
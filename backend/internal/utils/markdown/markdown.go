@@ -0,0 +1,141 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gosimple/slug"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+var md = goldmark.New(
+	goldmark.WithExtensions(
+		extension.Table,
+		extension.TaskList,
+		extension.Strikethrough,
+		extension.Linkify,
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+	),
+)
+
+// Heading is an entry of the table of contents
+type Heading struct {
+	Level  int
+	Text   string
+	Anchor string
+}
+
+var policy = newPolicy()
+var strict = bluemonday.StrictPolicy()
+
+// newPolicy builds the allowlist on top of bluemonday's policy for user
+// generated content, with the few extras GFM output needs.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// Fenced code blocks mark their language as "language-go" and such
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+
+	// Task list items render as disabled checkboxes
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^$|^checked$|^disabled$`)).OnElements("input")
+
+	// Heading anchors, as generated by ids
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[a-z0-9-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+
+	return p
+}
+
+// Render converts CommonMark (with GFM tables, task lists and strikethrough)
+// into sanitized HTML, along with the table of contents built from its headings
+func Render(src string) (string, []Heading, error) {
+	var buf bytes.Buffer
+
+	source := []byte(src)
+	ctx := parser.NewContext(parser.WithIDs(newIDs()))
+	doc := md.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+
+	toc := []Heading{}
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+
+		anchor, _ := h.AttributeString("id")
+		id, _ := anchor.([]byte)
+
+		toc = append(toc, Heading{
+			Level:  h.Level,
+			Text:   string(h.Text(source)),
+			Anchor: string(id),
+		})
+
+		return ast.WalkSkipChildren, nil
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		return "", nil, err
+	}
+
+	return policy.Sanitize(buf.String()), toc, nil
+}
+
+// PlainText strips all formatting, leaving the words separated by single spaces
+func PlainText(src string) (string, error) {
+	var buf bytes.Buffer
+
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+
+	text := html.UnescapeString(strict.Sanitize(buf.String()))
+	return strings.Join(strings.Fields(text), " "), nil
+}
+
+// ids generates heading anchors from their slug. Repeated headings get a
+// numbered suffix, so anchors stay stable as long as the headings before
+// them are unchanged.
+type ids struct {
+	used map[string]bool
+}
+
+func newIDs() *ids {
+	return &ids{used: make(map[string]bool)}
+}
+
+func (s *ids) Generate(value []byte, kind ast.NodeKind) []byte {
+	base := slug.Make(string(value))
+	if base == "" {
+		base = "section"
+	}
+
+	id := base
+	for i := 1; s.used[id]; i++ {
+		id = base + "-" + strconv.Itoa(i)
+	}
+
+	s.used[id] = true
+	return []byte(id)
+}
+
+func (s *ids) Put(value []byte) {
+	s.used[string(value)] = true
+}
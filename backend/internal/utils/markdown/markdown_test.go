@@ -0,0 +1,88 @@
+package markdown
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	var tests = []struct {
+		name    string
+		src     string
+		want    []string
+		notWant []string
+	}{
+		{"heading", "# Title", []string{`<h1 id="title">Title</h1>`}, nil},
+		{"fenced code", "```go\nfmt.Println()\n```", []string{`<code class="language-go">`}, nil},
+		{"table", "| a | b |\n| - | - |\n| 1 | 2 |", []string{"<table>", "<td>1</td>"}, nil},
+		{"task list", "- [x] done\n- [ ] todo", []string{`type="checkbox"`, "checked"}, nil},
+		{"strikethrough", "~~old~~", []string{"<del>old</del>"}, nil},
+		{"script stripped", "hello <script>alert(1)</script>", nil, []string{"<script"}},
+		{"event handler stripped", `<img src="x.png" onerror="alert(1)">`, nil, []string{"onerror"}},
+		{"javascript link stripped", "[click](javascript:alert(1))", nil, []string{"javascript:"}},
+		{"links are nofollow", "[site](https://example.com)", []string{`rel="nofollow noopener"`}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, _, err := Render(tt.src)
+			if err != nil {
+				t.Fatalf("expecting no error, got %v", err)
+			}
+
+			for _, s := range tt.want {
+				if !strings.Contains(html, s) {
+					t.Errorf("want %q in %q", s, html)
+				}
+			}
+
+			for _, s := range tt.notWant {
+				if strings.Contains(html, s) {
+					t.Errorf("not expecting %q in %q", s, html)
+				}
+			}
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	src := "# Title\n\nSome **bold** & [linked](https://example.com) text.\n\n- one\n- two"
+
+	text, err := PlainText(src)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	want := "Title Some bold & linked text. one two"
+	if text != want {
+		t.Errorf("want %q, got %q", want, text)
+	}
+}
+
+func TestRender_TOC(t *testing.T) {
+	src := "# Intro\n\n## Setup *quickly*\n\ntext\n\n## Setup quickly\n\n### Über\n\n## !!!"
+
+	html, toc, err := Render(src)
+	if err != nil {
+		t.Fatalf("expecting no error, got %v", err)
+	}
+
+	want := []Heading{
+		{1, "Intro", "intro"},
+		{2, "Setup quickly", "setup-quickly"},
+		{2, "Setup quickly", "setup-quickly-1"},
+		{3, "Über", "uber"},
+		{2, "!!!", "section"},
+	}
+
+	if !reflect.DeepEqual(toc, want) {
+		t.Errorf("want %v, got %v", want, toc)
+	}
+
+	for _, h := range want {
+		if !strings.Contains(html, `id="`+h.Anchor+`"`) {
+			t.Errorf("want anchor %q in %q", h.Anchor, html)
+		}
+	}
+}
@@ -17,6 +17,20 @@ type Meta struct {
 	Offset int `json:"offset"`
 }
 
+// MaxLimit is the most rows a single page can ask for
+const MaxLimit = 100
+
+// Limit reads the page size from the limit query parameter, falling back to
+// def when it's missing or below 1. Anything above MaxLimit is capped to it.
+func Limit(q url.Values, def int) int {
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit < 1 {
+		return def
+	}
+
+	return min(limit, MaxLimit)
+}
+
 // NewMeta creates a new instance for pagination meta
 func NewMeta(q url.Values, limit int) (*Meta, error) {
 	var err error
@@ -53,3 +53,27 @@ func TestMeta_SetNewTotal(t *testing.T) {
 		}
 	})
 }
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit string
+		want  int
+	}{
+		{"valid", "30", 30},
+		{"max", "100", 100},
+		{"missing", "", 20},
+		{"invalid", "x", 20},
+		{"zero", "0", 20},
+		{"negative", "-5", 20},
+		{"too large", "101", 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Limit(url.Values{"limit": {tt.limit}}, 20); got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package response
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type Response struct {
@@ -27,3 +31,88 @@ func JSON(w http.ResponseWriter, code int, res Response) {
 func Message(w http.ResponseWriter, code int, msg string) {
 	JSON(w, code, Response{Message: msg})
 }
+
+// ConditionalJSON writes res with 200 like JSON does, along with a strong
+// ETag hashed from the body and, unless modified is zero, a Last-Modified.
+// A 304 without a body is written instead when the request's If-None-Match,
+// or failing that its If-Modified-Since, shows the client is up to date.
+func ConditionalJSON(w http.ResponseWriter, r *http.Request, modified time.Time, res Response) {
+	jsonBytes, err := json.Marshal(res)
+	if err != nil {
+		http.Error(w, "Error encoding JSON response", http.StatusInternalServerError)
+		return
+	}
+
+	etag := hashETag(jsonBytes)
+
+	w.Header().Set("ETag", etag)
+	if !modified.IsZero() {
+		w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(jsonBytes)
+}
+
+// ETag is the one ConditionalJSON sends along with res, for the handlers
+// checking an If-Match against the current representation
+func ETag(res Response) (string, error) {
+	jsonBytes, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+
+	return hashETag(jsonBytes), nil
+}
+
+func hashETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// MatchETag evaluates an If-Match header, which uses the strong comparison
+// so a weak tag never matches (RFC 9110 13.1.1)
+func MatchETag(ifMatch, etag string) bool {
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified evaluates the preconditions of a GET, If-Modified-Since being
+// ignored when If-None-Match is sent (RFC 9110 13.2.2)
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			// If-None-Match uses the weak comparison
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+
+	// The header only has a precision of seconds
+	return !modified.Truncate(time.Second).After(ims)
+}
@@ -3,7 +3,9 @@ package response
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 var jsonTests = []struct {
@@ -40,3 +42,104 @@ func TestMessage(t *testing.T) {
 		t.Errorf("want 200, got %d", w.Code)
 	}
 }
+
+func TestConditionalJSON(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)
+	payload := Response{Data: "example"}
+
+	w := httptest.NewRecorder()
+	ConditionalJSON(w, httptest.NewRequest("GET", "/", nil), modified, payload)
+	etag := w.Header().Get("ETag")
+
+	if w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Fatalf("want 200 with a body, got %d", w.Code)
+	}
+
+	if etag == "" || !strings.HasPrefix(etag, `"`) {
+		t.Errorf("want a strong etag, got %q", etag)
+	}
+
+	if lm := w.Header().Get("Last-Modified"); lm != "Tue, 02 Jan 2024 03:04:05 GMT" {
+		t.Errorf("unexpected last modified %q", lm)
+	}
+
+	var tests = []struct {
+		name       string
+		method     string
+		header     map[string]string
+		statusCode int
+	}{
+		{"no preconditions", "GET", nil, http.StatusOK},
+		{"matching etag", "GET", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"matching weak etag", "GET", map[string]string{"If-None-Match": `"other", W/` + etag}, http.StatusNotModified},
+		{"any etag", "GET", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"other etag", "GET", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"not modified since", "GET", map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT"}, http.StatusNotModified},
+		{"modified since", "GET", map[string]string{"If-Modified-Since": "Tue, 02 Jan 2024 03:04:04 GMT"}, http.StatusOK},
+		{"invalid date", "GET", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"etag over date", "GET", map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": "Tue, 02 Jan 2024 03:04:05 GMT",
+		}, http.StatusOK},
+		{"not a read", "POST", map[string]string{"If-None-Match": etag}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			ConditionalJSON(w, r, modified, payload)
+
+			if w.Code != tt.statusCode {
+				t.Errorf("want %d, got %d", tt.statusCode, w.Code)
+			}
+
+			if w.Code == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Error("expecting no body")
+			}
+
+			if w.Header().Get("ETag") != etag {
+				t.Error("expecting the same etag for the same body")
+			}
+		})
+	}
+
+	w = httptest.NewRecorder()
+	ConditionalJSON(w, httptest.NewRequest("GET", "/", nil), time.Time{}, Response{Data: "other"})
+
+	if w.Header().Get("ETag") == etag {
+		t.Error("expecting a different etag for a different body")
+	}
+
+	if w.Header().Get("Last-Modified") != "" {
+		t.Error("expecting no last modified without a time")
+	}
+}
+
+func TestETag(t *testing.T) {
+	payload := Response{Data: "example"}
+
+	w := httptest.NewRecorder()
+	ConditionalJSON(w, httptest.NewRequest("GET", "/", nil), time.Time{}, payload)
+
+	etag, err := ETag(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if etag != w.Header().Get("ETag") {
+		t.Errorf("want the etag sent by ConditionalJSON %q, got %q", w.Header().Get("ETag"), etag)
+	}
+
+	if !MatchETag(`"x", `+etag, etag) || !MatchETag("*", etag) {
+		t.Error("want If-Match to match the etag")
+	}
+
+	if MatchETag("W/"+etag, etag) {
+		t.Error("want a weak etag not to match")
+	}
+}
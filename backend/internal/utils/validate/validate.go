@@ -14,6 +14,7 @@ var InputErrMessage = map[string]string{
 	"min":         "$field must be atleast $param characters",
 	"max":         "$field must not exceed $param characters",
 	"excludesall": "$field contains unwanted characters",
+	"oneof":       "$field must be one of: $param",
 }
 
 func Struct(i interface{}) *InputError {
@@ -0,0 +1,3 @@
+ALTER TABLE public.users
+    DROP COLUMN role,
+    DROP COLUMN suspended_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE public.users
+    ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user',
+    ADD COLUMN suspended_at TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE public.posts
+    ADD COLUMN hidden_at TIMESTAMP;
\ No newline at end of file
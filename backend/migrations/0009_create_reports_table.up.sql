@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS public.reports (
+    id SERIAL PRIMARY KEY,
+    post_id INT,
+    reporter_id INT NOT NULL,
+    reason VARCHAR(40) NOT NULL,
+    note VARCHAR(1000),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    action VARCHAR(20),
+    moderator_id INT,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP,
+    updated_at TIMESTAMP,
+    CONSTRAINT fk_post
+        FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE SET NULL,
+    CONSTRAINT fk_reporter
+        FOREIGN KEY (reporter_id) REFERENCES users (id) ON DELETE CASCADE,
+    CONSTRAINT fk_moderator
+        FOREIGN KEY (moderator_id) REFERENCES users (id) ON DELETE SET NULL,
+    CONSTRAINT unique_post_reporter
+        UNIQUE (post_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS reports_status_idx ON public.reports (status);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE public.users
+    DROP COLUMN suspended_until,
+    DROP COLUMN suspension_reason;
\ No newline at end of file
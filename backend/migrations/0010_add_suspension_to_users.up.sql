@@ -0,0 +1,3 @@
+ALTER TABLE public.users
+    ADD COLUMN suspended_until TIMESTAMP,
+    ADD COLUMN suspension_reason VARCHAR(255);
\ No newline at end of file
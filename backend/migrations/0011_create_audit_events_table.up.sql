@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS public.audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id INT,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20),
+    target_id INT,
+    ip VARCHAR(45),
+    user_agent VARCHAR(255),
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON public.audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS audit_events_action_idx ON public.audit_events (action);
+CREATE INDEX IF NOT EXISTS audit_events_created_at_idx ON public.audit_events (created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON public.audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS posts_deleted_at_idx;
+
+ALTER TABLE public.posts
+    DROP COLUMN deleted_at;
\ No newline at end of file
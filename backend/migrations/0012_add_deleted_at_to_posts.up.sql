@@ -0,0 +1,4 @@
+ALTER TABLE public.posts
+    ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS posts_deleted_at_idx ON public.posts (deleted_at);
\ No newline at end of file
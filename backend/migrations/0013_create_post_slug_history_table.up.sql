@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS public.post_slug_history (
+    id SERIAL PRIMARY KEY,
+    post_id INT NOT NULL,
+    slug VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP,
+    CONSTRAINT fk_post
+        FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS post_slug_history_post_id_idx ON public.post_slug_history (post_id);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE public.posts
+    DROP COLUMN content_html;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE public.posts
+    ADD COLUMN content_html TEXT;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE public.posts
+    DROP COLUMN word_count,
+    DROP COLUMN reading_time;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE public.posts
+    ADD COLUMN word_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN reading_time INT NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE public.posts
+    DROP COLUMN toc;
\ No newline at end of file
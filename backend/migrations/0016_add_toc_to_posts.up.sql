@@ -0,0 +1,2 @@
+ALTER TABLE public.posts
+    ADD COLUMN toc JSONB;
\ No newline at end of file
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS public.media (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    content_type VARCHAR(40) NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS media_user_id_idx ON public.media (user_id);
+
+CREATE TABLE IF NOT EXISTS public.post_media (
+    post_id INT NOT NULL,
+    media_id INT NOT NULL,
+    PRIMARY KEY (post_id, media_id),
+    CONSTRAINT fk_post
+        FOREIGN KEY (post_id) REFERENCES posts (id) ON DELETE CASCADE,
+    CONSTRAINT fk_media
+        FOREIGN KEY (media_id) REFERENCES media (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS post_media_media_id_idx ON public.post_media (media_id);
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE public.posts
+    DROP COLUMN version;
+
+ALTER TABLE public.users
+    DROP COLUMN version;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE public.posts
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE public.users
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
\ No newline at end of file
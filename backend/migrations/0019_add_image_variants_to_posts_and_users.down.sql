@@ -0,0 +1,5 @@
+ALTER TABLE public.posts
+    DROP COLUMN image_variants;
+
+ALTER TABLE public.users
+    DROP COLUMN avatar_variants;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE public.posts
+    ADD COLUMN image_variants TEXT;
+
+ALTER TABLE public.users
+    ADD COLUMN avatar_variants TEXT;
\ No newline at end of file
@@ -0,0 +1,17 @@
+// Package migrations embeds the SQL migrations and seeders so they ship
+// inside the binaries.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed seeders/*.sql
+var seeders embed.FS
+
+// Seeders holds the seeder files at its root, the same way FS does
+var Seeders, _ = fs.Sub(seeders, "seeders")
@@ -15,6 +15,7 @@ export interface Post {
   content: string;
   created_at?: string;
   updated_at?: string;
+  version?: number;
   category_id: number;
   category: Category;
   user_id: number;
@@ -41,6 +42,7 @@ export interface CreatePost {
   content: string;
   category_id: number;
   image: File | null;
+  version?: number;
 }
 
 export const sampleContent = `### 🌟 Lorem Ipsum 👋
@@ -16,6 +16,7 @@ export interface User {
   password: string;
   created_at?: string;
   updated_at?: string;
+  version?: number;
   posts_count?: number;
   bio?: string;
   posts?: Post[];
@@ -26,6 +27,7 @@ export interface UpdateForm {
   username: string;
   avatar: File | null;
   bio?: string;
+  version?: number;
 }
 
 export const useUserStore = defineStore("user", () => {
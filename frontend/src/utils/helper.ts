@@ -46,16 +46,16 @@ export const getAvatar = (user: User | null) => {
   return `https://ui-avatars.com/api/?name=${name}&background=random&size=120&color=fff`;
 };
 
-/** verifyImage will check if the file is either png/jpeg/jpg and less than 2mb */
+/** verifyImage will check if the file is either png/jpeg/jpg/gif/webp and less than 2mb */
 export const verifyImage = (files: FileList | null) => {
   const isImg =
     files &&
-    ["image/png", "image/jpeg", "image/jpg"].includes(
+    ["image/png", "image/jpeg", "image/jpg", "image/gif", "image/webp"].includes(
       files[0].type.toLowerCase()
     );
 
   if (!isImg) {
-    toast("File must be an image (png,jpg)");
+    toast("File must be an image (png,jpg,gif,webp)");
     return;
   }
 